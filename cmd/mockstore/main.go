@@ -0,0 +1,90 @@
+// Command mockstore serves an in-memory copy of the ecommerce API so the MCP
+// server can be developed without the real backend. Point API_URL at it and
+// use one of the tokens it prints as AUTH_TOKEN, or log in with a fixture
+// user's email and password.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/trenchesdeveloper/mcp-server-store/internal/mockstore"
+)
+
+func main() {
+	addr := flag.String("addr", getEnv("MOCKSTORE_ADDR", "localhost:8080"), "address to listen on")
+	fixtures := flag.String("fixtures", os.Getenv("MOCKSTORE_FIXTURES"), "JSON fixtures file (default: bundled sample data)")
+	secret := flag.String("secret", getEnv("MOCKSTORE_SECRET", "mockstore-dev-secret"), "HMAC key for signing tokens")
+	tokenTTL := flag.Duration("token-ttl", 24*time.Hour, "lifetime of the tokens printed at startup")
+	accessTTL := flag.Duration("access-token-ttl", 15*time.Minute, "lifetime of the tokens issued by /auth/login and /auth/refresh")
+	flag.Parse()
+
+	logger := logrus.New()
+	logger.SetFormatter(&logrus.TextFormatter{
+		FullTimestamp: true,
+	})
+
+	fx := mockstore.DefaultFixtures()
+	if *fixtures != "" {
+		var err error
+		if fx, err = mockstore.LoadFixtures(*fixtures); err != nil {
+			logger.WithError(err).Fatal("Failed to load fixtures")
+		}
+	}
+
+	store := mockstore.NewServer(fx,
+		mockstore.WithSecret([]byte(*secret)),
+		mockstore.WithAccessTokenTTL(*accessTTL),
+		mockstore.WithLogger(logger),
+	)
+
+	for _, user := range store.Users() {
+		token, err := store.IssueToken(user.ID, *tokenTTL)
+		if err != nil {
+			logger.WithError(err).Fatal("Failed to issue token")
+		}
+		logger.WithFields(logrus.Fields{
+			"user":  user.Email,
+			"token": token,
+		}).Info("Issued token")
+	}
+
+	srv := &http.Server{
+		Addr:              *addr,
+		Handler:           store,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+		<-signals
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(ctx)
+	}()
+
+	logger.WithFields(logrus.Fields{
+		"addr":    *addr,
+		"baseURL": "http://" + *addr + mockstore.DefaultBasePath,
+	}).Info("Mock store listening")
+
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		logger.WithError(err).Fatal("Mock store failed")
+	}
+	logger.Info("Mock store stopped")
+}
+
+func getEnv(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
@@ -0,0 +1,272 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/trenchesdeveloper/mcp-server-store/internal/client"
+	"github.com/trenchesdeveloper/mcp-server-store/internal/mcp"
+	"github.com/trenchesdeveloper/mcp-server-store/internal/transcript"
+)
+
+const cliUsage = `Usage: mcp-server <command> [flags]
+
+Without a command the server speaks MCP over stdio. The commands below run
+the registered tools in-process against the configured API_URL instead.
+
+Commands:
+  tools list                               List registered tools
+  tools call <name> [--arg k=v]... [--args JSON]
+                                           Call a tool and print its result
+  resources list                           List registered resources
+  resources read <uri>                     Read a resource
+  prompts list                             List registered prompts
+  prompts get <name> [--arg k=v]...        Resolve a prompt
+  replay <transcript>                      Replay a recorded session, answering
+                                           API calls from the recording, and
+                                           report responses that differ
+
+Flags:
+  --json    Print raw JSON results instead of text
+
+Transcripts do not record Authorization headers. To replay http sessions
+whose clients sent a bearer token, set AUTH_TOKEN to any value so the tools
+run as logged in; the recorded API responses are served either way.
+`
+
+// errToolFailed is returned when a tool ran but reported isError, and
+// errReplayMismatch when a replayed session diverged, so the process can
+// exit non-zero without logging a second error.
+var (
+	errToolFailed     = errors.New("tool returned an error result")
+	errReplayMismatch = errors.New("replayed responses differ from transcript")
+)
+
+// argFlag collects repeated --arg key=value flags.
+type argFlag map[string]string
+
+func (a argFlag) String() string { return fmt.Sprint(map[string]string(a)) }
+
+func (a argFlag) Set(value string) error {
+	key, val, ok := strings.Cut(value, "=")
+	if !ok || key == "" {
+		return fmt.Errorf("expected key=value, got %q", value)
+	}
+	a[key] = val
+	return nil
+}
+
+// runCLI executes a subcommand against the in-process server and writes
+// the output to out.
+func runCLI(ctx context.Context, server *mcp.Server, httpClient *client.RestClient, args []string, out io.Writer) error {
+	if len(args) == 2 && args[0] == "replay" {
+		return runReplay(ctx, server, httpClient, args[1], out)
+	}
+	if len(args) < 2 {
+		fmt.Fprint(out, cliUsage)
+		if len(args) == 1 && (args[0] == "help" || args[0] == "-h" || args[0] == "--help") {
+			return nil
+		}
+		return fmt.Errorf("missing command")
+	}
+
+	group, action, rest := args[0], args[1], args[2:]
+
+	// The first positional argument (tool name, URI, ...) may come before the flags.
+	var target string
+	if len(rest) > 0 && !strings.HasPrefix(rest[0], "-") {
+		target, rest = rest[0], rest[1:]
+	}
+
+	fs := flag.NewFlagSet(group+" "+action, flag.ContinueOnError)
+	fs.SetOutput(out)
+	asJSON := fs.Bool("json", false, "print raw JSON")
+	kv := argFlag{}
+	fs.Var(kv, "arg", "argument as key=value (repeatable)")
+	rawArgs := fs.String("args", "", "arguments as a JSON object")
+	if err := fs.Parse(rest); err != nil {
+		return err
+	}
+	if target == "" && fs.NArg() > 0 {
+		target = fs.Arg(0)
+	}
+
+	needTarget := func(what string) error {
+		if target == "" {
+			return fmt.Errorf("%s %s: missing %s", group, action, what)
+		}
+		return nil
+	}
+
+	switch group + " " + action {
+	case "tools list":
+		tools := server.ListTools()
+		if *asJSON {
+			return printJSON(out, tools)
+		}
+		for _, tool := range tools {
+			fmt.Fprintf(out, "%s\n    %s\n", tool.Name, tool.Description)
+		}
+		return nil
+
+	case "tools call":
+		if err := needTarget("tool name"); err != nil {
+			return err
+		}
+		arguments := map[string]interface{}{}
+		if *rawArgs != "" {
+			if err := json.Unmarshal([]byte(*rawArgs), &arguments); err != nil {
+				return fmt.Errorf("--args: %w", err)
+			}
+		}
+		for k, v := range kv {
+			arguments[k] = v
+		}
+		result, err := server.CallTool(ctx, target, arguments)
+		if err != nil {
+			return err
+		}
+		if *asJSON {
+			err = printJSON(out, result)
+		} else {
+			printContent(out, result.Content)
+		}
+		if err == nil && result.IsError {
+			err = errToolFailed
+		}
+		return err
+
+	case "resources list":
+		resources := server.ListResources()
+		if *asJSON {
+			return printJSON(out, resources)
+		}
+		for _, res := range resources {
+			fmt.Fprintf(out, "%s  %s\n    %s\n", res.URI, res.Name, res.Description)
+		}
+		return nil
+
+	case "resources read":
+		if err := needTarget("resource URI"); err != nil {
+			return err
+		}
+		result, err := server.ReadResource(ctx, target)
+		if err != nil {
+			return err
+		}
+		if *asJSON {
+			return printJSON(out, result)
+		}
+		for _, c := range result.Contents {
+			printResourceContents(out, c)
+		}
+		return nil
+
+	case "prompts list":
+		prompts := server.ListPrompts()
+		if *asJSON {
+			return printJSON(out, prompts)
+		}
+		for _, p := range prompts {
+			fmt.Fprintf(out, "%s\n    %s\n", p.Name, p.Description)
+		}
+		return nil
+
+	case "prompts get":
+		if err := needTarget("prompt name"); err != nil {
+			return err
+		}
+		result, err := server.GetPrompt(ctx, target, kv)
+		if err != nil {
+			return err
+		}
+		if *asJSON {
+			return printJSON(out, result)
+		}
+		if result.Description != "" {
+			fmt.Fprintf(out, "# %s\n\n", result.Description)
+		}
+		for _, msg := range result.Messages {
+			fmt.Fprintf(out, "[%s]\n", msg.Role)
+			printContent(out, []mcp.Content{msg.Content})
+		}
+		return nil
+	}
+
+	fmt.Fprint(out, cliUsage)
+	return fmt.Errorf("unknown command %q", group+" "+action)
+}
+
+// runReplay replays a transcript against the server with API calls served
+// from the recording, printing a diff for every response that changed.
+func runReplay(ctx context.Context, server *mcp.Server, httpClient *client.RestClient, path string, out io.Writer) error {
+	entries, err := transcript.Load(path)
+	if err != nil {
+		return err
+	}
+	replayTransport := transcript.NewReplayTransport(entries)
+	httpClient.SetTransport(replayTransport)
+
+	report, err := transcript.Replay(ctx, entries, server)
+	if err != nil {
+		return err
+	}
+
+	for _, m := range report.Mismatches {
+		request := fmt.Sprintf("id %s", m.ID)
+		if m.Session != "" {
+			request = fmt.Sprintf("session %s, %s", m.Session, request)
+		}
+		fmt.Fprintf(out, "--- %s (%s): response differs\n%s\n", request, m.Method, m.Diff())
+	}
+	fmt.Fprintf(out, "%d requests replayed, %d differ", report.Requests, len(report.Mismatches))
+	if n := replayTransport.Remaining(); n > 0 {
+		fmt.Fprintf(out, ", %d recorded API calls unused", n)
+	}
+	fmt.Fprintln(out)
+
+	if len(report.Mismatches) > 0 {
+		return errReplayMismatch
+	}
+	return nil
+}
+
+func printJSON(out io.Writer, v interface{}) error {
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// printContent renders content blocks for a terminal.
+func printContent(out io.Writer, content []mcp.Content) {
+	for _, c := range content {
+		switch c.Type {
+		case mcp.ContentTypeText:
+			fmt.Fprintln(out, strings.TrimRight(c.Text, "\n"))
+		case mcp.ContentTypeImage, mcp.ContentTypeAudio:
+			fmt.Fprintf(out, "[%s %s, %d bytes base64]\n", c.Type, c.MimeType, len(c.Data))
+		case mcp.ContentTypeResource:
+			if c.Resource != nil {
+				printResourceContents(out, *c.Resource)
+			}
+		case mcp.ContentTypeResourceLink:
+			fmt.Fprintf(out, "-> %s <%s>\n", c.Name, c.URI)
+		default:
+			fmt.Fprintf(out, "[%s content]\n", c.Type)
+		}
+	}
+}
+
+func printResourceContents(out io.Writer, c mcp.ResourceContents) {
+	fmt.Fprintf(out, "--- %s (%s)\n", c.URI, c.MimeType)
+	if c.Blob != "" {
+		fmt.Fprintf(out, "[%d bytes base64]\n", len(c.Blob))
+		return
+	}
+	fmt.Fprintln(out, strings.TrimRight(c.Text, "\n"))
+}
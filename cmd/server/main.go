@@ -1,73 +1,480 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/sirupsen/logrus"
 	"github.com/trenchesdeveloper/mcp-server-store/configs"
+	"github.com/trenchesdeveloper/mcp-server-store/internal/auth"
 	"github.com/trenchesdeveloper/mcp-server-store/internal/client"
+	"github.com/trenchesdeveloper/mcp-server-store/internal/gateway"
+	"github.com/trenchesdeveloper/mcp-server-store/internal/jsonrpc"
 	"github.com/trenchesdeveloper/mcp-server-store/internal/mcp"
+	"github.com/trenchesdeveloper/mcp-server-store/internal/store/rest"
+	"github.com/trenchesdeveloper/mcp-server-store/internal/telemetry"
 	"github.com/trenchesdeveloper/mcp-server-store/internal/tools"
+	"github.com/trenchesdeveloper/mcp-server-store/internal/tools/account"
 	"github.com/trenchesdeveloper/mcp-server-store/internal/tools/cart"
 	"github.com/trenchesdeveloper/mcp-server-store/internal/tools/orders"
 	"github.com/trenchesdeveloper/mcp-server-store/internal/tools/products"
+	"github.com/trenchesdeveloper/mcp-server-store/internal/transcript"
 )
 
 func main() {
-	// Load configuration
-	cfg := configs.LoadConfig()
+	os.Exit(run())
+}
 
-	// Configure logging
+// run starts the server and returns the process exit code. Every failure
+// returns instead of exiting so the deferred cleanup runs: traces are
+// flushed, the transcript is closed and upstream processes are stopped.
+func run() int {
 	logger := logrus.New()
-	level, err := logrus.ParseLevel(cfg.LogLevel)
-	if err != nil {
-		level = logrus.DebugLevel
-	}
-	logger.SetLevel(level)
-	logger.SetFormatter(&logrus.TextFormatter{
-		FullTimestamp: true,
-	})
 	// Log to stderr so stdout stays clean for JSON-RPC
 	logger.SetOutput(os.Stderr)
 
-	logger.Info("Starting MCP Server...")
+	// Flags come before any CLI subcommand: mcp-server -config store.yaml tools list
+	flags := flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+	configPath := flags.String("config", os.Getenv("CONFIG_FILE"), "YAML or JSON config file (env CONFIG_FILE)")
+	_ = flags.Parse(os.Args[1:])
+	args := flags.Args()
+
+	// Load configuration
+	cfg, err := configs.Load(*configPath)
+	if err != nil {
+		logger.WithError(err).Error("Invalid configuration")
+		return 1
+	}
+
+	// Subcommands run the tools once and exit; keep their output quiet
+	// unless a log level was asked for, in the config or LOG_LEVEL.
+	cliMode := len(args) > 0
+	if cliMode && cfg.Logging.Level == "" {
+		cfg.Logging.Level = "warn"
+	}
+
+	// Configure logging
+	configureLogging(logger, cfg.Logging)
+
+	logger.WithField("config", cfg.Path).Info("Starting MCP Server...")
+
+	// Export traces; until then, and with no exporter, spans are no-ops
+	stopTracing, err := telemetry.Setup(context.Background(), tracingConfig(cfg), logger)
+	if err != nil {
+		logger.WithError(err).Error("Failed to set up tracing")
+		return 1
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), tracingFlushTimeout)
+		defer cancel()
+		if err := stopTracing(ctx); err != nil {
+			logger.WithError(err).Warn("Failed to flush traces")
+		}
+	}()
+	if cfg.Tracing.Exporter != telemetry.ExporterNone {
+		logger.WithFields(logrus.Fields{
+			"exporter":    cfg.Tracing.Exporter,
+			"sampleRatio": cfg.Tracing.SampleRatio,
+		}).Info("Tracing enabled")
+	}
 
 	// Create HTTP client for the ecommerce API
-	httpClient := client.NewRestClient(cfg.APIURL, cfg.AuthToken, logger)
+	httpClient := client.NewRestClient(cfg.API.URL, cfg.API.Token, logger)
+	httpClient.SetTimeout(cfg.API.Timeout)
+	httpClient.SetRetryPolicy(retryPolicy(cfg.API.Retry))
+	httpClient.SetBreakerPolicy(breakerPolicy(cfg.API.Breaker))
+	httpClient.SetCachePolicy(cachePolicy(cfg.API.Cache))
 
 	// Create the MCP server
-	server := mcp.NewServer(
-		"mcp-server-store",
-		"0.1.0",
-		logger,
-		mcp.WithInstructions("A store management MCP server."),
+	opts := []mcp.ServerOption{
+		mcp.WithInstructions(cfg.Server.Instructions),
 		mcp.WithHTTPClient(httpClient),
-	)
+	}
+	// Record the session and its API traffic when asked to. CLI commands do
+	// not speak JSON-RPC, and a replay must not append to a transcript.
+	if cfg.Transcript.File != "" && !cliMode {
+		recorder, err := transcript.Create(cfg.Transcript.File)
+		if err != nil {
+			logger.WithError(err).Error("Failed to open transcript")
+			return 1
+		}
+		defer recorder.Close()
+		httpClient.SetTransport(recorder.RoundTripper(nil))
+		opts = append(opts, mcp.WithRecorder(recorder))
+		logger.WithField("path", cfg.Transcript.File).Info("Recording session transcript")
+	}
+	if cfg.Gateway.Config != "" || cfg.Path != "" {
+		// Upstream tool lists and config reloads can change the tools
+		// while a session is open.
+		opts = append(opts, mcp.WithListChanged())
+	}
+	server := mcp.NewServer(cfg.Server.Name, cfg.Server.Version, logger, opts...)
 
 	// Register tools
-	server.RegisterTool(tools.PingTool(), tools.PingHandler())
+	storeTools, err := registerTools(server, httpClient, cfg.Tools, cfg.Server.Namespaced, logger)
+	if err != nil {
+		logger.WithError(err).Error("Failed to register tools")
+		return 1
+	}
+	if err := checkToolSettings(cfg.Tools, storeTools); err != nil {
+		logger.WithError(err).Error("Invalid configuration")
+		return 1
+	}
+	if err := server.SetToolPolicy(toolPolicy(cfg)); err != nil {
+		logger.WithError(err).Error("Invalid configuration")
+		return 1
+	}
+	server.SetRateLimits(rateLimits(cfg))
+
+	// Gateway mode: mount upstream MCP servers next to the store tools
+	if cfg.Gateway.Config != "" {
+		gw, err := connectGateway(server, cfg.Gateway.Config, logger)
+		if err != nil {
+			logger.WithError(err).Error("Failed to start gateway")
+			return 1
+		}
+		defer gw.Close()
+	}
 
-	// Product tools
-	productTools := products.NewProductToolSet(httpClient, logger)
-	server.RegisterTool(productTools.ListTool(), productTools.ListHandler())
-	server.RegisterTool(productTools.SearchTool(), productTools.SearchHandler())
-	server.RegisterTool(productTools.GetDetailTool(), productTools.GetDetailHandler())
+	logger.WithField("tools", len(server.ListTools())).Info("Registered tools")
 
-	// Cart tools
-	cartTools := cart.NewCartToolSet(httpClient, logger)
-	server.RegisterTool(cartTools.AddToCartTool(), cartTools.AddToCartHandler())
-	server.RegisterTool(cartTools.ViewCartTool(), cartTools.ViewCartHandler())
+	if cliMode {
+		ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+		err := runCLI(ctx, server, httpClient, args, os.Stdout)
+		stop()
+		if err != nil {
+			if !errors.Is(err, errToolFailed) && !errors.Is(err, errReplayMismatch) {
+				logger.WithError(err).Error("Command failed")
+			}
+			return 1
+		}
+		return 0
+	}
 
-	// Order tools
-	orderTools := orders.NewOrderToolSet(httpClient, logger)
-	server.RegisterTool(orderTools.CreateOrderTool(), orderTools.CreateOrderHandler())
-	server.RegisterTool(orderTools.ListOrdersTool(), orderTools.ListOrdersHandler())
-	server.RegisterTool(orderTools.CancelOrderTool(), orderTools.CancelOrderHandler())
+	// Apply config changes on SIGHUP or when the config file changes
+	reload := newReloader(cfg, server, httpClient, storeTools, logger)
+	reloadCtx, stopReload := context.WithCancel(context.Background())
+	defer stopReload()
+	go reload.Run(reloadCtx)
 
-	logger.WithField("tools", len(server.ListTools())).Info("Registered tools")
+	// Start serving over the configured transport
+	t, err := newTransport(cfg, server, httpClient, logger)
+	if err != nil {
+		logger.WithError(err).Error("Failed to set up transport")
+		return 1
+	}
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- t.serve()
+	}()
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+
+	select {
+	case err := <-serveErr:
+		if err != nil {
+			logger.WithError(err).Error("Server exited with error")
+			return 1
+		}
+	case sig := <-signals:
+		logger.WithField("signal", sig.String()).Info("Received shutdown signal")
+
+		ctx, cancel := context.WithTimeout(context.Background(), reload.Config().Transport.ShutdownTimeout)
+		defer cancel()
+
+		if err := server.Shutdown(ctx); err != nil {
+			logger.WithError(err).Error("Graceful shutdown did not complete")
+			t.close(ctx)
+			// Give cancelled calls a moment to report their failure to the client.
+			select {
+			case <-serveErr:
+			case <-time.After(time.Second):
+			}
+			return 1
+		}
+		if err := t.close(ctx); err != nil {
+			logger.WithError(err).Error("Failed to close transport")
+		}
+		// Wait for the serve loop to flush its last response.
+		if err := <-serveErr; err != nil && !errors.Is(err, jsonrpc.ErrServerClosed) {
+			logger.WithError(err).Error("Server exited with error")
+		}
+	}
+
+	logger.Info("MCP server stopped")
+	return 0
+}
+
+// Tool bundles, mounted under their name when server.namespaced is set.
+const (
+	catalogBundle  = "catalog"  // browsing products
+	customerBundle = "customer" // the account, cart, and orders
+)
+
+// toolBundle is a group of tools and resource templates shipped together.
+type toolBundle struct {
+	name      string // empty for tools that are never namespaced
+	tools     []toolRegistration
+	templates []templateRegistration
+}
+
+type toolRegistration struct {
+	tool    mcp.Tool
+	handler mcp.ToolHandler
+}
+
+type templateRegistration struct {
+	template mcp.ResourceTemplate
+	handler  mcp.ResourceHandler
+}
+
+// toolRegistrar is implemented by mcp.Server and mcp.Registry.
+type toolRegistrar interface {
+	RegisterTool(tool mcp.Tool, handler mcp.ToolHandler) error
+	RegisterResourceTemplate(template mcp.ResourceTemplate, handler mcp.ResourceHandler) error
+}
+
+// registerTools registers every tool set on the server, applying the
+// per-tool description overrides from the config, and returns the names of
+// the tools it registered. It also registers the resource templates behind
+// the product and order URIs the tools return. Tools disabled in the
+// config are registered too so a reload can enable them; see toolPolicy.
+// It fails on the first name collision instead of silently replacing an
+// earlier tool.
+//
+// With namespaced set, each named bundle is built in a registry of its own
+// and mounted under its name, so list_products is listed, configured, and
+// called as catalog.list_products.
+func registerTools(server *mcp.Server, httpClient *client.RestClient, settings map[string]configs.ToolConfig, namespaced bool, logger *logrus.Logger) ([]string, error) {
+	backend := rest.New(httpClient)
+	sessions := auth.NewManager(httpClient, logger)
+	accountTools := account.NewAccountToolSet(sessions, logger)
+	productTools := products.NewProductToolSet(backend, logger)
+	cartTools := cart.NewCartToolSet(auth.Cart(backend, sessions), logger)
+	orderTools := orders.NewOrderToolSet(auth.Orders(backend, sessions), logger)
+
+	bundles := []toolBundle{
+		{
+			tools: []toolRegistration{
+				{tools.PingTool(), tools.PingHandler()},
+			},
+		},
+		{
+			name: catalogBundle,
+			tools: []toolRegistration{
+				{productTools.ListTool(), productTools.ListHandler()},
+				{productTools.SearchTool(), productTools.SearchHandler()},
+				{productTools.GetDetailTool(), productTools.GetDetailHandler()},
+			},
+			templates: []templateRegistration{
+				{productTools.ProductTemplate(), productTools.ProductResourceHandler()},
+			},
+		},
+		{
+			name: customerBundle,
+			tools: []toolRegistration{
+				// Account tools
+				{accountTools.LoginTool(), accountTools.LoginHandler()},
+				{accountTools.LogoutTool(), accountTools.LogoutHandler()},
+
+				// Cart tools
+				{cartTools.AddToCartTool(), cartTools.AddToCartHandler()},
+				{cartTools.ViewCartTool(), cartTools.ViewCartHandler()},
+
+				// Order tools
+				{orderTools.CreateOrderTool(), orderTools.CreateOrderHandler()},
+				{orderTools.ListOrdersTool(), orderTools.ListOrdersHandler()},
+				{orderTools.CancelOrderTool(), orderTools.CancelOrderHandler()},
+			},
+			templates: []templateRegistration{
+				{orderTools.OrderTemplate(), orderTools.OrderResourceHandler()},
+			},
+		},
+	}
+
+	var names []string
+	for _, bundle := range bundles {
+		if !namespaced || bundle.name == "" {
+			registered, err := bundle.register(server, "", settings)
+			if err != nil {
+				return nil, err
+			}
+			names = append(names, registered...)
+			continue
+		}
+
+		child := mcp.NewRegistry(mcp.ClientInfo{}, "", logger)
+		registered, err := bundle.register(child, bundle.name, settings)
+		if err != nil {
+			return nil, err
+		}
+		if err := server.Mount(bundle.name, child); err != nil {
+			return nil, err
+		}
+		names = append(names, registered...)
+	}
+	return names, nil
+}
+
+// register adds the bundle to target and returns the names its tools will
+// have once target is mounted under namespace.
+func (b *toolBundle) register(target toolRegistrar, namespace string, settings map[string]configs.ToolConfig) ([]string, error) {
+	names := make([]string, 0, len(b.tools))
+	for _, reg := range b.tools {
+		name := mcp.Namespaced(namespace, reg.tool.Name)
+		if description := settings[name].Description; description != "" {
+			reg.tool.Description = description
+		}
+		if err := target.RegisterTool(reg.tool, reg.handler); err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	for _, reg := range b.templates {
+		if err := target.RegisterResourceTemplate(reg.template, reg.handler); err != nil {
+			return nil, err
+		}
+	}
+	return names, nil
+}
+
+// checkToolSettings rejects settings for tools that do not exist, so a
+// typo does not silently leave a tool enabled.
+func checkToolSettings(settings map[string]configs.ToolConfig, known []string) error {
+	isKnown := make(map[string]bool, len(known))
+	for _, name := range known {
+		isKnown[name] = true
+	}
+	for name := range settings {
+		if !isKnown[name] {
+			return fmt.Errorf("config: tools.%s: no such tool", name)
+		}
+	}
+	return nil
+}
+
+// toolPolicy returns the tool access rules from the config.
+func toolPolicy(cfg *configs.Config) mcp.ToolPolicy {
+	policy := mcp.ToolPolicy{
+		ReadOnly: cfg.Access.ReadOnly,
+		Allow:    cfg.Access.Allow,
+		Deny:     cfg.Access.Deny,
+	}
+	for name, setting := range cfg.Tools {
+		if !setting.IsEnabled() {
+			policy.Disabled = append(policy.Disabled, name)
+		}
+	}
+	return policy
+}
+
+// rateLimits returns the tool call rate limits from the config.
+func rateLimits(cfg *configs.Config) mcp.RateLimits {
+	limit := func(l configs.RateLimitConfig) mcp.RateLimit {
+		return mcp.RateLimit{PerMinute: l.PerMinute, Burst: l.Burst}
+	}
+	limits := mcp.RateLimits{
+		Global:   limit(cfg.RateLimits.Global),
+		Session:  limit(cfg.RateLimits.Session),
+		Mutating: limit(cfg.RateLimits.Mutating),
+	}
+	for name, setting := range cfg.Tools {
+		if setting.RateLimit.PerMinute > 0 {
+			if limits.Tools == nil {
+				limits.Tools = make(map[string]mcp.RateLimit)
+			}
+			limits.Tools[name] = limit(setting.RateLimit)
+		}
+	}
+	return limits
+}
+
+// tracingFlushTimeout bounds how long exiting waits for spans to export.
+const tracingFlushTimeout = 5 * time.Second
+
+// tracingConfig converts the tracing settings for the telemetry package.
+func tracingConfig(cfg *configs.Config) telemetry.Config {
+	return telemetry.Config{
+		Exporter:       cfg.Tracing.Exporter,
+		Endpoint:       cfg.Tracing.Endpoint,
+		Insecure:       cfg.Tracing.Insecure,
+		File:           cfg.Tracing.File,
+		SampleRatio:    cfg.Tracing.SampleRatio,
+		ServiceName:    cfg.Server.Name,
+		ServiceVersion: cfg.Server.Version,
+	}
+}
+
+// retryPolicy converts the retry settings for the API client.
+func retryPolicy(cfg configs.RetryConfig) client.RetryPolicy {
+	return client.RetryPolicy{
+		MaxAttempts:    cfg.Attempts,
+		InitialBackoff: cfg.InitialBackoff,
+		MaxBackoff:     cfg.MaxBackoff,
+	}
+}
+
+// breakerPolicy converts the circuit breaker settings for the API client.
+func breakerPolicy(cfg configs.BreakerConfig) client.BreakerPolicy {
+	return client.BreakerPolicy{
+		FailureThreshold: cfg.FailureThreshold,
+		Cooldown:         cfg.Cooldown,
+		PerRoute:         cfg.PerRoute,
+	}
+}
+
+// cachePolicy converts the response cache settings for the API client.
+func cachePolicy(cfg configs.CacheConfig) client.CachePolicy {
+	if !cfg.Enabled {
+		return client.CachePolicy{}
+	}
+	return client.CachePolicy{
+		Routes:     cfg.Routes,
+		MaxEntries: cfg.MaxEntries,
+		MaxBytes:   cfg.MaxBytes,
+	}
+}
+
+// configureLogging applies the level and format settings to logger.
+func configureLogging(logger *logrus.Logger, cfg configs.LoggingConfig) {
+	level := logrus.DebugLevel
+	if cfg.Level != "" {
+		level, _ = logrus.ParseLevel(cfg.Level) // checked by configs.Load
+	}
+	logger.SetLevel(level)
+	if cfg.Format == "json" {
+		logger.SetFormatter(&logrus.JSONFormatter{})
+	} else {
+		logger.SetFormatter(&logrus.TextFormatter{
+			FullTimestamp: true,
+		})
+	}
+}
+
+// connectGateway connects every upstream listed in the gateway config file.
+// An upstream that cannot be reached is logged and skipped so the store
+// tools stay available.
+func connectGateway(server *mcp.Server, path string, logger *logrus.Logger) (*gateway.Gateway, error) {
+	gwCfg, err := gateway.LoadConfig(path)
+	if err != nil {
+		return nil, err
+	}
 
-	// Start serving over stdio
-	if err := server.ServeStdio(); err != nil {
-		logger.WithError(err).Fatal("Server exited with error")
+	gw := gateway.New(server, logger)
+	for _, upstream := range gwCfg.Upstreams {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		err := gw.Connect(ctx, upstream)
+		cancel()
+		if err != nil {
+			logger.WithError(err).WithField("upstream", upstream.Name).Error("Failed to connect upstream MCP server")
+		}
 	}
+	return gw, nil
 }
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"reflect"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/trenchesdeveloper/mcp-server-store/configs"
+	"github.com/trenchesdeveloper/mcp-server-store/internal/client"
+	"github.com/trenchesdeveloper/mcp-server-store/internal/mcp"
+)
+
+// configPollInterval is how often the config file is checked for changes.
+const configPollInterval = 2 * time.Second
+
+// reloader re-reads the configuration on SIGHUP or when the config file
+// changes, and applies the settings that are safe to change while a
+// session is open: log level and format, enabled tools and tool access
+// rules, the API timeout, and the instructions given to new sessions. Other
+// changes are logged and ignored until the next restart.
+type reloader struct {
+	server     *mcp.Server
+	httpClient *client.RestClient
+	storeTools []string
+	logger     *logrus.Logger
+
+	mu      sync.Mutex
+	current *configs.Config
+}
+
+func newReloader(cfg *configs.Config, server *mcp.Server, httpClient *client.RestClient, storeTools []string, logger *logrus.Logger) *reloader {
+	return &reloader{
+		server:     server,
+		httpClient: httpClient,
+		storeTools: storeTools,
+		logger:     logger,
+		current:    cfg,
+	}
+}
+
+// Config returns the configuration currently in effect.
+func (r *reloader) Config() *configs.Config {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.current
+}
+
+// Run reloads on SIGHUP and, if the configuration came from a file, when
+// that file's size or modification time changes. It blocks until ctx is done.
+func (r *reloader) Run(ctx context.Context) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	path := r.Config().Path
+	var poll <-chan time.Time
+	var last os.FileInfo
+	if path != "" {
+		last, _ = os.Stat(path)
+		ticker := time.NewTicker(configPollInterval)
+		defer ticker.Stop()
+		poll = ticker.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			r.logger.Info("Received SIGHUP, reloading configuration")
+			r.Reload()
+		case <-poll:
+			info, err := os.Stat(path)
+			if err != nil {
+				// Editors often replace the file; wait for it to reappear.
+				continue
+			}
+			if last != nil && info.ModTime().Equal(last.ModTime()) && info.Size() == last.Size() {
+				continue
+			}
+			last = info
+			r.logger.WithField("path", path).Info("Config file changed, reloading configuration")
+			r.Reload()
+		}
+	}
+}
+
+// Reload reads the configuration again and applies it. An invalid
+// configuration is logged and the current one is kept.
+func (r *reloader) Reload() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	next, err := configs.Load(r.current.Path)
+	if err == nil {
+		err = checkToolSettings(next.Tools, r.storeTools)
+	}
+	if err != nil {
+		r.logger.WithError(err).Warn("Ignoring invalid configuration, keeping the current one")
+		return
+	}
+
+	r.keepUnsafe(next)
+
+	if next.Logging != r.current.Logging {
+		configureLogging(r.logger, next.Logging)
+		r.logger.WithFields(logrus.Fields{
+			"logLevel":  next.Logging.Level,
+			"logFormat": next.Logging.Format,
+		}).Info("Logging settings updated")
+	}
+	if next.API.Timeout != r.current.API.Timeout {
+		r.httpClient.SetTimeout(next.API.Timeout)
+		r.logger.WithField("timeout", next.API.Timeout).Info("API timeout updated")
+	}
+	if next.API.Retry != r.current.API.Retry {
+		r.httpClient.SetRetryPolicy(retryPolicy(next.API.Retry))
+		r.logger.WithFields(logrus.Fields{
+			"attempts":       next.API.Retry.Attempts,
+			"initialBackoff": next.API.Retry.InitialBackoff,
+			"maxBackoff":     next.API.Retry.MaxBackoff,
+		}).Info("API retry settings updated")
+	}
+	if next.API.Breaker != r.current.API.Breaker {
+		r.httpClient.SetBreakerPolicy(breakerPolicy(next.API.Breaker))
+		r.logger.WithFields(logrus.Fields{
+			"failureThreshold": next.API.Breaker.FailureThreshold,
+			"cooldown":         next.API.Breaker.Cooldown,
+			"perRoute":         next.API.Breaker.PerRoute,
+		}).Info("API circuit breaker settings updated")
+	}
+	if !reflect.DeepEqual(next.API.Cache, r.current.API.Cache) {
+		r.httpClient.SetCachePolicy(cachePolicy(next.API.Cache))
+		r.logger.WithField("enabled", next.API.Cache.Enabled).Info("API cache settings updated, cache emptied")
+	}
+	if next.Server.Instructions != r.current.Server.Instructions {
+		r.server.SetInstructions(next.Server.Instructions)
+		r.logger.Info("Instructions updated for new sessions")
+	}
+	if err := r.server.SetToolPolicy(toolPolicy(next)); err != nil {
+		r.logger.WithError(err).Warn("Failed to apply tool access settings")
+	}
+	if limits := rateLimits(next); !reflect.DeepEqual(limits, rateLimits(r.current)) {
+		r.server.SetRateLimits(limits)
+	}
+
+	r.current = next
+	r.logger.Info("Configuration reloaded")
+}
+
+// keepUnsafe restores every setting in next that cannot change without a
+// restart to its current value, warning about each one that differed.
+func (r *reloader) keepUnsafe(next *configs.Config) {
+	cur := r.current
+	warn := func(setting string, changed bool) {
+		if changed {
+			r.logger.WithField("setting", setting).Warn("Setting cannot be changed while running, restart to apply it")
+		}
+	}
+
+	warn("server.name", next.Server.Name != cur.Server.Name)
+	warn("server.version", next.Server.Version != cur.Server.Version)
+	warn("server.namespaced", next.Server.Namespaced != cur.Server.Namespaced)
+	next.Server.Name, next.Server.Version = cur.Server.Name, cur.Server.Version
+	next.Server.Namespaced = cur.Server.Namespaced
+
+	warn("api.url", next.API.URL != cur.API.URL)
+	warn("api.token", next.API.Token != cur.API.Token)
+	next.API.URL, next.API.Token = cur.API.URL, cur.API.Token
+
+	warn("transport", !reflect.DeepEqual(next.Transport, cur.Transport))
+	next.Transport = cur.Transport
+
+	warn("oauth", !reflect.DeepEqual(next.OAuth, cur.OAuth))
+	next.OAuth = cur.OAuth
+
+	warn("gateway", next.Gateway != cur.Gateway)
+	next.Gateway = cur.Gateway
+
+	warn("transcript", next.Transcript != cur.Transcript)
+	next.Transcript = cur.Transcript
+
+	warn("tracing", next.Tracing != cur.Tracing)
+	next.Tracing = cur.Tracing
+
+	// Tool descriptions are fixed at registration; only enabled may change.
+	for name, tool := range next.Tools {
+		if tool.Description != cur.Tools[name].Description {
+			warn("tools."+name+".description", true)
+			tool.Description = cur.Tools[name].Description
+			next.Tools[name] = tool
+		}
+	}
+	for name, tool := range cur.Tools {
+		if _, ok := next.Tools[name]; !ok && tool.Description != "" {
+			warn("tools."+name+".description", true)
+			if next.Tools == nil {
+				next.Tools = make(map[string]configs.ToolConfig)
+			}
+			next.Tools[name] = configs.ToolConfig{Description: tool.Description}
+		}
+	}
+}
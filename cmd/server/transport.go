@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/trenchesdeveloper/mcp-server-store/configs"
+	"github.com/trenchesdeveloper/mcp-server-store/internal/client"
+	"github.com/trenchesdeveloper/mcp-server-store/internal/jsonrpc"
+	"github.com/trenchesdeveloper/mcp-server-store/internal/mcp"
+	"github.com/trenchesdeveloper/mcp-server-store/internal/mcphttp"
+	"github.com/trenchesdeveloper/mcp-server-store/internal/oauth"
+)
+
+// transport serves the MCP server to clients until closed.
+type transport struct {
+	// serve blocks until the transport stops, returning
+	// jsonrpc.ErrServerClosed after the server is shut down.
+	serve func() error
+	// close stops the transport once the server has shut down.
+	close func(ctx context.Context) error
+}
+
+// newTransport returns the transport selected in the config. The stdio
+// session authenticates with the configured API token; HTTP sessions use
+// the bearer token their client sends, which must be a valid access token
+// when OAuth is enabled. The HTTP transport also serves a health check.
+func newTransport(cfg *configs.Config, server *mcp.Server, httpClient *client.RestClient, logger *logrus.Logger) (*transport, error) {
+	if cfg.Transport.Type != "http" {
+		return &transport{
+			serve: server.ServeStdio,
+			close: func(context.Context) error { return nil },
+		}, nil
+	}
+
+	mux := http.NewServeMux()
+	opts := []mcphttp.Option{
+		mcphttp.WithSessionTTL(cfg.Transport.SessionTTL),
+		mcphttp.WithMaxSessions(cfg.Transport.MaxSessions),
+		mcphttp.WithAllowedOrigins(cfg.Transport.AllowedOrigins),
+	}
+	if cfg.OAuth.Enabled {
+		metadataURL, err := protectResource(mux, cfg.OAuth, logger)
+		if err != nil {
+			return nil, err
+		}
+		keys, err := newKeySet(cfg.OAuth, logger)
+		if err != nil {
+			return nil, err
+		}
+		validator := oauth.NewValidator(keys, cfg.OAuth.Issuer, cfg.OAuth.Audience, cfg.OAuth.Scopes)
+		opts = append(opts, mcphttp.WithOAuth(validator, metadataURL))
+	}
+	handler := mcphttp.NewHandler(server, logger, opts...)
+	mux.Handle(cfg.Transport.Path, handler)
+	mux.Handle("GET "+healthPath, healthHandler(httpClient))
+
+	addr := cfg.Transport.Addr
+	httpServer := &http.Server{Addr: addr, Handler: mux}
+	sweeping, stopSweeping := context.WithCancel(context.Background())
+
+	return &transport{
+		serve: func() error {
+			logger.WithFields(logrus.Fields{
+				"addr":  addr,
+				"path":  cfg.Transport.Path,
+				"oauth": cfg.OAuth.Enabled,
+			}).Info("Serving MCP over HTTP")
+			go handler.ExpireSessions(sweeping, sessionSweepInterval(cfg.Transport.SessionTTL))
+			err := httpServer.ListenAndServe()
+			if errors.Is(err, http.ErrServerClosed) {
+				return jsonrpc.ErrServerClosed
+			}
+			return err
+		},
+		close: func(ctx context.Context) error {
+			stopSweeping()
+			return httpServer.Shutdown(ctx)
+		},
+	}, nil
+}
+
+// sessionSweepInterval is how often idle sessions are looked for: often
+// enough that one outlives the TTL by at most a tenth, but no more than
+// once a second.
+func sessionSweepInterval(ttl time.Duration) time.Duration {
+	return max(ttl/10, time.Second)
+}
+
+// healthPath serves the health check.
+const healthPath = "/healthz"
+
+// healthHandler reports the state of the API circuit breakers. The server
+// is "degraded", with status 503, while any breaker is open.
+func healthHandler(httpClient *client.RestClient) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		health := struct {
+			Status   string                `json:"status"`
+			Breakers []client.BreakerState `json:"breakers"`
+		}{Status: "ok", Breakers: httpClient.Breakers()}
+
+		code := http.StatusOK
+		for _, b := range health.Breakers {
+			if b.State != client.BreakerClosed {
+				health.Status = "degraded"
+				code = http.StatusServiceUnavailable
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(code)
+		json.NewEncoder(w).Encode(health)
+	})
+}
+
+// protectResource serves the protected resource metadata on mux, both at
+// the path derived from the resource URL and at the root well-known path,
+// and returns the metadata URL clients are pointed at.
+func protectResource(mux *http.ServeMux, cfg configs.OAuthConfig, logger *logrus.Logger) (string, error) {
+	metadataURL, err := oauth.MetadataURL(cfg.Resource)
+	if err != nil {
+		return "", err
+	}
+	u, err := url.Parse(metadataURL)
+	if err != nil {
+		return "", err
+	}
+
+	handler := oauth.MetadataHandler(oauth.Metadata{
+		Resource:             cfg.Resource,
+		AuthorizationServers: cfg.AuthorizationServers,
+		ScopesSupported:      cfg.Scopes,
+	})
+	mux.Handle(u.Path, handler)
+	if u.Path != oauth.WellKnownPath {
+		mux.Handle(oauth.WellKnownPath, handler)
+	}
+
+	logger.WithField("metadata", metadataURL).Info("Serving OAuth protected resource metadata")
+	return metadataURL, nil
+}
+
+func newKeySet(cfg configs.OAuthConfig, logger *logrus.Logger) (*oauth.KeySet, error) {
+	if cfg.JWKSFile != "" {
+		return oauth.NewFileKeySet(cfg.JWKSFile, logger)
+	}
+	return oauth.NewURLKeySet(cfg.JWKSURL, nil, logger), nil
+}
@@ -1,38 +1,492 @@
 package configs
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
 	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
+	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
 )
 
+// Config is the server configuration. It is built from defaults, then an
+// optional YAML or JSON config file, then environment variables, which
+// take precedence over the file.
 type Config struct {
-	APIURL string // http://localhost:8000/api/v1
-	AuthToken string //JWT for current user
-	LogLevel string
-	Transport string // stdio(for now) or http
+	Server     ServerConfig          `yaml:"server"`
+	API        APIConfig             `yaml:"api"`
+	Transport  TransportConfig       `yaml:"transport"`
+	Logging    LoggingConfig         `yaml:"logging"`
+	Gateway    GatewayConfig         `yaml:"gateway"`
+	Transcript TranscriptConfig      `yaml:"transcript"`
+	Tracing    TracingConfig         `yaml:"tracing"`
+	OAuth      OAuthConfig           `yaml:"oauth"`
+	Access     AccessConfig          `yaml:"access"`
+	RateLimits RateLimitsConfig      `yaml:"rate_limits"`
+	Tools      map[string]ToolConfig `yaml:"tools"` // keyed by tool name
+
+	// Path is the config file the configuration was read from, if any.
+	Path string `yaml:"-"`
+}
+
+// ServerConfig is the identity reported to clients during initialize.
+type ServerConfig struct {
+	Name         string `yaml:"name"`
+	Version      string `yaml:"version"`
+	Instructions string `yaml:"instructions"`
+
+	// Namespaced mounts the store tools as bundles, e.g. "catalog.list_products"
+	// and "customer.create_order", instead of registering them by bare name.
+	Namespaced bool `yaml:"namespaced"`
 }
 
-func LoadConfig() *Config {
+type APIConfig struct {
+	URL     string        `yaml:"url"`     // http://localhost:8000/api/v1
+	Token   string        `yaml:"token"`   // JWT for the stdio session; HTTP sessions send their own
+	Timeout time.Duration `yaml:"timeout"` // per request
+	Retry   RetryConfig   `yaml:"retry"`
+	Breaker BreakerConfig `yaml:"circuit_breaker"`
+	Cache   CacheConfig   `yaml:"cache"`
+}
+
+// RetryConfig controls retries of API requests that are safe to repeat.
+type RetryConfig struct {
+	Attempts       int           `yaml:"attempts"`        // total tries; 1 disables retries
+	InitialBackoff time.Duration `yaml:"initial_backoff"` // doubled after every retry
+	MaxBackoff     time.Duration `yaml:"max_backoff"`
+}
+
+// BreakerConfig controls the circuit breakers that fail calls fast while
+// the API is down.
+type BreakerConfig struct {
+	FailureThreshold int           `yaml:"failure_threshold"` // consecutive failures that open a breaker; 0 disables
+	Cooldown         time.Duration `yaml:"cooldown"`          // how long an open breaker rejects calls
+	PerRoute         bool          `yaml:"per_route"`         // one breaker per route family instead of per host
+}
+
+// CacheConfig controls the cache of GET responses from the API.
+type CacheConfig struct {
+	Enabled    bool                     `yaml:"enabled"`
+	Routes     map[string]time.Duration `yaml:"routes"`      // TTL by path prefix; 0 disables a route
+	MaxEntries int                      `yaml:"max_entries"` // 0 for no limit
+	MaxBytes   int64                    `yaml:"max_bytes"`   // 0 for no limit
+}
+
+type TransportConfig struct {
+	Type            string        `yaml:"type"`             // stdio or http
+	Addr            string        `yaml:"addr"`             // listen address for http
+	Path            string        `yaml:"path"`             // MCP endpoint path for http
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout"` // how long to wait for in-flight requests on shutdown
+	SessionTTL      time.Duration `yaml:"session_ttl"`      // how long an idle http session is kept
+	MaxSessions     int           `yaml:"max_sessions"`     // open http sessions; initialize beyond it is refused
+	AllowedOrigins  []string      `yaml:"allowed_origins"`  // browser origins allowed to call the http endpoint; empty allows only localhost
+}
+
+type LoggingConfig struct {
+	Level  string `yaml:"level"`  // empty means debug, or warn for CLI subcommands
+	Format string `yaml:"format"` // text or json
+}
+
+type GatewayConfig struct {
+	Config string `yaml:"config"` // optional JSON file listing upstream MCP servers to aggregate
+}
+
+type TranscriptConfig struct {
+	File string `yaml:"file"` // optional JSONL file recording every session message and API call
+}
+
+// TracingConfig exports OpenTelemetry traces of JSON-RPC requests, tool
+// calls, and API requests.
+type TracingConfig struct {
+	Exporter    string  `yaml:"exporter"`     // none, otlp, stdout, or file
+	Endpoint    string  `yaml:"endpoint"`     // OTLP/HTTP collector; defaults to OTEL_EXPORTER_OTLP_ENDPOINT or localhost:4318
+	Insecure    bool    `yaml:"insecure"`     // plain HTTP to the collector
+	File        string  `yaml:"file"`         // for the file exporter
+	SampleRatio float64 `yaml:"sample_ratio"` // of traces not started by the client
+}
+
+// OAuthConfig makes the HTTP transport an OAuth 2.1 protected resource.
+// Access tokens must be JWTs signed by a key in the JWKS file or URL.
+type OAuthConfig struct {
+	Enabled              bool     `yaml:"enabled"`
+	Resource             string   `yaml:"resource"`              // this server's public MCP URL, e.g. https://mcp.example.com/mcp
+	AuthorizationServers []string `yaml:"authorization_servers"` // issuers clients can get tokens from
+	Issuer               string   `yaml:"issuer"`                // defaults to the first authorization server
+	Audience             string   `yaml:"audience"`              // defaults to resource
+	JWKSFile             string   `yaml:"jwks_file"`
+	JWKSURL              string   `yaml:"jwks_url"`
+	Scopes               []string `yaml:"scopes"` // required on every token
+}
+
+// fillDefaults derives the issuer and audience when they are not set.
+func (o *OAuthConfig) fillDefaults() {
+	if o.Issuer == "" && len(o.AuthorizationServers) > 0 {
+		o.Issuer = o.AuthorizationServers[0]
+	}
+	if o.Audience == "" {
+		o.Audience = o.Resource
+	}
+}
+
+// AccessConfig limits which tools clients can see and call. Patterns are
+// globs on tool names, e.g. "*_order".
+type AccessConfig struct {
+	ReadOnly bool     `yaml:"read_only"` // only tools that do not modify state
+	Allow    []string `yaml:"allow"`     // if set, only matching tools
+	Deny     []string `yaml:"deny"`      // never matching tools
+}
+
+// RateLimitsConfig limits tool calls with token buckets. Unset limits
+// allow any number of calls.
+type RateLimitsConfig struct {
+	Global   RateLimitConfig `yaml:"global"`   // all sessions together
+	Session  RateLimitConfig `yaml:"session"`  // each session
+	Mutating RateLimitConfig `yaml:"mutating"` // each session's calls to tools that modify state
+}
+
+// RateLimitConfig is one token bucket.
+type RateLimitConfig struct {
+	PerMinute float64 `yaml:"per_minute"` // 0 for no limit
+	Burst     int     `yaml:"burst"`      // defaults to per_minute
+}
+
+// ToolConfig adjusts a single tool. Unset fields leave the tool as built.
+type ToolConfig struct {
+	Enabled     *bool           `yaml:"enabled"`
+	Description string          `yaml:"description"`
+	RateLimit   RateLimitConfig `yaml:"rate_limit"` // per session
+}
+
+// IsEnabled reports whether the tool should be registered.
+func (t ToolConfig) IsEnabled() bool {
+	return t.Enabled == nil || *t.Enabled
+}
+
+// Default returns the configuration used when nothing is set.
+func Default() *Config {
+	return &Config{
+		Server: ServerConfig{
+			Name:         "mcp-server-store",
+			Version:      "0.1.0",
+			Instructions: "A store management MCP server.",
+		},
+		API: APIConfig{
+			URL:     "http://localhost:8080/api/v1",
+			Timeout: 30 * time.Second,
+			Retry: RetryConfig{
+				Attempts:       3,
+				InitialBackoff: 200 * time.Millisecond,
+				MaxBackoff:     5 * time.Second,
+			},
+			Breaker: BreakerConfig{
+				FailureThreshold: 5,
+				Cooldown:         30 * time.Second,
+			},
+			Cache: CacheConfig{
+				Routes: map[string]time.Duration{
+					"/products": time.Minute,
+					"/cart":     10 * time.Second,
+					"/orders":   10 * time.Second,
+				},
+				MaxEntries: 1000,
+				MaxBytes:   32 << 20,
+			},
+		},
+		Transport: TransportConfig{
+			Type:            "stdio",
+			Addr:            "localhost:3000",
+			Path:            "/mcp",
+			ShutdownTimeout: 30 * time.Second,
+			SessionTTL:      30 * time.Minute,
+			MaxSessions:     1000,
+		},
+		Logging: LoggingConfig{
+			Format: "text",
+		},
+		Tracing: TracingConfig{
+			Exporter:    "none",
+			SampleRatio: 1,
+		},
+	}
+}
+
+// Load builds the configuration from path (skipped if empty) and the
+// environment, and validates it. Unknown keys in the file are an error.
+func Load(path string) (*Config, error) {
 	// Load .env file if it exists
 	_ = godotenv.Load()
 
-	authToken := os.Getenv("AUTH_TOKEN")
-	if authToken == "" {
-		authToken = os.Getenv("JWT_TOKEN")
+	cfg := Default()
+	if path != "" {
+		if err := cfg.readFile(path); err != nil {
+			return nil, err
+		}
+	}
+	if err := cfg.applyEnv(); err != nil {
+		return nil, err
+	}
+	cfg.OAuth.fillDefaults()
+	if err := cfg.Validate(); err != nil {
+		if cfg.Path != "" {
+			return nil, fmt.Errorf("config %s: %w", cfg.Path, err)
+		}
+		return nil, fmt.Errorf("config: %w", err)
+	}
+	return cfg, nil
+}
+
+// readFile decodes a YAML or JSON file over cfg. JSON is read by the YAML
+// decoder, which accepts it as a subset.
+func (cfg *Config) readFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read config: %w", err)
 	}
+	cfg.Path = path
 
-	return &Config{
-		APIURL: getEnv("API_URL", "http://localhost:8080/api/v1"),
-		AuthToken: authToken,
-		LogLevel: getEnv("LOG_LEVEL", "debug"),
-		Transport: getEnv("TRANSPORT", "stdio"), // Options: stdio, http
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("parse config %s: %w", path, err)
+	}
+	return nil
+}
+
+// applyEnv overlays environment variables on cfg.
+func (cfg *Config) applyEnv() error {
+	setString := func(key string, dst *string) {
+		if value := os.Getenv(key); value != "" {
+			*dst = value
+		}
 	}
+	setDuration := func(key string, dst *time.Duration) error {
+		value := os.Getenv(key)
+		if value == "" {
+			return nil
+		}
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("%s: %q is not a duration", key, value)
+		}
+		*dst = d
+		return nil
+	}
+
+	setString("API_URL", &cfg.API.URL)
+	setString("JWT_TOKEN", &cfg.API.Token)
+	setString("AUTH_TOKEN", &cfg.API.Token) // takes precedence over JWT_TOKEN
+	setString("LOG_LEVEL", &cfg.Logging.Level)
+	setString("LOG_FORMAT", &cfg.Logging.Format)
+	setString("TRANSPORT", &cfg.Transport.Type) // Options: stdio, http
+	setString("HTTP_ADDR", &cfg.Transport.Addr)
+	setString("HTTP_PATH", &cfg.Transport.Path)
+	setString("GATEWAY_CONFIG", &cfg.Gateway.Config)
+	setString("TRANSCRIPT_FILE", &cfg.Transcript.File)
+	setString("TRACING_EXPORTER", &cfg.Tracing.Exporter)
+	setString("TRACING_FILE", &cfg.Tracing.File)
+
+	setList := func(key string, dst *[]string) {
+		if value := os.Getenv(key); value != "" {
+			*dst = nil
+			for _, item := range strings.Split(value, ",") {
+				if item = strings.TrimSpace(item); item != "" {
+					*dst = append(*dst, item)
+				}
+			}
+		}
+	}
+	setList("HTTP_ALLOWED_ORIGINS", &cfg.Transport.AllowedOrigins)
+
+	if value := os.Getenv("API_CACHE"); value != "" {
+		enabled, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("API_CACHE: %q is not a boolean", value)
+		}
+		cfg.API.Cache.Enabled = enabled
+	}
+
+	setList("TOOLS_ALLOW", &cfg.Access.Allow)
+	setList("TOOLS_DENY", &cfg.Access.Deny)
+	if value := os.Getenv("READ_ONLY"); value != "" {
+		readOnly, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("READ_ONLY: %q is not a boolean", value)
+		}
+		cfg.Access.ReadOnly = readOnly
+	}
+
+	if value := os.Getenv("API_RETRY_ATTEMPTS"); value != "" {
+		attempts, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("API_RETRY_ATTEMPTS: %q is not an integer", value)
+		}
+		cfg.API.Retry.Attempts = attempts
+	}
+
+	if err := setDuration("API_TIMEOUT", &cfg.API.Timeout); err != nil {
+		return err
+	}
+	return setDuration("SHUTDOWN_TIMEOUT", &cfg.Transport.ShutdownTimeout)
 }
 
-func getEnv(key, fallback string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
+// Validate checks every setting and reports all problems at once.
+func (cfg *Config) Validate() error {
+	var problems []string
+	add := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	if cfg.Server.Name == "" {
+		add("server.name must not be empty")
+	}
+	if cfg.Server.Version == "" {
+		add("server.version must not be empty")
+	}
+
+	if !isHTTPURL(cfg.API.URL) {
+		add("api.url %q must be an absolute http or https URL", cfg.API.URL)
 	}
-	return fallback
-}
\ No newline at end of file
+	if cfg.API.Timeout <= 0 {
+		add("api.timeout must be positive, got %s", cfg.API.Timeout)
+	}
+	if r := cfg.API.Retry; r.Attempts < 1 {
+		add("api.retry.attempts must be at least 1, got %d", r.Attempts)
+	} else if r.Attempts > 1 {
+		if r.InitialBackoff <= 0 {
+			add("api.retry.initial_backoff must be positive, got %s", r.InitialBackoff)
+		}
+		if r.MaxBackoff < r.InitialBackoff {
+			add("api.retry.max_backoff (%s) must not be less than initial_backoff (%s)", r.MaxBackoff, r.InitialBackoff)
+		}
+	}
+	if b := cfg.API.Breaker; b.FailureThreshold < 0 {
+		add("api.circuit_breaker.failure_threshold must not be negative, got %d", b.FailureThreshold)
+	} else if b.FailureThreshold > 0 && b.Cooldown <= 0 {
+		add("api.circuit_breaker.cooldown must be positive, got %s", b.Cooldown)
+	}
+	for prefix, ttl := range cfg.API.Cache.Routes {
+		if !strings.HasPrefix(prefix, "/") {
+			add("api.cache.routes: %q must start with /", prefix)
+		}
+		if ttl < 0 {
+			add("api.cache.routes.%s must not be negative, got %s", prefix, ttl)
+		}
+	}
+	if cfg.API.Cache.MaxEntries < 0 || cfg.API.Cache.MaxBytes < 0 {
+		add("api.cache.max_entries and max_bytes must not be negative")
+	}
+
+	switch cfg.Transport.Type {
+	case "stdio":
+	case "http":
+		if cfg.Transport.Addr == "" {
+			add("transport.addr must not be empty for the http transport")
+		}
+		if !strings.HasPrefix(cfg.Transport.Path, "/") {
+			add("transport.path %q must start with /", cfg.Transport.Path)
+		}
+		for _, origin := range cfg.Transport.AllowedOrigins {
+			if !isHTTPURL(origin) {
+				add("transport.allowed_origins: %q is not an http(s) origin", origin)
+			}
+		}
+	default:
+		add("transport.type %q is not supported (supported: stdio, http)", cfg.Transport.Type)
+	}
+	if cfg.Transport.ShutdownTimeout <= 0 {
+		add("transport.shutdown_timeout must be positive, got %s", cfg.Transport.ShutdownTimeout)
+	}
+	if cfg.Transport.SessionTTL <= 0 {
+		add("transport.session_ttl must be positive, got %s", cfg.Transport.SessionTTL)
+	}
+	if cfg.Transport.MaxSessions <= 0 {
+		add("transport.max_sessions must be positive, got %d", cfg.Transport.MaxSessions)
+	}
+
+	if _, err := logrus.ParseLevel(cfg.Logging.Level); cfg.Logging.Level != "" && err != nil {
+		add("logging.level %q is not a valid level", cfg.Logging.Level)
+	}
+	if cfg.Logging.Format != "text" && cfg.Logging.Format != "json" {
+		add("logging.format %q must be text or json", cfg.Logging.Format)
+	}
+
+	switch t := cfg.Tracing; t.Exporter {
+	case "none", "otlp":
+	case "stdout":
+		if cfg.Transport.Type == "stdio" {
+			add("tracing.exporter stdout would corrupt the stdio transport; use file")
+		}
+	case "file":
+		if t.File == "" {
+			add("tracing.file must be set for the file exporter")
+		}
+	default:
+		add("tracing.exporter %q must be none, otlp, stdout, or file", t.Exporter)
+	}
+	if r := cfg.Tracing.SampleRatio; r < 0 || r > 1 {
+		add("tracing.sample_ratio must be between 0 and 1, got %g", r)
+	}
+
+	if o := cfg.OAuth; o.Enabled {
+		if cfg.Transport.Type != "http" {
+			add("oauth requires the http transport")
+		}
+		if !isHTTPURL(o.Resource) {
+			add("oauth.resource %q must be an absolute http or https URL", o.Resource)
+		}
+		if len(o.AuthorizationServers) == 0 {
+			add("oauth.authorization_servers must list at least one server")
+		}
+		for _, server := range o.AuthorizationServers {
+			if !isHTTPURL(server) {
+				add("oauth.authorization_servers: %q must be an absolute http or https URL", server)
+			}
+		}
+		if (o.JWKSFile == "") == (o.JWKSURL == "") {
+			add("oauth needs exactly one of jwks_file and jwks_url")
+		}
+		if o.JWKSURL != "" && !isHTTPURL(o.JWKSURL) {
+			add("oauth.jwks_url %q must be an absolute http or https URL", o.JWKSURL)
+		}
+	}
+
+	for _, pattern := range append(append([]string{}, cfg.Access.Allow...), cfg.Access.Deny...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			add("access: %q is not a valid glob pattern", pattern)
+		}
+	}
+
+	checkLimit := func(setting string, l RateLimitConfig) {
+		if l.PerMinute < 0 || l.Burst < 0 {
+			add("%s: per_minute and burst must not be negative", setting)
+		}
+	}
+	checkLimit("rate_limits.global", cfg.RateLimits.Global)
+	checkLimit("rate_limits.session", cfg.RateLimits.Session)
+	checkLimit("rate_limits.mutating", cfg.RateLimits.Mutating)
+
+	if _, ok := cfg.Tools[""]; ok {
+		add("tools: tool name must not be empty")
+	}
+	for name, tool := range cfg.Tools {
+		checkLimit("tools."+name+".rate_limit", tool.RateLimit)
+	}
+
+	if len(problems) > 0 {
+		return errors.New(strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+func isHTTPURL(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
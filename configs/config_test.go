@@ -0,0 +1,183 @@
+package configs
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDefaultIsValid(t *testing.T) {
+	if err := Default().Validate(); err != nil {
+		t.Errorf("Default().Validate() = %v", err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(cfg *Config)
+		want   string // part of the error; "" for a valid config
+	}{
+		{"http transport", func(cfg *Config) { cfg.Transport.Type = "http" }, ""},
+		{"empty server name", func(cfg *Config) { cfg.Server.Name = "" }, "server.name must not be empty"},
+		{"relative api url", func(cfg *Config) { cfg.API.URL = "/api/v1" }, `api.url "/api/v1" must be an absolute`},
+		{"ftp api url", func(cfg *Config) { cfg.API.URL = "ftp://store/api" }, "must be an absolute http or https URL"},
+		{"zero timeout", func(cfg *Config) { cfg.API.Timeout = 0 }, "api.timeout must be positive"},
+		{"no attempts", func(cfg *Config) { cfg.API.Retry.Attempts = 0 }, "api.retry.attempts must be at least 1"},
+		{"single attempt needs no backoff", func(cfg *Config) {
+			cfg.API.Retry = RetryConfig{Attempts: 1}
+		}, ""},
+		{"max backoff below initial", func(cfg *Config) { cfg.API.Retry.MaxBackoff = time.Millisecond }, "api.retry.max_backoff"},
+		{"breaker without cooldown", func(cfg *Config) { cfg.API.Breaker.Cooldown = 0 }, "api.circuit_breaker.cooldown"},
+		{"breaker disabled", func(cfg *Config) { cfg.API.Breaker = BreakerConfig{} }, ""},
+		{"cache route without slash", func(cfg *Config) {
+			cfg.API.Cache.Routes = map[string]time.Duration{"products": time.Minute}
+		}, `"products" must start with /`},
+		{"unknown transport", func(cfg *Config) { cfg.Transport.Type = "grpc" }, `transport.type "grpc" is not supported`},
+		{"http path without slash", func(cfg *Config) {
+			cfg.Transport.Type = "http"
+			cfg.Transport.Path = "mcp"
+		}, `transport.path "mcp" must start with /`},
+		{"bad origin", func(cfg *Config) {
+			cfg.Transport.Type = "http"
+			cfg.Transport.AllowedOrigins = []string{"example.com"}
+		}, `"example.com" is not an http(s) origin`},
+		{"bad log level", func(cfg *Config) { cfg.Logging.Level = "loud" }, `logging.level "loud"`},
+		{"bad log format", func(cfg *Config) { cfg.Logging.Format = "xml" }, `logging.format "xml"`},
+		{"stdout tracing on stdio", func(cfg *Config) { cfg.Tracing.Exporter = "stdout" }, "would corrupt the stdio transport"},
+		{"stdout tracing on http", func(cfg *Config) {
+			cfg.Transport.Type = "http"
+			cfg.Tracing.Exporter = "stdout"
+		}, ""},
+		{"file tracing without a file", func(cfg *Config) { cfg.Tracing.Exporter = "file" }, "tracing.file must be set"},
+		{"sample ratio above 1", func(cfg *Config) { cfg.Tracing.SampleRatio = 2 }, "tracing.sample_ratio"},
+		{"oauth on stdio", func(cfg *Config) {
+			cfg.OAuth = OAuthConfig{
+				Enabled:              true,
+				Resource:             "https://store.example.com/mcp",
+				AuthorizationServers: []string{"https://auth.example.com"},
+				JWKSURL:              "https://auth.example.com/jwks",
+			}
+		}, "oauth requires the http transport"},
+		{"oauth with both jwks sources", func(cfg *Config) {
+			cfg.Transport.Type = "http"
+			cfg.OAuth = OAuthConfig{
+				Enabled:              true,
+				Resource:             "https://store.example.com/mcp",
+				AuthorizationServers: []string{"https://auth.example.com"},
+				JWKSURL:              "https://auth.example.com/jwks",
+				JWKSFile:             "jwks.json",
+			}
+		}, "exactly one of jwks_file and jwks_url"},
+		{"bad access pattern", func(cfg *Config) { cfg.Access.Deny = []string{"[place"} }, `"[place" is not a valid glob pattern`},
+		{"negative rate limit", func(cfg *Config) { cfg.RateLimits.Session.PerMinute = -1 }, "rate_limits.session"},
+		{"empty tool name", func(cfg *Config) { cfg.Tools = map[string]ToolConfig{"": {}} }, "tool name must not be empty"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := Default()
+			tc.change(cfg)
+			err := cfg.Validate()
+			if tc.want == "" {
+				if err != nil {
+					t.Errorf("Validate = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("Validate = %v, want %q", err, tc.want)
+			}
+		})
+	}
+}
+
+func TestValidateReportsEveryProblem(t *testing.T) {
+	cfg := Default()
+	cfg.Server.Name = ""
+	cfg.Transport.Type = "grpc"
+	cfg.Logging.Format = "xml"
+
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("Validate succeeded")
+	}
+	if n := len(strings.Split(err.Error(), "; ")); n != 3 {
+		t.Errorf("Validate = %v, want 3 problems", err)
+	}
+}
+
+func writeConfig(t *testing.T, data string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	path := writeConfig(t, `
+server:
+  name: test-store
+api:
+  url: https://store.example.com/api
+  timeout: 5s
+tools:
+  place_order:
+    enabled: false
+`)
+	t.Setenv("API_TIMEOUT", "10s")
+	t.Setenv("TOOLS_DENY", "cancel_*, ,delete_*")
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Server.Name != "test-store" || cfg.Server.Version != Default().Server.Version {
+		t.Errorf("server = %+v, want the file's name over the default version", cfg.Server)
+	}
+	if cfg.API.URL != "https://store.example.com/api" {
+		t.Errorf("api.url = %s", cfg.API.URL)
+	}
+	if cfg.API.Timeout != 10*time.Second {
+		t.Errorf("api.timeout = %s, want the environment's 10s", cfg.API.Timeout)
+	}
+	if got := strings.Join(cfg.Access.Deny, " "); got != "cancel_* delete_*" {
+		t.Errorf("access.deny = %q, want the environment's list", got)
+	}
+	if cfg.Tools["place_order"].IsEnabled() || !cfg.Tools["list_orders"].IsEnabled() {
+		t.Errorf("tools = %+v, want only place_order disabled", cfg.Tools)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		env  map[string]string
+		want string
+	}{
+		{"unknown key", "server:\n  nmae: store\n", nil, "field nmae not found"},
+		{"json", `{"transport": {"type": "pigeon"}}`, nil, `transport.type "pigeon"`},
+		{"bad env duration", "", map[string]string{"API_TIMEOUT": "soon"}, `API_TIMEOUT: "soon" is not a duration`},
+		{"bad env boolean", "", map[string]string{"READ_ONLY": "maybe"}, `READ_ONLY: "maybe" is not a boolean`},
+		{"invalid value", "api:\n  timeout: 0s\n", nil, "api.timeout must be positive"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			path := writeConfig(t, tc.file)
+			for key, value := range tc.env {
+				t.Setenv(key, value)
+			}
+			_, err := Load(path)
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("Load = %v, want %q", err, tc.want)
+			}
+			if err != nil && tc.env == nil && !strings.Contains(err.Error(), path) {
+				t.Errorf("Load = %v, want it to name %s", err, path)
+			}
+		})
+	}
+}
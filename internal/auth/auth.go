@@ -0,0 +1,287 @@
+// Package auth logs MCP sessions in to the ecommerce API. A Session holds
+// the API tokens obtained by the login tool; the Manager performs logins
+// against the API's auth endpoints and refreshes access tokens shortly
+// before they expire, so cart and order calls keep working.
+package auth
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/trenchesdeveloper/mcp-server-store/internal/client"
+)
+
+// ErrNotLoggedIn is returned for authenticated calls made by a session that
+// has neither logged in nor brought its own token.
+var ErrNotLoggedIn = errors.New("not logged in: use the login tool with your email and password, or a refresh token")
+
+// refreshMargin is how long before expiry an access token is refreshed.
+const refreshMargin = time.Minute
+
+// ---- Session ----
+
+// Session is one MCP session's login to the ecommerce API.
+type Session struct {
+	mu           sync.Mutex
+	accessToken  string
+	refreshToken string
+	expiresAt    time.Time // zero if the token does not say
+	email        string
+
+	// refreshing serializes refreshes so concurrent calls use one new token.
+	refreshing sync.Mutex
+}
+
+// NewSession creates a session that is not logged in.
+func NewSession() *Session {
+	return &Session{}
+}
+
+// Status describes a session's login.
+type Status struct {
+	LoggedIn   bool
+	Email      string
+	ExpiresAt  time.Time
+	CanRefresh bool
+}
+
+// Status returns the session's current login.
+func (s *Session) Status() Status {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return Status{
+		LoggedIn:   s.accessToken != "",
+		Email:      s.email,
+		ExpiresAt:  s.expiresAt,
+		CanRefresh: s.refreshToken != "",
+	}
+}
+
+func (s *Session) set(tokens *tokenResponse) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.accessToken = tokens.AccessToken
+	s.refreshToken = tokens.RefreshToken
+	s.expiresAt = tokenExpiry(tokens.AccessToken)
+	if s.expiresAt.IsZero() && tokens.ExpiresIn > 0 {
+		s.expiresAt = time.Now().Add(time.Duration(tokens.ExpiresIn) * time.Second)
+	}
+	if tokens.User.Email != "" {
+		s.email = tokens.User.Email
+	}
+}
+
+func (s *Session) clear() (refreshToken string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	refreshToken = s.refreshToken
+	s.accessToken, s.refreshToken, s.email = "", "", ""
+	s.expiresAt = time.Time{}
+	return refreshToken
+}
+
+type sessionKey struct{}
+
+// ContextWithSession returns a copy of ctx whose calls log in as session.
+func ContextWithSession(ctx context.Context, session *Session) context.Context {
+	return context.WithValue(ctx, sessionKey{}, session)
+}
+
+// ---- Manager ----
+
+// Manager logs sessions in and out and keeps their tokens fresh.
+type Manager struct {
+	httpClient *client.RestClient
+	logger     *logrus.Logger
+
+	// fallback is the session of callers whose context carries none, i.e.
+	// the single stdio or in-process session.
+	fallback *Session
+}
+
+// NewManager creates a Manager calling the auth endpoints through httpClient.
+func NewManager(httpClient *client.RestClient, logger *logrus.Logger) *Manager {
+	return &Manager{httpClient: httpClient, logger: logger, fallback: NewSession()}
+}
+
+// Session returns the session ctx belongs to.
+func (m *Manager) Session(ctx context.Context) *Session {
+	if session, ok := ctx.Value(sessionKey{}).(*Session); ok {
+		return session
+	}
+	return m.fallback
+}
+
+// tokenResponse is the data of the API's login and refresh responses.
+type tokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int64  `json:"expires_in"`
+	User         struct {
+		Email string `json:"email"`
+	} `json:"user"`
+}
+
+type envelope struct {
+	Data tokenResponse `json:"data"`
+}
+
+// Login signs the session of ctx in with an email and password.
+func (m *Manager) Login(ctx context.Context, email, password string) (Status, error) {
+	tokens, err := m.requestTokens(ctx, "/auth/login", map[string]string{
+		"email":    email,
+		"password": password,
+	})
+	if err != nil {
+		return Status{}, fmt.Errorf("login failed: %w", err)
+	}
+	session := m.Session(ctx)
+	session.set(tokens)
+	m.logger.WithField("email", tokens.User.Email).Info("Session logged in")
+	return session.Status(), nil
+}
+
+// LoginWithRefreshToken signs the session of ctx in with a refresh token
+// obtained elsewhere.
+func (m *Manager) LoginWithRefreshToken(ctx context.Context, refreshToken string) (Status, error) {
+	tokens, err := m.requestTokens(ctx, "/auth/refresh", map[string]string{
+		"refresh_token": refreshToken,
+	})
+	if err != nil {
+		return Status{}, fmt.Errorf("login failed: %w", err)
+	}
+	session := m.Session(ctx)
+	session.set(tokens)
+	m.logger.WithField("email", tokens.User.Email).Info("Session logged in with refresh token")
+	return session.Status(), nil
+}
+
+// Logout forgets the session's tokens and revokes its refresh token. It
+// reports whether the session was logged in.
+func (m *Manager) Logout(ctx context.Context) bool {
+	session := m.Session(ctx)
+	wasLoggedIn := session.Status().LoggedIn
+	refreshToken := session.clear()
+	if refreshToken != "" {
+		// Best effort: the local tokens are gone either way.
+		if _, err := m.httpClient.WithContext(ctx).Post("/auth/logout", map[string]string{
+			"refresh_token": refreshToken,
+		}); err != nil {
+			m.logger.WithError(err).Warn("Failed to revoke refresh token")
+		}
+	}
+	if wasLoggedIn {
+		m.logger.Info("Session logged out")
+	}
+	return wasLoggedIn
+}
+
+// Authorize returns a copy of ctx whose authenticated API calls use the
+// session's access token, refreshing it first if it is about to expire.
+// A session that has not logged in keeps whatever token it already has,
+// such as the HTTP caller's bearer token or the configured AUTH_TOKEN;
+// without any token ErrNotLoggedIn is returned.
+func (m *Manager) Authorize(ctx context.Context) (context.Context, error) {
+	session := m.Session(ctx)
+	status := session.Status()
+	if !status.LoggedIn {
+		if m.httpClient.WithContext(ctx).HasToken() {
+			return ctx, nil
+		}
+		return ctx, ErrNotLoggedIn
+	}
+
+	if !status.ExpiresAt.IsZero() && time.Until(status.ExpiresAt) < refreshMargin {
+		if err := m.refresh(ctx, session); err != nil {
+			return ctx, err
+		}
+	}
+
+	session.mu.Lock()
+	token := session.accessToken
+	session.mu.Unlock()
+	return client.ContextWithToken(ctx, token), nil
+}
+
+// refresh exchanges the session's refresh token for new tokens. If the API
+// rejects the refresh token the session is logged out; after a temporary
+// failure it stays logged in so a later call can try again.
+func (m *Manager) refresh(ctx context.Context, session *Session) error {
+	session.refreshing.Lock()
+	defer session.refreshing.Unlock()
+
+	// Another call may have refreshed while this one waited.
+	status := session.Status()
+	if !status.LoggedIn {
+		return ErrNotLoggedIn
+	}
+	if time.Until(status.ExpiresAt) >= refreshMargin {
+		return nil
+	}
+	if !status.CanRefresh {
+		session.clear()
+		return fmt.Errorf("session expired: %w", ErrNotLoggedIn)
+	}
+
+	session.mu.Lock()
+	refreshToken := session.refreshToken
+	session.mu.Unlock()
+
+	tokens, err := m.requestTokens(ctx, "/auth/refresh", map[string]string{
+		"refresh_token": refreshToken,
+	})
+	if err != nil {
+		m.logger.WithError(err).Warn("Failed to refresh access token")
+		var apiErr *client.APIError
+		if errors.As(err, &apiErr) && !apiErr.Temporary() {
+			session.clear()
+			return fmt.Errorf("session expired and could not be refreshed (%v): %w", err, ErrNotLoggedIn)
+		}
+		return fmt.Errorf("refresh access token: %w", err)
+	}
+	session.set(tokens)
+	m.logger.WithField("expiresAt", session.Status().ExpiresAt).Info("Refreshed access token")
+	return nil
+}
+
+func (m *Manager) requestTokens(ctx context.Context, path string, body interface{}) (*tokenResponse, error) {
+	data, err := m.httpClient.WithContext(ctx).Post(path, body)
+	if err != nil {
+		return nil, err
+	}
+	var resp envelope
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, fmt.Errorf("parse auth response: %w", err)
+	}
+	if resp.Data.AccessToken == "" {
+		return nil, errors.New("no access token in auth response")
+	}
+	return &resp.Data, nil
+}
+
+// tokenExpiry reads the exp claim of a JWT without verifying it; the API
+// that issued the token is the one that checks it.
+func tokenExpiry(token string) time.Time {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return time.Time{}
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return time.Time{}
+	}
+	var claims struct {
+		ExpiresAt int64 `json:"exp"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil || claims.ExpiresAt == 0 {
+		return time.Time{}
+	}
+	return time.Unix(claims.ExpiresAt, 0)
+}
@@ -0,0 +1,79 @@
+package auth
+
+import (
+	"context"
+
+	"github.com/trenchesdeveloper/mcp-server-store/internal/store"
+)
+
+// ---- Store decorators ----
+
+// Cart wraps a store.Cart so every call is made with the caller's session
+// token, refreshed if needed.
+func Cart(cart store.Cart, m *Manager) store.Cart {
+	return &authCart{cart: cart, m: m}
+}
+
+type authCart struct {
+	cart store.Cart
+	m    *Manager
+}
+
+func (c *authCart) AddToCart(ctx context.Context, productID uint, quantity int) (*store.CartSummary, error) {
+	ctx, err := c.m.Authorize(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return c.cart.AddToCart(ctx, productID, quantity)
+}
+
+func (c *authCart) ViewCart(ctx context.Context) (*store.CartContents, error) {
+	ctx, err := c.m.Authorize(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return c.cart.ViewCart(ctx)
+}
+
+// Orders wraps a store.Orders so every call is made with the caller's
+// session token, refreshed if needed.
+func Orders(orders store.Orders, m *Manager) store.Orders {
+	return &authOrders{orders: orders, m: m}
+}
+
+type authOrders struct {
+	orders store.Orders
+	m      *Manager
+}
+
+func (o *authOrders) PlaceOrder(ctx context.Context) (*store.Order, error) {
+	ctx, err := o.m.Authorize(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return o.orders.PlaceOrder(ctx)
+}
+
+func (o *authOrders) ListOrders(ctx context.Context, opts store.ListOptions) (*store.OrderPage, error) {
+	ctx, err := o.m.Authorize(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return o.orders.ListOrders(ctx, opts)
+}
+
+func (o *authOrders) GetOrder(ctx context.Context, id uint) (*store.Order, error) {
+	ctx, err := o.m.Authorize(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return o.orders.GetOrder(ctx, id)
+}
+
+func (o *authOrders) CancelOrder(ctx context.Context, id uint) (*store.Order, error) {
+	ctx, err := o.m.Authorize(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return o.orders.CancelOrder(ctx, id)
+}
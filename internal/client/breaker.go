@@ -0,0 +1,253 @@
+package client
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/sirupsen/logrus"
+)
+
+// ---- Circuit breaker ----
+
+// BreakerPolicy controls the circuit breakers that stop calls to an API
+// that keeps failing. A breaker opens after FailureThreshold consecutive
+// failures (network errors and 5xx responses) and rejects calls for
+// Cooldown. It then lets one probe call through: success closes it, and
+// failure opens it for another cool-down.
+type BreakerPolicy struct {
+	// FailureThreshold is the number of consecutive failures that opens
+	// a breaker; 0 disables the breakers.
+	FailureThreshold int
+	Cooldown         time.Duration
+
+	// PerRoute keeps a breaker per route family, the first path segment
+	// such as /products or /orders, instead of one for the whole host.
+	PerRoute bool
+}
+
+// DefaultBreakerPolicy is used until SetBreakerPolicy is called.
+var DefaultBreakerPolicy = BreakerPolicy{
+	FailureThreshold: 5,
+	Cooldown:         30 * time.Second,
+}
+
+// Breaker states.
+const (
+	BreakerClosed   = "closed"
+	BreakerOpen     = "open"
+	BreakerHalfOpen = "half-open"
+)
+
+// BreakerState describes one circuit breaker, for health reporting.
+type BreakerState struct {
+	Name     string    `json:"name"`
+	State    string    `json:"state"`
+	Failures int       `json:"failures"`
+	OpenedAt time.Time `json:"opened_at,omitzero"`
+	RetryAt  time.Time `json:"retry_at,omitzero"`
+}
+
+// UnavailableError is returned without calling the API while its circuit
+// breaker is open.
+type UnavailableError struct {
+	Breaker string
+	RetryIn time.Duration
+}
+
+func (e *UnavailableError) Error() string {
+	seconds := int(math.Ceil(e.RetryIn.Seconds()))
+	return fmt.Sprintf("store API unavailable, retry in %d seconds", max(seconds, 1))
+}
+
+// breaker is one circuit breaker. Its fields are guarded by breakers.mu.
+type breaker struct {
+	name     string
+	state    string
+	failures int
+	openedAt time.Time
+	retryAt  time.Time
+	probing  bool // a half-open probe call is in flight
+}
+
+// breakers holds the breakers shared by a client and its copies.
+type breakers struct {
+	mu     sync.Mutex
+	policy BreakerPolicy
+	host   string
+	byName map[string]*breaker
+	logger *logrus.Logger
+	now    func() time.Time
+}
+
+func newBreakers(baseURL string, logger *logrus.Logger) *breakers {
+	host := baseURL
+	if u, err := url.Parse(baseURL); err == nil && u.Host != "" {
+		host = u.Host
+	}
+	return &breakers{
+		policy: DefaultBreakerPolicy,
+		host:   host,
+		byName: make(map[string]*breaker),
+		logger: logger,
+		now:    time.Now,
+	}
+}
+
+// SetBreakerPolicy replaces the circuit breaker policy of the client and
+// its copies. Every breaker starts over closed.
+func (c *RestClient) SetBreakerPolicy(policy BreakerPolicy) {
+	c.breakers.mu.Lock()
+	defer c.breakers.mu.Unlock()
+	c.breakers.policy = policy
+	c.breakers.byName = make(map[string]*breaker)
+}
+
+// Breakers returns the state of every circuit breaker that has seen a
+// call, ordered by name.
+func (c *RestClient) Breakers() []BreakerState {
+	b := c.breakers
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	states := make([]BreakerState, 0, len(b.byName))
+	for _, br := range b.byName {
+		state := br.state
+		if state == BreakerOpen && !b.now().Before(br.retryAt) {
+			state = BreakerHalfOpen // the next call will probe
+		}
+		states = append(states, BreakerState{
+			Name:     br.name,
+			State:    state,
+			Failures: br.failures,
+			OpenedAt: br.openedAt,
+			RetryAt:  br.retryAt,
+		})
+	}
+	sort.Slice(states, func(i, j int) bool { return states[i].Name < states[j].Name })
+	return states
+}
+
+// name returns the name of the breaker guarding path.
+func (b *breakers) name(path string) string {
+	if !b.policy.PerRoute {
+		return b.host
+	}
+	family, _, _ := strings.Cut(strings.TrimPrefix(path, "/"), "/")
+	return b.host + "/" + family
+}
+
+// outcome is how a call went, as far as its breaker is concerned.
+type outcome int
+
+const (
+	succeeded outcome = iota
+	failed
+	abandoned // the caller gave up, which says nothing about the API
+)
+
+// outcomeOf classifies an attempt. Client errors such as 404 mean the API
+// is up; network errors and 5xx responses count as failures.
+func outcomeOf(resp *resty.Response, err error, cancelled bool) outcome {
+	switch {
+	case cancelled:
+		return abandoned
+	case err != nil, resp.StatusCode() >= http.StatusInternalServerError:
+		return failed
+	}
+	return succeeded
+}
+
+// allow reports whether a call to path may go ahead. If it may, done must
+// be called with the call's outcome.
+func (b *breakers) allow(path string) (done func(outcome), err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.policy.FailureThreshold <= 0 {
+		return func(outcome) {}, nil
+	}
+	name := b.name(path)
+	br, ok := b.byName[name]
+	if !ok {
+		br = &breaker{name: name, state: BreakerClosed}
+		b.byName[name] = br
+	}
+
+	// Only the probe's outcome decides a half-open breaker; calls let
+	// through before it opened may still finish meanwhile.
+	probe := false
+	switch br.state {
+	case BreakerOpen:
+		if wait := br.retryAt.Sub(b.now()); wait > 0 {
+			return nil, &UnavailableError{Breaker: name, RetryIn: wait}
+		}
+		b.transition(br, BreakerHalfOpen)
+		br.probing, probe = true, true
+	case BreakerHalfOpen:
+		if br.probing {
+			return nil, &UnavailableError{Breaker: name, RetryIn: time.Second}
+		}
+		br.probing, probe = true, true
+	}
+
+	policy := b.policy
+	return func(result outcome) {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		if b.byName[name] != br {
+			return // the policy was replaced meanwhile
+		}
+		if probe {
+			br.probing = false
+		} else if br.state != BreakerClosed {
+			return // outlived by the breaker opening
+		}
+		switch result {
+		case abandoned:
+			return
+		case succeeded:
+			br.failures = 0
+			br.openedAt, br.retryAt = time.Time{}, time.Time{}
+			if probe {
+				b.transition(br, BreakerClosed)
+			}
+			return
+		}
+		br.failures++
+		if probe || br.failures >= policy.FailureThreshold {
+			br.openedAt = b.now()
+			br.retryAt = br.openedAt.Add(policy.Cooldown)
+			b.transition(br, BreakerOpen)
+		}
+	}, nil
+}
+
+// transition moves br to state and logs it. b.mu must be held.
+func (b *breakers) transition(br *breaker, state string) {
+	if br.state == state && state != BreakerOpen {
+		return
+	}
+	fields := logrus.Fields{
+		"breaker":  br.name,
+		"from":     br.state,
+		"to":       state,
+		"failures": br.failures,
+	}
+	br.state = state
+	switch state {
+	case BreakerOpen:
+		fields["retryAt"] = br.retryAt.Format(time.RFC3339)
+		b.logger.WithFields(fields).Warn("Circuit breaker opened")
+	case BreakerClosed:
+		b.logger.WithFields(fields).Info("Circuit breaker closed")
+	default:
+		b.logger.WithFields(fields).Info("Circuit breaker probing")
+	}
+}
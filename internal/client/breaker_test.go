@@ -0,0 +1,116 @@
+package client
+
+import (
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func newTestBreakers(t *testing.T, policy BreakerPolicy) (*breakers, *time.Time) {
+	t.Helper()
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	b := newBreakers("http://api.test/api/v1", logger)
+	b.policy = policy
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	b.now = func() time.Time { return now }
+	return b, &now
+}
+
+func mustAllow(t *testing.T, b *breakers, path string) func(outcome) {
+	t.Helper()
+	done, err := b.allow(path)
+	if err != nil {
+		t.Fatalf("allow(%s): %v", path, err)
+	}
+	return done
+}
+
+func breakerState(b *breakers, name string) string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.byName[name].state
+}
+
+func TestBreakerOpensAfterThresholdAndProbes(t *testing.T) {
+	b, now := newTestBreakers(t, BreakerPolicy{FailureThreshold: 2, Cooldown: 10 * time.Second})
+
+	mustAllow(t, b, "/products")(failed)
+	mustAllow(t, b, "/products")(failed)
+	if got := breakerState(b, "api.test"); got != BreakerOpen {
+		t.Fatalf("state after 2 failures = %s, want open", got)
+	}
+
+	_, err := b.allow("/products")
+	var unavailable *UnavailableError
+	if !errors.As(err, &unavailable) || unavailable.RetryIn != 10*time.Second {
+		t.Fatalf("allow while open = %v, want UnavailableError retrying in 10s", err)
+	}
+
+	*now = now.Add(10 * time.Second)
+	probe := mustAllow(t, b, "/products")
+	if _, err := b.allow("/products"); err == nil {
+		t.Fatal("second call while probing was allowed")
+	}
+	probe(failed)
+	if got := breakerState(b, "api.test"); got != BreakerOpen {
+		t.Fatalf("state after failed probe = %s, want open", got)
+	}
+
+	*now = now.Add(10 * time.Second)
+	mustAllow(t, b, "/products")(succeeded)
+	if got := breakerState(b, "api.test"); got != BreakerClosed {
+		t.Fatalf("state after successful probe = %s, want closed", got)
+	}
+}
+
+func TestBreakerIgnoresCallsOutlivedByOpening(t *testing.T) {
+	b, now := newTestBreakers(t, BreakerPolicy{FailureThreshold: 1, Cooldown: 10 * time.Second})
+
+	slow := mustAllow(t, b, "/orders")
+	mustAllow(t, b, "/orders")(failed)
+
+	*now = now.Add(10 * time.Second)
+	probe := mustAllow(t, b, "/orders")
+
+	// The call let through before the breaker opened finishes while the
+	// probe is still running; it must not end the probe or close the breaker.
+	slow(succeeded)
+	if got := breakerState(b, "api.test"); got != BreakerHalfOpen {
+		t.Fatalf("state after stale success = %s, want half-open", got)
+	}
+	if _, err := b.allow("/orders"); err == nil {
+		t.Fatal("second probe was allowed while the first is in flight")
+	}
+
+	probe(failed)
+	if got := breakerState(b, "api.test"); got != BreakerOpen {
+		t.Fatalf("state after failed probe = %s, want open", got)
+	}
+}
+
+func TestBreakerAbandonedProbeAllowsAnother(t *testing.T) {
+	b, now := newTestBreakers(t, BreakerPolicy{FailureThreshold: 1, Cooldown: time.Second})
+
+	mustAllow(t, b, "/cart")(failed)
+	*now = now.Add(time.Second)
+	mustAllow(t, b, "/cart")(abandoned)
+
+	mustAllow(t, b, "/cart")(succeeded)
+	if got := breakerState(b, "api.test"); got != BreakerClosed {
+		t.Fatalf("state = %s, want closed", got)
+	}
+}
+
+func TestBreakerPerRoute(t *testing.T) {
+	b, _ := newTestBreakers(t, BreakerPolicy{FailureThreshold: 1, Cooldown: time.Minute, PerRoute: true})
+
+	mustAllow(t, b, "/orders/1/cancel")(failed)
+	if _, err := b.allow("/orders"); err == nil {
+		t.Fatal("call to /orders allowed while its breaker is open")
+	}
+	mustAllow(t, b, "/products/1")(succeeded)
+}
@@ -0,0 +1,287 @@
+package client
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ---- Response cache ----
+
+// CachePolicy controls the cache of successful GET responses. Responses
+// are cached per path, query, and credentials, for the TTL of the longest
+// route prefix matching the path; paths without a route, or whose route
+// has a zero TTL, are not cached. Expired entries with an ETag or
+// Last-Modified validator are revalidated instead of fetched again. An
+// authenticated POST, PUT, or DELETE drops every entry cached with its
+// credentials, so the cart and orders are read fresh after a change.
+type CachePolicy struct {
+	// Routes maps path prefixes, such as "/products", to TTLs.
+	Routes map[string]time.Duration
+
+	// MaxEntries and MaxBytes bound the cache; the least recently used
+	// entries are evicted first. Zero means no limit.
+	MaxEntries int
+	MaxBytes   int64
+}
+
+// ttl returns the TTL for path: that of the longest matching route.
+func (p CachePolicy) ttl(path string) time.Duration {
+	var ttl time.Duration
+	longest := -1
+	for prefix, d := range p.Routes {
+		if len(prefix) > longest && hasPathPrefix(path, prefix) {
+			ttl, longest = d, len(prefix)
+		}
+	}
+	return ttl
+}
+
+// hasPathPrefix reports whether path is prefix or lies below it.
+func hasPathPrefix(path, prefix string) bool {
+	prefix = strings.TrimSuffix(prefix, "/")
+	return path == prefix || strings.HasPrefix(path, prefix+"/") || prefix == ""
+}
+
+type cacheEntry struct {
+	key          string
+	identity     string
+	body         []byte
+	etag         string
+	lastModified string
+	expires      time.Time
+}
+
+// responseCache is an LRU cache shared by a client and its copies.
+type responseCache struct {
+	mu      sync.Mutex
+	policy  CachePolicy
+	entries map[string]*list.Element // of *cacheEntry
+	lru     *list.List               // most recently used first
+	bytes   int64
+	logger  *logrus.Logger
+
+	// generation counts invalidations, so a response fetched before one
+	// is not cached after it.
+	generation uint64
+	now        func() time.Time
+}
+
+func newResponseCache(logger *logrus.Logger) *responseCache {
+	return &responseCache{
+		entries: make(map[string]*list.Element),
+		lru:     list.New(),
+		logger:  logger,
+		now:     time.Now,
+	}
+}
+
+// SetCachePolicy replaces the response cache policy of the client and its
+// copies, emptying the cache. The zero policy turns caching off.
+func (c *RestClient) SetCachePolicy(policy CachePolicy) {
+	rc := c.cache
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.policy = policy
+	rc.entries = make(map[string]*list.Element)
+	rc.lru.Init()
+	rc.bytes = 0
+}
+
+// CacheStats describes the response cache.
+type CacheStats struct {
+	Entries int   `json:"entries"`
+	Bytes   int64 `json:"bytes"`
+}
+
+// CacheStats returns the size of the response cache.
+func (c *RestClient) CacheStats() CacheStats {
+	rc := c.cache
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	return CacheStats{Entries: rc.lru.Len(), Bytes: rc.bytes}
+}
+
+// identity identifies the credentials of a request without keeping them.
+func (c *RestClient) identity() string {
+	if !c.useToken {
+		return ""
+	}
+	token := c.token()
+	if token == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:8])
+}
+
+// requestKey identifies a GET by its path, query, and credentials. The
+// query is encoded in sorted order.
+func requestKey(path string, query map[string]string, identity string) string {
+	values := make(url.Values, len(query))
+	for k, v := range query {
+		values.Set(k, v)
+	}
+	return identity + " " + path + "?" + values.Encode()
+}
+
+// lookup returns the entry for key, and whether it is still fresh. A stale
+// entry is returned only if it can be revalidated.
+func (rc *responseCache) lookup(key string) (entry *cacheEntry, fresh bool) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	el, ok := rc.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry = el.Value.(*cacheEntry)
+	if rc.now().Before(entry.expires) {
+		rc.lru.MoveToFront(el)
+		return entry, true
+	}
+	if entry.etag == "" && entry.lastModified == "" {
+		rc.remove(el)
+		return nil, false
+	}
+	return entry, false
+}
+
+func (rc *responseCache) currentGeneration() uint64 {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	return rc.generation
+}
+
+// store caches a successful response to the request identified by key,
+// unless the cache was invalidated since generation.
+func (rc *responseCache) store(key, identity string, ttl time.Duration, generation uint64, resp *resty.Response) {
+	entry := &cacheEntry{
+		key:          key,
+		identity:     identity,
+		body:         resp.Body(),
+		etag:         resp.Header().Get("ETag"),
+		lastModified: resp.Header().Get("Last-Modified"),
+		expires:      rc.now().Add(ttl),
+	}
+	size := int64(len(entry.body))
+
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	if rc.generation != generation || (rc.policy.MaxBytes > 0 && size > rc.policy.MaxBytes) {
+		return
+	}
+	if el, ok := rc.entries[key]; ok {
+		rc.remove(el)
+	}
+	rc.entries[key] = rc.lru.PushFront(entry)
+	rc.bytes += size
+
+	for (rc.policy.MaxEntries > 0 && rc.lru.Len() > rc.policy.MaxEntries) ||
+		(rc.policy.MaxBytes > 0 && rc.bytes > rc.policy.MaxBytes) {
+		rc.remove(rc.lru.Back())
+	}
+}
+
+// revalidated extends an entry the API confirmed is unchanged.
+func (rc *responseCache) revalidated(entry *cacheEntry, ttl time.Duration) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	if el, ok := rc.entries[entry.key]; ok && el.Value == entry {
+		entry.expires = rc.now().Add(ttl)
+		rc.lru.MoveToFront(el)
+	}
+}
+
+// invalidate drops every entry cached with identity.
+func (rc *responseCache) invalidate(identity string) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.generation++
+	dropped := 0
+	for el := rc.lru.Front(); el != nil; {
+		next := el.Next()
+		if el.Value.(*cacheEntry).identity == identity {
+			rc.remove(el)
+			dropped++
+		}
+		el = next
+	}
+	if dropped > 0 {
+		rc.logger.WithField("entries", dropped).Debug("Invalidated cached API responses")
+	}
+}
+
+// remove drops an entry. rc.mu must be held.
+func (rc *responseCache) remove(el *list.Element) {
+	entry := rc.lru.Remove(el).(*cacheEntry)
+	delete(rc.entries, entry.key)
+	rc.bytes -= int64(len(entry.body))
+}
+
+// cachedGet serves a GET from the cache when it can, revalidating stale
+// entries, and caches successful responses. Identical GETs in flight at
+// the same time share one upstream call.
+func (c *RestClient) cachedGet(path string, query map[string]string, build func(*resty.Request)) ([]byte, error) {
+	rc := c.cache
+	rc.mu.Lock()
+	ttl := rc.policy.ttl(path)
+	rc.mu.Unlock()
+
+	identity := c.identity()
+	key := requestKey(path, query, identity)
+	if ttl <= 0 {
+		return c.coalesce(key, identity, func(ctx context.Context) ([]byte, error) {
+			return c.WithContext(ctx).send(http.MethodGet, path, build)
+		})
+	}
+
+	generation := rc.currentGeneration()
+	entry, fresh := rc.lookup(key)
+	if fresh {
+		trace.SpanFromContext(c.ctx).AddEvent("API response served from cache",
+			trace.WithAttributes(attribute.String("url.path", path)))
+		c.logger.WithField("path", path).Debug("API response served from cache")
+		return entry.body, nil
+	}
+
+	conditional := build
+	if entry != nil {
+		conditional = func(req *resty.Request) {
+			build(req)
+			if entry.etag != "" {
+				req.SetHeader("If-None-Match", entry.etag)
+			}
+			if entry.lastModified != "" {
+				req.SetHeader("If-Modified-Since", entry.lastModified)
+			}
+		}
+	}
+
+	return c.coalesce(key, identity, func(ctx context.Context) ([]byte, error) {
+		resp, err := c.WithContext(ctx).execute(http.MethodGet, path, conditional)
+		if err != nil {
+			return nil, err
+		}
+		if entry != nil && resp.StatusCode() == http.StatusNotModified {
+			rc.revalidated(entry, ttl)
+			c.logger.WithField("path", path).Debug("Cached API response revalidated")
+			return entry.body, nil
+		}
+		if err := checkResponse(resp); err != nil {
+			return nil, err
+		}
+		rc.store(key, identity, ttl, generation, resp)
+		return resp.Body(), nil
+	})
+}
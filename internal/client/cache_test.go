@@ -0,0 +1,286 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newCachingClient returns a client caching with policy, on a fake clock,
+// and a count of the requests the API received.
+func newCachingClient(t *testing.T, policy CachePolicy, handler http.HandlerFunc) (*RestClient, *atomic.Int32, *time.Time) {
+	t.Helper()
+	calls := new(atomic.Int32)
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		handler(w, r)
+	})
+	c.SetCachePolicy(policy)
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	c.cache.now = func() time.Time { return now }
+	return c, calls, &now
+}
+
+func okBody(w http.ResponseWriter, r *http.Request) {
+	fmt.Fprintf(w, `{"success":true,"path":%q}`, r.URL.Path)
+}
+
+func TestCachePolicyTTL(t *testing.T) {
+	policy := CachePolicy{Routes: map[string]time.Duration{
+		"/products":        time.Minute,
+		"/products/search": 0,
+		"/orders/":         10 * time.Second,
+	}}
+	tests := []struct {
+		path string
+		want time.Duration
+	}{
+		{"/products", time.Minute},
+		{"/products/42", time.Minute},
+		{"/products/search", 0},
+		{"/products/search/more", 0},
+		{"/productsale", 0},
+		{"/orders", 10 * time.Second},
+		{"/orders/7", 10 * time.Second},
+		{"/cart", 0},
+	}
+	for _, tc := range tests {
+		if got := policy.ttl(tc.path); got != tc.want {
+			t.Errorf("ttl(%q) = %v, want %v", tc.path, got, tc.want)
+		}
+	}
+	if got := (CachePolicy{Routes: map[string]time.Duration{"/": time.Second}}).ttl("/cart"); got != time.Second {
+		t.Errorf("ttl under the root route = %v, want 1s", got)
+	}
+}
+
+func TestCacheServesFreshResponses(t *testing.T) {
+	c, calls, now := newCachingClient(t, CachePolicy{Routes: map[string]time.Duration{"/products": time.Minute}}, okBody)
+
+	for i := 0; i < 3; i++ {
+		if _, err := c.Get("/products", map[string]string{"page": "1"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("API calls for a fresh entry = %d, want 1", n)
+	}
+
+	if _, err := c.Get("/products", map[string]string{"page": "2"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Get("/cart", nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Get("/cart", nil); err != nil {
+		t.Fatal(err)
+	}
+	if n := calls.Load(); n != 4 {
+		t.Errorf("API calls for another query and an uncached route = %d, want 4", n)
+	}
+
+	// Without a validator, an expired entry is fetched again.
+	*now = now.Add(time.Minute)
+	if _, err := c.Get("/products", map[string]string{"page": "1"}); err != nil {
+		t.Fatal(err)
+	}
+	if n := calls.Load(); n != 5 {
+		t.Errorf("API calls after expiry = %d, want 5", n)
+	}
+}
+
+func TestCacheSkipsFailures(t *testing.T) {
+	c, calls, _ := newCachingClient(t, CachePolicy{Routes: map[string]time.Duration{"/products": time.Minute}},
+		func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"success":false,"message":"Product not found"}`))
+		})
+
+	for i := 0; i < 2; i++ {
+		if _, err := c.Get("/products/9", nil); err == nil {
+			t.Fatal("Get of a missing product succeeded")
+		}
+	}
+	if n := calls.Load(); n != 2 {
+		t.Errorf("API calls = %d, want 2: errors are not cached", n)
+	}
+}
+
+func TestCacheRevalidates(t *testing.T) {
+	tests := []struct {
+		name      string
+		validator string // response header
+		condition string // request header
+		value     string
+	}{
+		{"etag", "ETag", "If-None-Match", `"v1"`},
+		{"last-modified", "Last-Modified", "If-Modified-Since", "Thu, 01 Jan 2026 00:00:00 GMT"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var conditions []string
+			c, calls, now := newCachingClient(t, CachePolicy{Routes: map[string]time.Duration{"/products": time.Minute}},
+				func(w http.ResponseWriter, r *http.Request) {
+					conditions = append(conditions, r.Header.Get(tc.condition))
+					w.Header().Set(tc.validator, tc.value)
+					if r.Header.Get(tc.condition) == tc.value {
+						w.WriteHeader(http.StatusNotModified)
+						return
+					}
+					w.Write([]byte(`{"success":true,"data":"first"}`))
+				})
+
+			first, err := c.Get("/products/1", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			*now = now.Add(2 * time.Minute)
+			second, err := c.Get("/products/1", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(second) != string(first) {
+				t.Errorf("body after 304 = %s, want the cached %s", second, first)
+			}
+			if len(conditions) != 2 || conditions[0] != "" || conditions[1] != tc.value {
+				t.Errorf("%s sent = %q, want none and then %s", tc.condition, conditions, tc.value)
+			}
+
+			// The 304 made the entry fresh again.
+			if _, err := c.Get("/products/1", nil); err != nil {
+				t.Fatal(err)
+			}
+			if n := calls.Load(); n != 2 {
+				t.Errorf("API calls = %d, want 2", n)
+			}
+		})
+	}
+}
+
+func TestCacheRevalidationReplacesChangedEntry(t *testing.T) {
+	version := "v1"
+	c, _, now := newCachingClient(t, CachePolicy{Routes: map[string]time.Duration{"/products": time.Minute}},
+		func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("ETag", version)
+			if r.Header.Get("If-None-Match") == version {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			fmt.Fprintf(w, `{"success":true,"version":%q}`, version)
+		})
+
+	if _, err := c.Get("/products/1", nil); err != nil {
+		t.Fatal(err)
+	}
+	version = "v2"
+	*now = now.Add(2 * time.Minute)
+	body, err := c.Get("/products/1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(body) != `{"success":true,"version":"v2"}` {
+		t.Errorf("body after a changed ETag = %s, want v2", body)
+	}
+}
+
+func TestCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	routes := map[string]time.Duration{"/products": time.Minute}
+	c, calls, _ := newCachingClient(t, CachePolicy{Routes: routes, MaxEntries: 2}, okBody)
+
+	get := func(path string) {
+		t.Helper()
+		if _, err := c.Get(path, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	get("/products/1")
+	get("/products/2")
+	get("/products/1") // now the most recently used
+	get("/products/3") // evicts /products/2
+	if stats := c.CacheStats(); stats.Entries != 2 {
+		t.Errorf("entries = %d, want 2", stats.Entries)
+	}
+
+	before := calls.Load()
+	get("/products/1")
+	if calls.Load() != before {
+		t.Error("the recently used entry was evicted")
+	}
+	get("/products/2")
+	if calls.Load() != before+1 {
+		t.Error("the least recently used entry was kept")
+	}
+}
+
+func TestCacheBoundsBytes(t *testing.T) {
+	c, _, _ := newCachingClient(t, CachePolicy{
+		Routes:   map[string]time.Duration{"/products": time.Minute},
+		MaxBytes: 100,
+	}, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/products/big" {
+			fmt.Fprintf(w, `{"success":true,"data":%q}`, string(make([]byte, 200)))
+			return
+		}
+		okBody(w, r)
+	})
+
+	for _, path := range []string{"/products/1", "/products/2", "/products/3"} {
+		if _, err := c.Get(path, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if stats := c.CacheStats(); stats.Bytes > 100 || stats.Entries != 2 {
+		t.Errorf("cache = %+v, want 2 entries within 100 bytes", stats)
+	}
+
+	if _, err := c.Get("/products/big", nil); err != nil {
+		t.Fatal(err)
+	}
+	if stats := c.CacheStats(); stats.Entries != 2 {
+		t.Errorf("entries after a response larger than the cache = %d, want it skipped", stats.Entries)
+	}
+}
+
+func TestCacheInvalidatesPerIdentity(t *testing.T) {
+	c, calls, _ := newCachingClient(t, CachePolicy{Routes: map[string]time.Duration{"/cart": time.Minute}}, okBody)
+	as := func(token string) *RestClient {
+		return c.WithToken().WithContext(ContextWithToken(context.Background(), token))
+	}
+	alice, bob := as("alice-token"), as("bob-token")
+
+	for _, user := range []*RestClient{alice, bob, alice, bob} {
+		if _, err := user.Get("/cart", nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := calls.Load(); n != 2 {
+		t.Fatalf("API calls = %d, want one per user", n)
+	}
+
+	if _, err := alice.Post("/cart/items", nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := bob.Get("/cart", nil); err != nil {
+		t.Fatal(err)
+	}
+	if n := calls.Load(); n != 3 {
+		t.Errorf("API calls after alice's change = %d, want bob's cart still cached", n)
+	}
+	if _, err := alice.Get("/cart", nil); err != nil {
+		t.Fatal(err)
+	}
+	if n := calls.Load(); n != 4 {
+		t.Errorf("API calls = %d, want alice's cart read again", n)
+	}
+
+	// Anonymous requests, such as logging in, drop nothing.
+	if _, err := c.Post("/auth/login", nil); err != nil {
+		t.Fatal(err)
+	}
+	if stats := c.CacheStats(); stats.Entries != 2 {
+		t.Errorf("entries after an anonymous POST = %d, want 2", stats.Entries)
+	}
+}
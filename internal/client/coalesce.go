@@ -0,0 +1,102 @@
+package client
+
+import (
+	"context"
+	"sync"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ---- Request coalescing ----
+
+// flight is an upstream GET shared by every caller asking for the same
+// response while it is in progress.
+type flight struct {
+	identity string // credentials of the request; see RestClient.identity
+
+	done    chan struct{}
+	body    []byte
+	err     error
+	waiters int
+	cancel  context.CancelFunc
+}
+
+// flights tracks the in-flight GETs of a client and its copies.
+type flights struct {
+	mu    sync.Mutex
+	byKey map[string]*flight
+}
+
+func newFlights() *flights {
+	return &flights{byKey: make(map[string]*flight)}
+}
+
+// coalesce returns the result of fetch for key, joining a call already in
+// flight for the same key instead of starting another. identity is the
+// credentials the key was built with, so detach can find the call. The shared call
+// runs on a context that keeps the values of the first caller's context
+// but not its cancellation, so a caller giving up does not fail the
+// others; it is cancelled only once every caller has given up. The
+// returned body is shared and must not be modified.
+func (c *RestClient) coalesce(key, identity string, fetch func(ctx context.Context) ([]byte, error)) ([]byte, error) {
+	ctx := c.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	f := c.flights
+	f.mu.Lock()
+	fl, joined := f.byKey[key]
+	if joined {
+		fl.waiters++
+	} else {
+		shared, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		fl = &flight{identity: identity, done: make(chan struct{}), waiters: 1, cancel: cancel}
+		f.byKey[key] = fl
+		go func() {
+			defer cancel()
+			fl.body, fl.err = fetch(shared)
+			f.mu.Lock()
+			if f.byKey[key] == fl {
+				delete(f.byKey, key)
+			}
+			f.mu.Unlock()
+			close(fl.done)
+		}()
+	}
+	f.mu.Unlock()
+
+	if joined {
+		trace.SpanFromContext(ctx).AddEvent("Joined in-flight API request")
+		c.logger.WithField("key", key).Debug("Joined in-flight API request")
+	}
+
+	select {
+	case <-fl.done:
+		return fl.body, fl.err
+	case <-ctx.Done():
+		f.mu.Lock()
+		fl.waiters--
+		if fl.waiters == 0 {
+			fl.cancel()
+			if f.byKey[key] == fl {
+				delete(f.byKey, key)
+			}
+		}
+		f.mu.Unlock()
+		return nil, ctx.Err()
+	}
+}
+
+// detach stops later GETs with identity from joining the calls now in
+// flight, which may have read state a mutation has since changed. Callers
+// already waiting still get the result of their call.
+func (f *flights) detach(identity string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for key, fl := range f.byKey {
+		if fl.identity == identity {
+			delete(f.byKey, key)
+		}
+	}
+}
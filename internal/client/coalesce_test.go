@@ -0,0 +1,195 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// waitForWaiters waits until n callers wait on the client's flights.
+func waitForWaiters(t *testing.T, c *RestClient, n int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		c.flights.mu.Lock()
+		waiters := 0
+		for _, fl := range c.flights.byKey {
+			waiters += fl.waiters
+		}
+		c.flights.mu.Unlock()
+		if waiters == n {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%d callers waiting on flights, want %d", waiters, n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestIdenticalGetsShareOneCall(t *testing.T) {
+	var calls atomic.Int32
+	release := make(chan struct{})
+	c := newTestClient(t, func(w http.ResponseWriter, _ *http.Request) {
+		n := calls.Add(1)
+		<-release
+		fmt.Fprintf(w, `{"success":true,"call":%d}`, n)
+	})
+
+	const callers = 5
+	bodies := make([]string, callers)
+	var wg sync.WaitGroup
+	for i := range callers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			body, err := c.Get("/products", map[string]string{"page": "1"})
+			if err != nil {
+				t.Errorf("Get: %v", err)
+			}
+			bodies[i] = string(body)
+		}()
+	}
+	waitForWaiters(t, c, callers)
+	close(release)
+	wg.Wait()
+
+	if n := calls.Load(); n != 1 {
+		t.Errorf("upstream calls = %d, want 1", n)
+	}
+	for i, body := range bodies {
+		if body != bodies[0] {
+			t.Errorf("caller %d got %q, caller 0 got %q", i, body, bodies[0])
+		}
+	}
+
+	// Different queries are different requests.
+	if _, err := c.Get("/products", map[string]string{"page": "2"}); err != nil {
+		t.Fatal(err)
+	}
+	if n := calls.Load(); n != 2 {
+		t.Errorf("upstream calls after another query = %d, want 2", n)
+	}
+}
+
+func TestCancelledWaiterLeavesOthersTheCall(t *testing.T) {
+	release := make(chan struct{})
+	upstreamCancelled := make(chan struct{}, 1)
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+			w.Write([]byte(`{"success":true}`))
+		case <-r.Context().Done():
+			upstreamCancelled <- struct{}{}
+		}
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	gaveUp := make(chan error, 1)
+	go func() {
+		_, err := c.WithContext(ctx).Get("/products", nil)
+		gaveUp <- err
+	}()
+	waitForWaiters(t, c, 1)
+	stayed := make(chan error, 1)
+	go func() {
+		_, err := c.Get("/products", nil)
+		stayed <- err
+	}()
+	waitForWaiters(t, c, 2)
+
+	cancel()
+	if err := <-gaveUp; err != context.Canceled {
+		t.Errorf("cancelled caller = %v, want context.Canceled", err)
+	}
+	close(release)
+	if err := <-stayed; err != nil {
+		t.Errorf("remaining caller = %v, want the response", err)
+	}
+	select {
+	case <-upstreamCancelled:
+		t.Error("the shared call was cancelled while a caller still waited")
+	default:
+	}
+}
+
+func TestLastWaiterCancelsTheCall(t *testing.T) {
+	started := make(chan struct{})
+	upstreamCancelled := make(chan struct{})
+	c := newTestClient(t, func(_ http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-r.Context().Done()
+		close(upstreamCancelled)
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		_, err := c.WithContext(ctx).Get("/products", nil)
+		done <- err
+	}()
+	<-started
+	cancel()
+
+	if err := <-done; err != context.Canceled {
+		t.Errorf("Get = %v, want context.Canceled", err)
+	}
+	select {
+	case <-upstreamCancelled:
+	case <-time.After(5 * time.Second):
+		t.Error("the upstream call outlived its last caller")
+	}
+}
+
+func TestMutationDetachesFlights(t *testing.T) {
+	var gets atomic.Int32
+	firstStarted := make(chan struct{})
+	release := make(chan struct{})
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Write([]byte(`{"success":true}`))
+			return
+		}
+		n := gets.Add(1)
+		if n == 1 {
+			close(firstStarted)
+			<-release
+		}
+		fmt.Fprintf(w, `{"success":true,"read":%d}`, n)
+	})
+	alice := c.WithToken().WithContext(ContextWithToken(context.Background(), "alice-token"))
+
+	stale := make(chan string, 1)
+	go func() {
+		body, _ := alice.Get("/cart", nil)
+		stale <- string(body)
+	}()
+	<-firstStarted
+
+	if _, err := alice.Post("/cart/items", map[string]int{"product_id": 1}); err != nil {
+		t.Fatalf("Post: %v", err)
+	}
+
+	fresh := make(chan string, 1)
+	go func() {
+		body, _ := alice.Get("/cart", nil)
+		fresh <- string(body)
+	}()
+	select {
+	case body := <-fresh:
+		if body != `{"success":true,"read":2}` {
+			t.Errorf("GET after the mutation = %s, want a read of its own", body)
+		}
+	case <-time.After(5 * time.Second):
+		t.Error("GET after the mutation joined the flight started before it")
+	}
+
+	close(release)
+	if body := <-stale; body != `{"success":true,"read":1}` {
+		t.Errorf("GET in flight during the mutation = %s, want its own read", body)
+	}
+}
@@ -0,0 +1,116 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// APIError is returned for a response the ecommerce API did not mark as a
+// success: a non-2xx status, or a 2xx whose envelope has success=false.
+type APIError struct {
+	StatusCode int
+	Method     string
+	Path       string
+
+	// Message and Detail are the envelope's message and error fields.
+	Message string
+	Detail  string
+
+	// RequestID identifies the request in the API's logs, if it sent one.
+	RequestID string
+}
+
+func (e *APIError) Error() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s %s: %s", e.Method, e.Path, e.Status())
+	if e.Message != "" {
+		fmt.Fprintf(&sb, ": %s", e.Message)
+	}
+	if e.Detail != "" && e.Detail != e.Message {
+		fmt.Fprintf(&sb, " (%s)", e.Detail)
+	}
+	if e.RequestID != "" {
+		fmt.Fprintf(&sb, " [request %s]", e.RequestID)
+	}
+	return sb.String()
+}
+
+// Status returns the status code and text, e.g. "404 Not Found".
+func (e *APIError) Status() string {
+	if text := http.StatusText(e.StatusCode); text != "" {
+		return fmt.Sprintf("%d %s", e.StatusCode, text)
+	}
+	return fmt.Sprintf("HTTP %d", e.StatusCode)
+}
+
+// Temporary reports whether the request may succeed if repeated later.
+func (e *APIError) Temporary() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= 500
+}
+
+// requestIDHeaders are the response headers checked for a request ID.
+var requestIDHeaders = []string{"X-Request-Id", "X-Correlation-Id", "Request-Id"}
+
+// checkResponse returns an *APIError if resp is not a success.
+func checkResponse(resp *resty.Response) error {
+	var body struct {
+		Success   *bool  `json:"success"`
+		Message   string `json:"message"`
+		Error     string `json:"error"`
+		RequestID string `json:"request_id"`
+	}
+	// Bodies that are not an envelope leave every field empty.
+	_ = json.Unmarshal(resp.Body(), &body)
+
+	if resp.IsSuccess() && (body.Success == nil || *body.Success) {
+		return nil
+	}
+
+	apiErr := &APIError{
+		StatusCode: resp.StatusCode(),
+		Method:     resp.Request.Method,
+		Path:       requestPath(resp.Request.URL),
+		Message:    body.Message,
+		Detail:     body.Error,
+		RequestID:  body.RequestID,
+	}
+	for _, header := range requestIDHeaders {
+		if apiErr.RequestID != "" {
+			break
+		}
+		apiErr.RequestID = resp.Header().Get(header)
+	}
+	if apiErr.Message == "" && !resp.IsSuccess() {
+		// Not an envelope, e.g. a proxy's error page; keep a short excerpt.
+		apiErr.Message = excerpt(resp.String(), 200)
+	}
+	return apiErr
+}
+
+// requestPath strips the scheme, host, and query from a request URL.
+func requestPath(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	return u.Path
+}
+
+// excerpt collapses the whitespace in s and cuts it to at most n bytes,
+// backing up to the start of a rune so the result stays valid UTF-8.
+func excerpt(s string, n int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n] + "..."
+}
@@ -0,0 +1,26 @@
+package client
+
+import (
+	"testing"
+	"unicode/utf8"
+)
+
+func TestExcerptKeepsRunesWhole(t *testing.T) {
+	tests := []struct {
+		in   string
+		n    int
+		want string
+	}{
+		{"short", 10, "short"},
+		{"a  b\n\tc", 10, "a b c"},
+		{"abcdef", 3, "abc..."},
+		{"café au lait", 4, "caf..."}, // é is two bytes; 4 would split it
+		{"€uro", 2, "..."},
+	}
+	for _, tc := range tests {
+		got := excerpt(tc.in, tc.n)
+		if got != tc.want || !utf8.ValidString(got) {
+			t.Errorf("excerpt(%q, %d) = %q, want %q", tc.in, tc.n, got, tc.want)
+		}
+	}
+}
@@ -1,6 +1,9 @@
 package client
 
 import (
+	"context"
+	"net/http"
+	"sync/atomic"
 	"time"
 
 	"github.com/go-resty/resty/v2"
@@ -13,14 +16,28 @@ type RestClient struct {
 	baseURL      string
 	defaultToken string
 	useToken     bool
+	ctx          context.Context
 	logger       *logrus.Logger
+
+	// idempotencyKey is sent with every request; see WithIdempotencyKey.
+	idempotencyKey string
+
+	// retry, breakers, cache, flights, and timeout are shared with every
+	// copy of the client.
+	retry    *retrier
+	breakers *breakers
+	cache    *responseCache
+	flights  *flights
+	timeout  *atomic.Int64 // per attempt, in nanoseconds
 }
 
+// DefaultTimeout is the per-request timeout until SetTimeout is called.
+const DefaultTimeout = 30 * time.Second
+
 // NewRestClient creates a new RestClient configured with the base URL and auth token.
 func NewRestClient(baseURL, defaultToken string, logger *logrus.Logger) *RestClient {
 	client := resty.New().
 		SetBaseURL(baseURL).
-		SetTimeout(30*time.Second).
 		SetHeader("Content-Type", "application/json").
 		SetHeader("Accept", "application/json")
 
@@ -39,7 +56,13 @@ func NewRestClient(baseURL, defaultToken string, logger *logrus.Logger) *RestCli
 		baseURL:      baseURL,
 		defaultToken: defaultToken,
 		logger:       logger,
+		retry:        &retrier{policy: DefaultRetryPolicy},
+		breakers:     newBreakers(baseURL, logger),
+		cache:        newResponseCache(logger),
+		flights:      newFlights(),
+		timeout:      new(atomic.Int64),
 	}
+	rc.timeout.Store(int64(DefaultTimeout))
 
 	logger.WithFields(logrus.Fields{
 		"baseURL":  baseURL,
@@ -49,61 +72,119 @@ func NewRestClient(baseURL, defaultToken string, logger *logrus.Logger) *RestCli
 	return rc
 }
 
+// SetTransport replaces the transport used for API requests, for example to
+// record or replay upstream traffic.
+func (c *RestClient) SetTransport(transport http.RoundTripper) {
+	c.client.SetTransport(transport)
+}
+
+// SetTimeout sets the per-request timeout for API calls of the client and
+// its copies. It is safe to call while requests are in flight; each attempt
+// uses the timeout in effect when it starts.
+func (c *RestClient) SetTimeout(timeout time.Duration) {
+	c.timeout.Store(int64(timeout))
+}
+
 func (c *RestClient) PrepareRequest() *resty.Request {
 	request := c.client.R()
-	if c.useToken && c.defaultToken != "" {
-		request.SetAuthToken(c.defaultToken)
+	if c.ctx != nil {
+		request.SetContext(c.ctx)
+	}
+	if c.useToken {
+		if token := c.token(); token != "" {
+			request.SetAuthToken(token)
+		}
 	}
 	return request
 }
 
+// token returns the token for authenticated requests: the session's token
+// from the request context if there is one, otherwise the default token.
+func (c *RestClient) token() string {
+	if token, ok := TokenFromContext(c.ctx); ok {
+		return token
+	}
+	return c.defaultToken
+}
+
+// HasToken reports whether authenticated requests from this client would
+// carry a token, taking the bound context's session token into account.
+func (c *RestClient) HasToken() bool {
+	return c.token() != ""
+}
+
+// WithToken returns a copy of the client whose requests are authenticated
+// as the calling session; see ContextWithToken.
 func (c *RestClient) WithToken() *RestClient {
 	clone := *c
 	clone.useToken = true
 	return &clone
 }
 
+// WithContext returns a copy of the client whose requests are bound to ctx,
+// so they are aborted when the calling tool is cancelled.
+func (c *RestClient) WithContext(ctx context.Context) *RestClient {
+	clone := *c
+	clone.ctx = ctx
+	return &clone
+}
+
 // Get sends a GET request to the given path and returns the response body.
+// Like the other request methods, it returns an *APIError if the API does
+// not report success, and retries according to the retry policy. The
+// response may come from the cache (see SetCachePolicy) or be shared with
+// an identical GET already in flight.
 func (c *RestClient) Get(path string, queryParams map[string]string) ([]byte, error) {
-	req := c.PrepareRequest()
-	if len(queryParams) > 0 {
-		req.SetQueryParams(queryParams)
-	}
-	resp, err := req.Get(path)
-	if err != nil {
-		return nil, err
-	}
-	return resp.Body(), nil
+	return c.cachedGet(path, queryParams, func(req *resty.Request) {
+		if len(queryParams) > 0 {
+			req.SetQueryParams(queryParams)
+		}
+	})
 }
 
 // Post sends a POST request with a JSON body and returns the response body.
+// It is only retried if the client has an idempotency key.
 func (c *RestClient) Post(path string, body interface{}) ([]byte, error) {
-	resp, err := c.PrepareRequest().
-		SetBody(body).
-		Post(path)
-	if err != nil {
-		return nil, err
-	}
-	return resp.Body(), nil
+	return c.mutate(http.MethodPost, path, func(req *resty.Request) {
+		req.SetBody(body)
+	})
 }
 
 // Put sends a PUT request with a JSON body and returns the response body.
 func (c *RestClient) Put(path string, body interface{}) ([]byte, error) {
-	resp, err := c.PrepareRequest().
-		SetBody(body).
-		Put(path)
-	if err != nil {
-		return nil, err
-	}
-	return resp.Body(), nil
+	return c.mutate(http.MethodPut, path, func(req *resty.Request) {
+		req.SetBody(body)
+	})
 }
 
 // Delete sends a DELETE request and returns the response body.
 func (c *RestClient) Delete(path string) ([]byte, error) {
-	resp, err := c.PrepareRequest().
-		Delete(path)
+	return c.mutate(http.MethodDelete, path, func(*resty.Request) {})
+}
+
+// mutate sends a request that may change state on the API. If it is
+// authenticated, the responses cached with the same credentials are
+// dropped afterwards, even if it failed, since it may have been applied
+// anyway, and later GETs no longer join the ones in flight. Anonymous
+// requests, such as logging in, leave both alone.
+func (c *RestClient) mutate(method, path string, build func(*resty.Request)) ([]byte, error) {
+	if identity := c.identity(); identity != "" {
+		defer func() {
+			c.cache.invalidate(identity)
+			c.flights.detach(identity)
+		}()
+	}
+	return c.send(method, path, build)
+}
+
+// send sends a request and returns the body of a successful response.
+func (c *RestClient) send(method, path string, build func(*resty.Request)) ([]byte, error) {
+	resp, err := c.execute(method, path, build)
 	if err != nil {
 		return nil, err
 	}
+	if err := checkResponse(resp); err != nil {
+		return nil, err
+	}
 	return resp.Body(), nil
 }
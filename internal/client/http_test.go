@@ -0,0 +1,64 @@
+package client
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func newTestClient(t *testing.T, handler http.HandlerFunc) *RestClient {
+	t.Helper()
+	ts := httptest.NewServer(handler)
+	t.Cleanup(ts.Close)
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	c := NewRestClient(ts.URL, "", logger)
+	c.SetRetryPolicy(RetryPolicy{MaxAttempts: 1})
+	return c
+}
+
+func TestSetTimeoutAppliesToNextRequest(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(200 * time.Millisecond):
+		case <-r.Context().Done():
+			return
+		}
+		w.Write([]byte(`{"success":true}`))
+	})
+
+	if _, err := c.Get("/slow", nil); err != nil {
+		t.Fatalf("Get with the default timeout: %v", err)
+	}
+	c.SetTimeout(20 * time.Millisecond)
+	if _, err := c.WithContext(t.Context()).Get("/slow", nil); err == nil {
+		t.Error("Get outlasting the timeout set on the original client succeeded on a copy")
+	}
+}
+
+func TestSetTimeoutWhileRequestsInFlight(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, _ *http.Request) {
+		w.Write([]byte(`{"success":true}`))
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			c.SetTimeout(time.Duration(i+1) * time.Second)
+		}()
+		go func() {
+			defer wg.Done()
+			if _, err := c.Post("/orders", nil); err != nil {
+				t.Errorf("Post: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+}
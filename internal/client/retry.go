@@ -0,0 +1,218 @@
+package client
+
+import (
+	"context"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/sirupsen/logrus"
+)
+
+// ---- Retries ----
+
+// IdempotencyKeyHeader carries the key that makes a POST safe to repeat.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// maxRetryAfter bounds how long a Retry-After header can make a call wait;
+// asked to wait longer, the client gives up instead.
+const maxRetryAfter = time.Minute
+
+// RetryPolicy controls how failed requests are retried. Only requests that
+// are safe to repeat are retried: GET, HEAD, PUT, and DELETE, and POSTs
+// sent with an idempotency key. They are retried after network errors and
+// after 408, 429, 500, 502, 503, and 504 responses.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of tries; 1 disables retries.
+	MaxAttempts int
+
+	// The wait before retry n is InitialBackoff * 2^(n-1), capped at
+	// MaxBackoff, of which a random half is jitter. A Retry-After header
+	// on a 429 or 503 response is used instead.
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+// DefaultRetryPolicy is used until SetRetryPolicy is called.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:    3,
+	InitialBackoff: 200 * time.Millisecond,
+	MaxBackoff:     5 * time.Second,
+}
+
+// retrier holds the retry policy shared by a client and its copies.
+type retrier struct {
+	mu     sync.RWMutex
+	policy RetryPolicy
+}
+
+func (r *retrier) get() RetryPolicy {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.policy
+}
+
+// SetRetryPolicy replaces the retry policy of the client and its copies.
+func (c *RestClient) SetRetryPolicy(policy RetryPolicy) {
+	c.retry.mu.Lock()
+	defer c.retry.mu.Unlock()
+	c.retry.policy = policy
+}
+
+// WithIdempotencyKey returns a copy of the client whose requests carry key
+// in the Idempotency-Key header, which makes its POSTs safe to retry.
+func (c *RestClient) WithIdempotencyKey(key string) *RestClient {
+	clone := *c
+	clone.idempotencyKey = key
+	return &clone
+}
+
+type idempotencyKeyKey struct{}
+
+// ContextWithIdempotencyKey returns a copy of ctx carrying the idempotency
+// key of the operation it belongs to; see IdempotencyKeyFromContext.
+func ContextWithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyKeyKey{}, key)
+}
+
+// IdempotencyKeyFromContext returns the key set by ContextWithIdempotencyKey,
+// or "" if there is none. Backends pass it to WithIdempotencyKey for the
+// requests that carry out the operation.
+func IdempotencyKeyFromContext(ctx context.Context) string {
+	key, _ := ctx.Value(idempotencyKeyKey{}).(string)
+	return key
+}
+
+// canRetry reports whether a request is safe to send more than once.
+func (c *RestClient) canRetry(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete:
+		return true
+	case http.MethodPost:
+		return c.idempotencyKey != ""
+	}
+	return false
+}
+
+// execute sends a request built by build, retrying it according to the
+// retry policy, and returns the final response or error. Every attempt
+// goes through the circuit breaker for path, is traced in a span, and is
+// bounded by the timeout set with SetTimeout.
+func (c *RestClient) execute(method, path string, build func(*resty.Request)) (*resty.Response, error) {
+	policy := c.retry.get()
+	attempts := policy.MaxAttempts
+	if attempts < 1 || !c.canRetry(method) {
+		attempts = 1
+	}
+
+	ctx := c.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	for attempt := 1; ; attempt++ {
+		done, err := c.breakers.allow(path)
+		if err != nil {
+			return nil, err
+		}
+		req := c.PrepareRequest()
+		if c.idempotencyKey != "" {
+			req.SetHeader(IdempotencyKeyHeader, c.idempotencyKey)
+		}
+		build(req)
+		attemptCtx, cancel := context.WithTimeout(ctx, time.Duration(c.timeout.Load()))
+		span := c.startAttempt(attemptCtx, req, method, path, attempt)
+		resp, err := req.Execute(method, path)
+		cancel()
+		endAttempt(span, resp, err)
+		done(outcomeOf(resp, err, ctx.Err() != nil))
+
+		if attempt >= attempts || !retryable(ctx, resp, err) {
+			return resp, err
+		}
+		wait, ok := retryDelay(policy, attempt, resp)
+		if !ok {
+			return resp, err
+		}
+
+		fields := logrus.Fields{
+			"method":      method,
+			"path":        path,
+			"attempt":     attempt,
+			"maxAttempts": attempts,
+			"wait":        wait.String(),
+		}
+		if err != nil {
+			fields["error"] = err.Error()
+		} else {
+			fields["status"] = resp.StatusCode()
+		}
+		c.logger.WithFields(fields).Warn("API request failed, retrying")
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return resp, err
+		case <-timer.C:
+		}
+	}
+}
+
+// retryable reports whether a failed attempt is worth repeating. Network
+// errors and per-attempt timeouts are, unless the caller gave up.
+func retryable(ctx context.Context, resp *resty.Response, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	if err != nil {
+		return true
+	}
+	switch resp.StatusCode() {
+	case http.StatusRequestTimeout, http.StatusTooManyRequests,
+		http.StatusInternalServerError, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// retryDelay returns how long to wait before the next attempt, and false if
+// the server asked for a longer wait than maxRetryAfter.
+func retryDelay(policy RetryPolicy, attempt int, resp *resty.Response) (time.Duration, bool) {
+	if resp != nil && (resp.StatusCode() == http.StatusTooManyRequests || resp.StatusCode() == http.StatusServiceUnavailable) {
+		if wait, ok := parseRetryAfter(resp.Header().Get("Retry-After")); ok {
+			return wait, wait <= maxRetryAfter
+		}
+	}
+
+	backoff := policy.InitialBackoff
+	for i := 1; i < attempt && backoff < policy.MaxBackoff; i++ {
+		backoff *= 2
+	}
+	if policy.MaxBackoff > 0 && backoff > policy.MaxBackoff {
+		backoff = policy.MaxBackoff
+	}
+	if backoff <= 0 {
+		return 0, true
+	}
+	half := backoff / 2
+	return half + rand.N(half+1), true
+}
+
+// parseRetryAfter reads a Retry-After value in seconds or as an HTTP date.
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return max(time.Until(at), 0), true
+	}
+	return 0, false
+}
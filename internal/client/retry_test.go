@@ -0,0 +1,184 @@
+package client
+
+import (
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// newRetryingClient returns a client that retries up to three times with
+// a negligible backoff, and a count of the requests the API received.
+func newRetryingClient(t *testing.T, handler func(w http.ResponseWriter, r *http.Request, call int32)) (*RestClient, *atomic.Int32) {
+	t.Helper()
+	calls := new(atomic.Int32)
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		handler(w, r, calls.Add(1))
+	})
+	c.SetRetryPolicy(RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond})
+	return c, calls
+}
+
+// failTwice answers the first two calls with status and then succeeds.
+func failTwice(status int) func(http.ResponseWriter, *http.Request, int32) {
+	return func(w http.ResponseWriter, _ *http.Request, call int32) {
+		if call <= 2 {
+			w.WriteHeader(status)
+			return
+		}
+		w.Write([]byte(`{"success":true}`))
+	}
+}
+
+func TestRetriedRequests(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    int
+		send      func(c *RestClient) error
+		wantCalls int32
+		wantErr   bool
+	}{
+		{"GET after 503", http.StatusServiceUnavailable, func(c *RestClient) error {
+			_, err := c.Get("/products", nil)
+			return err
+		}, 3, false},
+		{"GET after 500", http.StatusInternalServerError, func(c *RestClient) error {
+			_, err := c.Get("/products", nil)
+			return err
+		}, 3, false},
+		{"GET after 404", http.StatusNotFound, func(c *RestClient) error {
+			_, err := c.Get("/products", nil)
+			return err
+		}, 1, true},
+		{"DELETE after 502", http.StatusBadGateway, func(c *RestClient) error {
+			_, err := c.Delete("/orders/1")
+			return err
+		}, 3, false},
+		{"POST without a key", http.StatusServiceUnavailable, func(c *RestClient) error {
+			_, err := c.Post("/orders", nil)
+			return err
+		}, 1, true},
+		{"POST with a key", http.StatusServiceUnavailable, func(c *RestClient) error {
+			_, err := c.WithIdempotencyKey("order-1").Post("/orders", nil)
+			return err
+		}, 3, false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			c, calls := newRetryingClient(t, failTwice(tc.status))
+			err := tc.send(c)
+			if (err != nil) != tc.wantErr {
+				t.Errorf("error = %v, want error: %v", err, tc.wantErr)
+			}
+			if n := calls.Load(); n != tc.wantCalls {
+				t.Errorf("API calls = %d, want %d", n, tc.wantCalls)
+			}
+		})
+	}
+}
+
+func TestRetriesCarryTheIdempotencyKey(t *testing.T) {
+	var keys []string
+	c, _ := newRetryingClient(t, func(w http.ResponseWriter, r *http.Request, call int32) {
+		keys = append(keys, r.Header.Get(IdempotencyKeyHeader))
+		failTwice(http.StatusServiceUnavailable)(w, r, call)
+	})
+
+	if _, err := c.WithIdempotencyKey("order-1").Post("/orders", nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 3 || keys[0] != "order-1" || keys[1] != "order-1" || keys[2] != "order-1" {
+		t.Errorf("Idempotency-Key per attempt = %q, want order-1 on every attempt", keys)
+	}
+}
+
+func TestRetryAfterOverridesBackoff(t *testing.T) {
+	c, calls := newRetryingClient(t, func(w http.ResponseWriter, _ *http.Request, call int32) {
+		if call == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte(`{"success":true}`))
+	})
+	// A backoff this long would time the test out; Retry-After: 0 wins.
+	c.SetRetryPolicy(RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Hour, MaxBackoff: time.Hour})
+
+	if _, err := c.Get("/products", nil); err != nil {
+		t.Fatal(err)
+	}
+	if n := calls.Load(); n != 2 {
+		t.Errorf("API calls = %d, want 2", n)
+	}
+}
+
+func TestRetryAfterTooLongGivesUp(t *testing.T) {
+	c, calls := newRetryingClient(t, func(w http.ResponseWriter, _ *http.Request, _ int32) {
+		w.Header().Set("Retry-After", "120")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+
+	if _, err := c.Get("/products", nil); err == nil {
+		t.Fatal("Get succeeded")
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("API calls = %d, want 1: waiting two minutes is not worth it", n)
+	}
+}
+
+func response(status int, retryAfter string) *resty.Response {
+	header := http.Header{}
+	if retryAfter != "" {
+		header.Set("Retry-After", retryAfter)
+	}
+	return &resty.Response{RawResponse: &http.Response{StatusCode: status, Header: header}}
+}
+
+func TestRetryDelay(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 10, InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+	date := time.Now().Add(time.Hour).UTC().Format(http.TimeFormat)
+
+	tests := []struct {
+		name     string
+		resp     *resty.Response
+		min, max time.Duration
+		wantOK   bool
+	}{
+		{"429 in seconds", response(http.StatusTooManyRequests, "3"), 3 * time.Second, 3 * time.Second, true},
+		{"503 in seconds", response(http.StatusServiceUnavailable, "0"), 0, 0, true},
+		{"too long", response(http.StatusTooManyRequests, "61"), 61 * time.Second, 61 * time.Second, false},
+		{"as a date too far ahead", response(http.StatusServiceUnavailable, date), 59 * time.Minute, time.Hour, false},
+		{"ignored on 500", response(http.StatusInternalServerError, "30"), 50 * time.Millisecond, 100 * time.Millisecond, true},
+		{"unparseable", response(http.StatusTooManyRequests, "soon"), 50 * time.Millisecond, 100 * time.Millisecond, true},
+		{"network error", nil, 50 * time.Millisecond, 100 * time.Millisecond, true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			wait, ok := retryDelay(policy, 1, tc.resp)
+			if ok != tc.wantOK || wait < tc.min || wait > tc.max {
+				t.Errorf("retryDelay = %v, %v; want %v..%v, %v", wait, ok, tc.min, tc.max, tc.wantOK)
+			}
+		})
+	}
+}
+
+func TestBackoffIsCapped(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 100, InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+
+	for attempt, want := range map[int]time.Duration{
+		1:  100 * time.Millisecond,
+		2:  200 * time.Millisecond,
+		4:  800 * time.Millisecond,
+		5:  time.Second,
+		50: time.Second,
+	} {
+		for i := 0; i < 20; i++ {
+			wait, ok := retryDelay(policy, attempt, nil)
+			if !ok || wait < want/2 || wait > want {
+				t.Fatalf("attempt %d: wait = %v, want %v..%v", attempt, wait, want/2, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,22 @@
+package client
+
+import "context"
+
+type tokenKey struct{}
+
+// ContextWithToken returns a copy of ctx carrying the caller's API token.
+// Authenticated requests made with that context use it instead of the
+// client's default token; an empty token means the caller is anonymous.
+func ContextWithToken(ctx context.Context, token string) context.Context {
+	return context.WithValue(ctx, tokenKey{}, token)
+}
+
+// TokenFromContext returns the token set by ContextWithToken, and whether
+// one was set.
+func TokenFromContext(ctx context.Context) (string, bool) {
+	if ctx == nil {
+		return "", false
+	}
+	token, ok := ctx.Value(tokenKey{}).(string)
+	return token, ok
+}
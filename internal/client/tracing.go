@@ -0,0 +1,63 @@
+package client
+
+import (
+	"context"
+	"strings"
+
+	"github.com/go-resty/resty/v2"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ---- Tracing ----
+
+var tracer = otel.Tracer("github.com/trenchesdeveloper/mcp-server-store/internal/client")
+
+// startAttempt starts the client span of one attempt at a request, and
+// binds req to it so its trace context reaches the API in the traceparent
+// header.
+func (c *RestClient) startAttempt(ctx context.Context, req *resty.Request, method, path string, attempt int) trace.Span {
+	attrs := []attribute.KeyValue{
+		attribute.String("http.request.method", method),
+		attribute.String("url.full", c.baseURL+path),
+		attribute.String("url.path", path),
+	}
+	if attempt > 1 {
+		attrs = append(attrs, attribute.Int("http.request.resend_count", attempt-1))
+	}
+	ctx, span := tracer.Start(ctx, method+" "+routeTemplate(path),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...))
+	req.SetContext(ctx)
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+	return span
+}
+
+// endAttempt records how an attempt went on its span and ends it.
+func endAttempt(span trace.Span, resp *resty.Response, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	} else {
+		span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode()))
+		if resp.StatusCode() >= 400 {
+			span.SetStatus(codes.Error, resp.Status())
+		}
+	}
+	span.End()
+}
+
+// routeTemplate replaces the numeric segments of path, such as IDs, with
+// "{id}", so span names stay few: "/orders/{id}/cancel".
+func routeTemplate(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if segment != "" && strings.Trim(segment, "0123456789") == "" {
+			segments[i] = "{id}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
@@ -0,0 +1,380 @@
+// Package gateway aggregates upstream MCP servers into a local mcp.Server.
+// Each upstream's tools, resources, and prompts are mounted under a prefix,
+// calls are proxied to the upstream, list changes are relayed to the local
+// server's clients, and progress reaches the client that made the call.
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+	"github.com/trenchesdeveloper/mcp-server-store/internal/mcp"
+	"github.com/trenchesdeveloper/mcp-server-store/internal/mcpclient"
+)
+
+// UpstreamConfig describes how to reach one upstream MCP server.
+// Exactly one of Command (stdio subprocess) or URL (HTTP) must be set.
+type UpstreamConfig struct {
+	Name    string            `json:"name"`
+	Prefix  string            `json:"prefix,omitempty"`  // namespace for mounted tools and prompts (default: Name)
+	Command string            `json:"command,omitempty"` // executable to launch for stdio
+	Args    []string          `json:"args,omitempty"`
+	Env     map[string]string `json:"env,omitempty"`     // added to the gateway's own environment
+	URL     string            `json:"url,omitempty"`     // MCP endpoint for HTTP
+	Headers map[string]string `json:"headers,omitempty"` // extra headers sent with every HTTP request
+}
+
+// Config is the on-disk gateway configuration.
+type Config struct {
+	Upstreams []UpstreamConfig `json:"upstreams"`
+}
+
+// LoadConfig reads a JSON gateway configuration file.
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read gateway config: %w", err)
+	}
+	var cfg Config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("parse gateway config %s: %w", path, err)
+	}
+	for i := range cfg.Upstreams {
+		if err := cfg.Upstreams[i].validate(); err != nil {
+			return nil, fmt.Errorf("gateway config %s: upstream %d: %w", path, i, err)
+		}
+	}
+	return &cfg, nil
+}
+
+func (c *UpstreamConfig) validate() error {
+	if c.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if (c.Command == "") == (c.URL == "") {
+		return fmt.Errorf("upstream %q: exactly one of command or url is required", c.Name)
+	}
+	return nil
+}
+
+func (c *UpstreamConfig) prefix() string {
+	if c.Prefix != "" {
+		return c.Prefix
+	}
+	return c.Name
+}
+
+// Gateway connects to upstream MCP servers and mounts them into a local server.
+type Gateway struct {
+	server *mcp.Server
+	logger *logrus.Logger
+
+	mu        sync.Mutex
+	upstreams map[string]*upstream
+}
+
+// upstream is a connected upstream server.
+type upstream struct {
+	cfg    UpstreamConfig
+	client *mcpclient.Client
+	info   *mcp.InitializeResult
+	server *mcp.Server
+	logger *logrus.Entry
+
+	// refreshMu serializes refreshes triggered by list_changed notifications.
+	refreshMu sync.Mutex
+
+	// progress maps the progress tokens sent upstream to the calls that
+	// asked for progress. Tokens are numbered per upstream so two clients
+	// using the same token do not see each other's progress.
+	progressMu   sync.Mutex
+	progress     map[string]progressCall
+	nextProgress int64
+}
+
+// progressCall is a proxied tool call whose client asked for progress.
+type progressCall struct {
+	ctx   context.Context // the local request, to notify its client
+	token interface{}     // the client's own progress token
+}
+
+// New creates a gateway that mounts upstreams into server. The server
+// should be created with mcp.WithListChanged so clients learn about
+// upstream changes.
+func New(server *mcp.Server, logger *logrus.Logger) *Gateway {
+	return &Gateway{
+		server:    server,
+		logger:    logger,
+		upstreams: make(map[string]*upstream),
+	}
+}
+
+// Connect starts or dials the upstream, performs the MCP handshake, and
+// mounts its tools, resources, and prompts under the upstream's prefix.
+func (g *Gateway) Connect(ctx context.Context, cfg UpstreamConfig) error {
+	if err := cfg.validate(); err != nil {
+		return err
+	}
+
+	g.mu.Lock()
+	_, exists := g.upstreams[cfg.prefix()]
+	g.mu.Unlock()
+	if exists {
+		return fmt.Errorf("upstream prefix %q is already connected", cfg.prefix())
+	}
+
+	logger := g.logger.WithField("upstream", cfg.Name)
+	client, err := dial(cfg, g.logger, logger)
+	if err != nil {
+		return fmt.Errorf("upstream %q: %w", cfg.Name, err)
+	}
+	u := &upstream{
+		cfg:      cfg,
+		client:   client,
+		server:   g.server,
+		logger:   logger,
+		progress: make(map[string]progressCall),
+	}
+	client.OnAnyNotification(u.handleNotification)
+
+	if u.info, err = client.Initialize(ctx); err != nil {
+		client.Close()
+		return fmt.Errorf("upstream %q: %w", cfg.Name, err)
+	}
+	if err := u.refresh(ctx); err != nil {
+		client.Close()
+		return fmt.Errorf("upstream %q: %w", cfg.Name, err)
+	}
+
+	g.mu.Lock()
+	g.upstreams[cfg.prefix()] = u
+	g.mu.Unlock()
+
+	u.logger.WithFields(logrus.Fields{
+		"server":  u.info.ServerInfo.Name,
+		"version": u.info.ServerInfo.Version,
+		"prefix":  cfg.prefix(),
+	}).Info("Connected upstream MCP server")
+
+	return nil
+}
+
+// Close disconnects every upstream and removes its entries from the server.
+func (g *Gateway) Close() error {
+	g.mu.Lock()
+	upstreams := g.upstreams
+	g.upstreams = make(map[string]*upstream)
+	g.mu.Unlock()
+
+	var firstErr error
+	for prefix, u := range upstreams {
+		g.server.Unmount(prefix)
+		if err := u.client.Close(); err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("upstream %q: %w", u.cfg.Name, err)
+		}
+	}
+	return firstErr
+}
+
+// dial creates a client for the upstream: a subprocess for stdio, or an
+// HTTP client for a URL.
+func dial(cfg UpstreamConfig, logger *logrus.Logger, entry *logrus.Entry) (*mcpclient.Client, error) {
+	opts := []mcpclient.Option{
+		mcpclient.WithLogger(logger),
+		mcpclient.WithClientInfo("mcp-server-store-gateway", "0.1.0"),
+	}
+
+	if cfg.URL != "" {
+		for k, v := range cfg.Headers {
+			opts = append(opts, mcpclient.WithHeader(k, v))
+		}
+		return mcpclient.NewHTTPClient(cfg.URL, opts...), nil
+	}
+
+	cmd := exec.Command(cfg.Command, cfg.Args...)
+	cmd.Env = os.Environ()
+	for k, v := range cfg.Env {
+		cmd.Env = append(cmd.Env, k+"="+v)
+	}
+	cmd.Stderr = entry.WriterLevel(logrus.DebugLevel)
+	return mcpclient.NewStdioClient(cmd, opts...)
+}
+
+// refresh lists everything the upstream offers and remounts it.
+func (u *upstream) refresh(ctx context.Context) error {
+	u.refreshMu.Lock()
+	defer u.refreshMu.Unlock()
+
+	child := mcp.NewRegistry(mcp.ClientInfo{}, "", u.logger.Logger)
+	caps := u.info.Capabilities
+
+	if caps.Tools != nil {
+		tools, err := u.client.ListTools(ctx)
+		if err != nil {
+			return err
+		}
+		for _, tool := range tools {
+			if err := child.RegisterTool(tool, u.toolHandler(tool.Name)); err != nil {
+				return err
+			}
+		}
+	}
+
+	if caps.Resources != nil {
+		resources, err := u.client.ListResources(ctx)
+		if err != nil {
+			return err
+		}
+		for _, resource := range resources {
+			if err := child.RegisterResource(resource, u.resourceHandler()); err != nil {
+				return err
+			}
+		}
+	}
+
+	if caps.Prompts != nil {
+		prompts, err := u.client.ListPrompts(ctx)
+		if err != nil {
+			return err
+		}
+		for _, prompt := range prompts {
+			if err := child.RegisterPrompt(prompt, u.promptHandler(prompt.Name)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return u.server.Remount(u.cfg.prefix(), child)
+}
+
+// ---- Proxy handlers ----
+
+func (u *upstream) toolHandler(name string) mcp.ToolHandler {
+	return func(ctx context.Context, arguments map[string]interface{}) (*mcp.ToolCallResult, error) {
+		token, ok := mcp.ProgressTokenFromContext(ctx)
+		if !ok {
+			result, err := u.client.CallTool(ctx, name, arguments)
+			if err != nil {
+				return nil, fmt.Errorf("upstream %s: %w", u.cfg.Name, err)
+			}
+			return result, nil
+		}
+
+		upstreamToken := u.trackProgress(ctx, token)
+		defer u.untrackProgress(upstreamToken)
+		result, err := u.client.CallToolWithProgress(ctx, name, arguments, upstreamToken)
+		if err != nil {
+			return nil, fmt.Errorf("upstream %s: %w", u.cfg.Name, err)
+		}
+		return result, nil
+	}
+}
+
+func (u *upstream) resourceHandler() mcp.ResourceHandler {
+	return func(ctx context.Context, uri string) (*mcp.ReadResourceResult, error) {
+		result, err := u.client.ReadResource(ctx, uri)
+		if err != nil {
+			return nil, fmt.Errorf("upstream %s: %w", u.cfg.Name, err)
+		}
+		return result, nil
+	}
+}
+
+func (u *upstream) promptHandler(name string) mcp.PromptHandler {
+	return func(ctx context.Context, arguments map[string]string) (*mcp.GetPromptResult, error) {
+		result, err := u.client.GetPrompt(ctx, name, arguments)
+		if err != nil {
+			return nil, fmt.Errorf("upstream %s: %w", u.cfg.Name, err)
+		}
+		return result, nil
+	}
+}
+
+// ---- Notifications ----
+
+// handleNotification relays upstream notifications to local clients.
+// It runs on the client's read loop, so anything that calls back into the
+// upstream is done in a separate goroutine. Upstream log messages and
+// resource updates are not tied to a local session, so they are only
+// logged.
+func (u *upstream) handleNotification(method string, params json.RawMessage) {
+	u.logger.WithField("method", method).Debug("Received upstream notification")
+
+	switch method {
+	case mcp.NotificationToolsListChanged,
+		mcp.NotificationResourcesListChanged,
+		mcp.NotificationPromptsListChanged:
+		go func() {
+			if err := u.refresh(context.Background()); err != nil {
+				u.logger.WithError(err).Error("Failed to refresh upstream after list change")
+				return
+			}
+			if err := u.server.Notify(method, nil); err != nil {
+				u.logger.WithError(err).Warn("Failed to relay list change")
+			}
+		}()
+
+	case mcp.NotificationProgress:
+		u.relayProgress(params)
+
+	case mcp.NotificationMessage:
+		var msg mcp.LoggingMessageNotification
+		if err := json.Unmarshal(params, &msg); err != nil {
+			u.logger.WithError(err).Warn("Invalid upstream log message")
+			return
+		}
+		u.logger.WithFields(logrus.Fields{
+			"level":  msg.Level,
+			"logger": msg.Logger,
+			"data":   msg.Data,
+		}).Info("Upstream log message")
+	}
+}
+
+// trackProgress records a call whose client asked for progress and
+// returns the token to send upstream in its place.
+func (u *upstream) trackProgress(ctx context.Context, token interface{}) string {
+	u.progressMu.Lock()
+	defer u.progressMu.Unlock()
+	u.nextProgress++
+	upstreamToken := strconv.FormatInt(u.nextProgress, 10)
+	u.progress[upstreamToken] = progressCall{ctx: ctx, token: token}
+	return upstreamToken
+}
+
+func (u *upstream) untrackProgress(upstreamToken string) {
+	u.progressMu.Lock()
+	delete(u.progress, upstreamToken)
+	u.progressMu.Unlock()
+}
+
+// relayProgress sends upstream progress to the client of the call it
+// belongs to, with that client's own token. Progress for a call that has
+// finished, or that never asked for it, is dropped.
+func (u *upstream) relayProgress(params json.RawMessage) {
+	var p map[string]interface{}
+	if err := json.Unmarshal(params, &p); err != nil {
+		u.logger.WithError(err).Warn("Invalid upstream progress notification")
+		return
+	}
+	upstreamToken, _ := p["progressToken"].(string)
+
+	u.progressMu.Lock()
+	call, ok := u.progress[upstreamToken]
+	u.progressMu.Unlock()
+	if !ok {
+		u.logger.WithField("progressToken", p["progressToken"]).Debug("Dropping progress for an unknown call")
+		return
+	}
+
+	p["progressToken"] = call.token
+	if err := u.server.NotifyCaller(call.ctx, mcp.NotificationProgress, p); err != nil {
+		u.logger.WithError(err).Debug("Failed to relay upstream progress")
+	}
+}
@@ -0,0 +1,351 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/trenchesdeveloper/mcp-server-store/internal/mcp"
+	"github.com/trenchesdeveloper/mcp-server-store/internal/mcpclient"
+)
+
+// stubEnv makes the test binary serve the stub upstream on stdio instead
+// of running the tests, so the gateway can launch it as a subprocess.
+const stubEnv = "GATEWAY_TEST_STUB"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(stubEnv) == "1" {
+		if err := serveStub(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+func quietLogger() *logrus.Logger {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	return logger
+}
+
+// serveStub serves the stub upstream on stdio.
+func serveStub() error {
+	server, err := newStubServer(nil)
+	if err != nil {
+		return err
+	}
+	return server.ServeStdio()
+}
+
+// newStubServer builds an upstream with an echo tool, a resource, a
+// progress tool that reports progress to its caller, and an add_tool tool
+// that registers another tool and announces it through listChanged, or
+// through the server's own notification if that is nil.
+func newStubServer(listChanged func() error) (*mcp.Server, error) {
+	server := mcp.NewServer("stub", "1.0.0", quietLogger(), mcp.WithListChanged())
+	if listChanged == nil {
+		listChanged = server.NotifyToolsListChanged
+	}
+
+	err := server.RegisterTool(mcp.Tool{
+		Name:        "echo",
+		Description: "Echoes its text argument.",
+		InputSchema: mcp.InputSchema{Type: "object"},
+	}, func(_ context.Context, arguments map[string]interface{}) (*mcp.ToolCallResult, error) {
+		text, _ := arguments["text"].(string)
+		return &mcp.ToolCallResult{Content: []mcp.Content{mcp.NewTextContent("echo: " + text)}}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = server.RegisterTool(mcp.Tool{
+		Name:        "progress",
+		InputSchema: mcp.InputSchema{Type: "object"},
+	}, func(ctx context.Context, _ map[string]interface{}) (*mcp.ToolCallResult, error) {
+		if token, ok := mcp.ProgressTokenFromContext(ctx); ok {
+			err := server.NotifyCaller(ctx, mcp.NotificationProgress, &mcp.ProgressNotification{
+				ProgressToken: token,
+				Progress:      1,
+				Total:         2,
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+		return &mcp.ToolCallResult{Content: []mcp.Content{mcp.NewTextContent("done")}}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = server.RegisterTool(mcp.Tool{
+		Name:        "add_tool",
+		InputSchema: mcp.InputSchema{Type: "object"},
+	}, func(_ context.Context, _ map[string]interface{}) (*mcp.ToolCallResult, error) {
+		err := server.RegisterTool(mcp.Tool{Name: "added", InputSchema: mcp.InputSchema{Type: "object"}},
+			func(context.Context, map[string]interface{}) (*mcp.ToolCallResult, error) {
+				return &mcp.ToolCallResult{Content: []mcp.Content{mcp.NewTextContent("added")}}, nil
+			})
+		if err != nil {
+			return nil, err
+		}
+		if err := listChanged(); err != nil {
+			return nil, err
+		}
+		return &mcp.ToolCallResult{Content: []mcp.Content{mcp.NewTextContent("ok")}}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = server.RegisterResource(mcp.Resource{URI: "stub://readme", Name: "readme", MimeType: "text/plain"},
+		func(_ context.Context, uri string) (*mcp.ReadResourceResult, error) {
+			return &mcp.ReadResourceResult{Contents: []mcp.ResourceContents{
+				mcp.NewTextResourceContents(uri, "text/plain", "stub readme"),
+			}}, nil
+		})
+	if err != nil {
+		return nil, err
+	}
+	return server, nil
+}
+
+// serveHTTPStub serves the stub upstream over HTTP. POST answers with a
+// JSON body; GET holds the event stream that carries list_changed, as a
+// streamable HTTP server sends notifications outside of a request.
+func serveHTTPStub(t *testing.T) string {
+	t.Helper()
+	const sessionID = "stub-session"
+	events := make(chan string, 1)
+	server, err := newStubServer(func() error {
+		events <- `{"jsonrpc":"2.0","method":"` + mcp.NotificationToolsListChanged + `"}`
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost:
+			body, _ := io.ReadAll(r.Body)
+			w.Header().Set("Mcp-Session-Id", sessionID)
+			resp := server.HandleMessage(r.Context(), body)
+			if resp == nil {
+				w.WriteHeader(http.StatusAccepted)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.Write(resp)
+
+		case http.MethodGet:
+			if r.Header.Get("Mcp-Session-Id") != sessionID {
+				http.Error(w, "missing session", http.StatusBadRequest)
+				return
+			}
+			w.Header().Set("Content-Type", "text/event-stream")
+			w.WriteHeader(http.StatusOK)
+			w.(http.Flusher).Flush()
+			for {
+				select {
+				case event := <-events:
+					fmt.Fprintf(w, "data: %s\n\n", event)
+					w.(http.Flusher).Flush()
+				case <-r.Context().Done():
+					return
+				}
+			}
+
+		case http.MethodDelete:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	t.Cleanup(ts.Close)
+	return ts.URL
+}
+
+func connectStub(t *testing.T) (*mcp.Server, *Gateway) {
+	t.Helper()
+	executable, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return connect(t, UpstreamConfig{
+		Name:    "stub",
+		Command: executable,
+		Env:     map[string]string{stubEnv: "1"},
+	})
+}
+
+func connectHTTPStub(t *testing.T) (*mcp.Server, *Gateway) {
+	t.Helper()
+	return connect(t, UpstreamConfig{Name: "stub", URL: serveHTTPStub(t)})
+}
+
+func connect(t *testing.T, cfg UpstreamConfig) (*mcp.Server, *Gateway) {
+	t.Helper()
+	server := mcp.NewServer("gateway", "test", quietLogger(), mcp.WithListChanged())
+	gw := New(server, quietLogger())
+	t.Cleanup(func() { gw.Close() })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := gw.Connect(ctx, cfg); err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	return server, gw
+}
+
+func toolNames(server *mcp.Server) []string {
+	var names []string
+	for _, tool := range server.ListTools() {
+		names = append(names, tool.Name)
+	}
+	return names
+}
+
+func TestGatewayMountsUpstream(t *testing.T) {
+	server, _ := connectStub(t)
+
+	names := toolNames(server)
+	for _, want := range []string{"stub.echo", "stub.add_tool"} {
+		if !slices.Contains(names, want) {
+			t.Errorf("tools = %v, missing %s", names, want)
+		}
+	}
+
+	result, err := server.CallTool(context.Background(), "stub.echo", map[string]interface{}{"text": "hi"})
+	if err != nil {
+		t.Fatalf("CallTool: %v", err)
+	}
+	if len(result.Content) != 1 || result.Content[0].Text != "echo: hi" {
+		t.Errorf("CallTool result = %+v, want echo: hi", result.Content)
+	}
+
+	read, err := server.ReadResource(context.Background(), "stub://readme")
+	if err != nil {
+		t.Fatalf("ReadResource: %v", err)
+	}
+	if len(read.Contents) != 1 || read.Contents[0].Text != "stub readme" {
+		t.Errorf("ReadResource = %+v, want the stub readme", read.Contents)
+	}
+}
+
+func TestGatewayRefreshesOnListChanged(t *testing.T) {
+	for name, connectUpstream := range map[string]func(*testing.T) (*mcp.Server, *Gateway){
+		"stdio": connectStub,
+		"http":  connectHTTPStub,
+	} {
+		t.Run(name, func(t *testing.T) {
+			server, _ := connectUpstream(t)
+			waitForAddedTool(t, server)
+		})
+	}
+}
+
+// waitForAddedTool calls stub.add_tool and waits for the gateway to list
+// the tool it announces.
+func waitForAddedTool(t *testing.T, server *mcp.Server) {
+	t.Helper()
+	if _, err := server.CallTool(context.Background(), "stub.add_tool", nil); err != nil {
+		t.Fatalf("CallTool: %v", err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !slices.Contains(toolNames(server), "stub.added") {
+		if time.Now().After(deadline) {
+			t.Fatalf("tools = %v, want stub.added after list_changed", toolNames(server))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestGatewayRoutesProgressToTheCaller(t *testing.T) {
+	server, _ := connectStub(t)
+
+	// progressOf connects a local client and collects the progress tokens
+	// it receives.
+	progressOf := func() (*mcpclient.Client, func() []interface{}) {
+		c := mcpclient.NewInProcessClient(server)
+		t.Cleanup(func() { c.Close() })
+		if _, err := c.Initialize(context.Background()); err != nil {
+			t.Fatalf("Initialize: %v", err)
+		}
+		var mu sync.Mutex
+		var tokens []interface{}
+		c.OnNotification(mcp.NotificationProgress, func(_ string, params json.RawMessage) {
+			var p mcp.ProgressNotification
+			json.Unmarshal(params, &p)
+			mu.Lock()
+			tokens = append(tokens, p.ProgressToken)
+			mu.Unlock()
+		})
+		return c, func() []interface{} {
+			mu.Lock()
+			defer mu.Unlock()
+			return append([]interface{}(nil), tokens...)
+		}
+	}
+	alice, aliceTokens := progressOf()
+	bob, bobTokens := progressOf()
+
+	if _, err := alice.CallToolWithProgress(context.Background(), "stub.progress", nil, "alice-1"); err != nil {
+		t.Fatalf("CallTool: %v", err)
+	}
+	if _, err := bob.CallTool(context.Background(), "stub.progress", nil); err != nil {
+		t.Fatalf("CallTool: %v", err)
+	}
+
+	// Progress is written before the response, so it has arrived by now.
+	if got := aliceTokens(); len(got) != 1 || got[0] != "alice-1" {
+		t.Errorf("progress for the caller = %v, want [alice-1]", got)
+	}
+	if got := bobTokens(); len(got) != 0 {
+		t.Errorf("progress for another client = %v, want none", got)
+	}
+}
+
+func TestGatewayCloseUnmounts(t *testing.T) {
+	server, gw := connectStub(t)
+
+	if err := gw.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if names := toolNames(server); len(names) != 0 {
+		t.Errorf("tools after Close = %v, want none", names)
+	}
+	if _, err := server.CallTool(context.Background(), "stub.echo", nil); err == nil {
+		t.Error("CallTool after Close succeeded")
+	}
+}
+
+func TestGatewayRejectsDuplicatePrefix(t *testing.T) {
+	_, gw := connectStub(t)
+
+	err := gw.Connect(context.Background(), UpstreamConfig{Name: "stub", Command: "unused"})
+	if err == nil {
+		t.Fatal("second upstream with the same prefix was connected")
+	}
+}
+
+func TestLoadConfigValidates(t *testing.T) {
+	path := t.TempDir() + "/gateway.json"
+	if err := os.WriteFile(path, []byte(`{"upstreams":[{"name":"both","command":"x","url":"http://y"}]}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadConfig(path); err == nil {
+		t.Error("LoadConfig accepted an upstream with both command and url")
+	}
+}
@@ -7,6 +7,9 @@ const (
 	ErrorMethodNotFound = -32601 // Method Not Found
 	ErrorInvalidParams  = -32602 // Invalid Params
 	ErrorInternal  = -32603 // Internal Error
+
+	// Implementation-defined server errors (-32000 to -32099)
+	ErrorShuttingDown = -32000 // Server is shutting down
 )
 
 // NewError creates a new JSON-RPC error
@@ -41,4 +44,9 @@ func NewInvalidParamsError(message string, data interface{}) *Error {
 // NewInternalError creates a new JSON-RPC internal error
 func NewInternalError(message string, data interface{}) *Error {
 	return NewError(ErrorInternal, message, data)
-}
\ No newline at end of file
+}
+
+// NewShuttingDownError creates a new error for requests received during shutdown
+func NewShuttingDownError() *Error {
+	return NewError(ErrorShuttingDown, "Server is shutting down", nil)
+}
@@ -2,39 +2,160 @@ package jsonrpc
 
 import (
 	"bufio"
+	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
-	"os"
+	"sync"
 
 	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Handler is a function that handles a JSON-RPC request and returns a result or error.
-type Handler func(params json.RawMessage) (interface{}, *Error)
+// The context is cancelled when the server is forced to stop before the handler returns.
+type Handler func(ctx context.Context, params json.RawMessage) (interface{}, *Error)
 
-// Server is a JSON-RPC 2.0 server that reads requests from an io.Reader
-// and writes responses to an io.Writer (typically stdin/stdout for stdio transport).
+// ErrServerClosed is returned by the serve methods after Shutdown has been called.
+var ErrServerClosed = errors.New("jsonrpc: server closed")
+
+// ErrNoCaller is returned by NotifyCaller when the request did not arrive
+// on a connection that can carry notifications, or that connection has
+// closed.
+var ErrNoCaller = errors.New("jsonrpc: no connection to notify")
+
+// Direction tells a Recorder which way a message travelled.
+type Direction string
+
+const (
+	Inbound  Direction = "in"  // read from the client
+	Outbound Direction = "out" // written to the client
+)
+
+// Recorder receives a copy of every message read from or written to a
+// client connection, for example to keep a transcript of a session. ctx is
+// the context the message was handled in, which identifies the session.
+type Recorder interface {
+	RecordMessage(ctx context.Context, direction Direction, msg []byte)
+}
+
+// Server is a JSON-RPC 2.0 server. It serves any number of transports at
+// once, reading requests from each and writing responses back to the same
+// transport (typically stdin/stdout for the stdio transport).
 type Server struct {
 	handlers map[string]Handler
 	logger   *logrus.Logger
+	recorder Recorder
+
+	// baseCtx is the parent of every request context. It is cancelled when
+	// Shutdown gives up waiting for in-flight requests.
+	baseCtx context.Context
+	cancel  context.CancelFunc
+
+	mu       sync.Mutex
+	closing  bool
+	quit     chan struct{}
+	inFlight sync.WaitGroup
+
+	// conns are the open client connections that notifications are sent to.
+	connMu sync.Mutex
+	conns  map[*connection]struct{}
 }
 
-// NewServer creates a new JSON-RPC server with the given reader and writer.
+// connection serializes writes to a single client so responses and
+// server-initiated notifications never interleave.
+type connection struct {
+	mu     sync.Mutex
+	writer *bufio.Writer
+}
+
+// NewServer creates a new JSON-RPC server.
 func NewServer(logger *logrus.Logger) *Server {
+	ctx, cancel := context.WithCancel(context.Background())
 	return &Server{
 		handlers: make(map[string]Handler),
 		logger:   logger,
+		baseCtx:  ctx,
+		cancel:   cancel,
+		quit:     make(chan struct{}),
+		conns:    make(map[*connection]struct{}),
 	}
 }
 
+// SetRecorder installs a recorder for all connection traffic. It must be
+// called before serving.
+func (s *Server) SetRecorder(r Recorder) {
+	s.recorder = r
+}
+
+var tracer = otel.Tracer("github.com/trenchesdeveloper/mcp-server-store/internal/jsonrpc")
+
+// metaCarrier returns the string fields of params._meta, where MCP clients
+// put W3C trace context ("traceparent", "tracestate").
+func metaCarrier(params json.RawMessage) propagation.MapCarrier {
+	carrier := propagation.MapCarrier{}
+	var p struct {
+		Meta map[string]interface{} `json:"_meta"`
+	}
+	if len(params) == 0 || params[0] != '{' || json.Unmarshal(params, &p) != nil {
+		return carrier
+	}
+	for key, value := range p.Meta {
+		if s, ok := value.(string); ok {
+			carrier[key] = s
+		}
+	}
+	return carrier
+}
+
+type requestIDKey struct{}
+
+type connectionKey struct{}
+
+// RequestIDFromContext returns the ID of the request a handler is serving,
+// and false for notifications.
+func RequestIDFromContext(ctx context.Context) (interface{}, bool) {
+	id := ctx.Value(requestIDKey{})
+	return id, id != nil
+}
+
 func (s *Server) RegisterMethod(method string, handler Handler) {
 	s.handlers[method] = handler
 	s.logger.WithField("method", method).Info("Registered method")
 }
 
-func (s *Server) HandleRequest(req *Request) *Response {
+// HandleRequest runs the handler for req in a span continuing the trace
+// context in req's params._meta, if any.
+func (s *Server) HandleRequest(ctx context.Context, req *Request) *Response {
+	ctx = otel.GetTextMapPropagator().Extract(ctx, metaCarrier(req.Params))
+	attrs := []attribute.KeyValue{
+		attribute.String("rpc.system", "jsonrpc"),
+		attribute.String("rpc.method", req.Method),
+	}
+	if !req.IsNotification() {
+		ctx = context.WithValue(ctx, requestIDKey{}, req.ID)
+		attrs = append(attrs, attribute.String("rpc.jsonrpc.request_id", fmt.Sprint(req.ID)))
+	}
+	ctx, span := tracer.Start(ctx, req.Method,
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(attrs...))
+	defer span.End()
+
+	resp := s.handleRequest(ctx, req)
+	if resp.Error != nil {
+		span.SetAttributes(attribute.Int("rpc.jsonrpc.error_code", resp.Error.Code))
+		span.SetStatus(codes.Error, resp.Error.Message)
+	}
+	return resp
+}
+
+func (s *Server) handleRequest(ctx context.Context, req *Request) *Response {
 	s.logger.WithFields(logrus.Fields{
 		"method": req.Method,
 		"id":     req.ID,
@@ -55,7 +176,7 @@ func (s *Server) HandleRequest(req *Request) *Response {
 		))
 	}
 
-	result, err := handler(req.Params)
+	result, err := handler(ctx, req.Params)
 	if err != nil {
 		var jsonErr *Error
 		if errors.As(err, &jsonErr) {
@@ -66,48 +187,286 @@ func (s *Server) HandleRequest(req *Request) *Response {
 	return NewSuccessResponse(req.ID, result)
 }
 
+// HandleMessage handles a single encoded request or notification and
+// returns the encoded response, or nil for notifications. It bypasses the
+// connection machinery, so nothing is recorded.
+func (s *Server) HandleMessage(ctx context.Context, msg []byte) []byte {
+	var resp *Response
+	var req Request
+	if err := json.Unmarshal(msg, &req); err != nil {
+		resp = NewErrorResponse(nil, NewParseError("Failed to unmarshal request", err))
+	} else {
+		resp = s.HandleRequest(ctx, &req)
+		if req.IsNotification() {
+			return nil
+		}
+	}
+	data, err := json.Marshal(resp)
+	if err != nil {
+		s.logger.WithError(err).Error("Failed to marshal message")
+		return nil
+	}
+	return data
+}
+
+// ServeMessage handles a single encoded request or notification received
+// outside a stream, for example in an HTTP request body, and returns the
+// encoded response or nil for notifications. Unlike HandleMessage it is
+// recorded, counted as in flight for Shutdown, and rejected once the server
+// is shutting down. The handler's context is cancelled when either ctx is
+// done or Shutdown gives up waiting.
+func (s *Server) ServeMessage(ctx context.Context, msg []byte) []byte {
+	msg = bytes.TrimSpace(msg)
+	if s.recorder != nil {
+		s.recorder.RecordMessage(ctx, Inbound, msg)
+	}
+
+	var resp *Response
+	var req Request
+	if err := json.Unmarshal(msg, &req); err != nil {
+		resp = NewErrorResponse(nil, NewParseError("Failed to unmarshal request", err))
+	} else if !s.acquire() {
+		if req.IsNotification() {
+			return nil
+		}
+		resp = NewErrorResponse(req.ID, NewShuttingDownError())
+	} else {
+		ctx, cancel := context.WithCancel(ctx)
+		stop := context.AfterFunc(s.baseCtx, cancel)
+		resp = s.HandleRequest(ctx, &req)
+		stop()
+		cancel()
+		s.inFlight.Done()
+		if req.IsNotification() {
+			return nil
+		}
+	}
+
+	data, err := json.Marshal(resp)
+	if err != nil {
+		s.logger.WithError(err).Error("Failed to marshal message")
+		return nil
+	}
+	if s.recorder != nil {
+		s.recorder.RecordMessage(ctx, Outbound, data)
+	}
+	return data
+}
+
+// ServeStdio reads newline-delimited requests from stdin and writes responses
+// to stdout. It returns nil on EOF and ErrServerClosed after Shutdown.
 func (s *Server) ServeStdio() error {
 	s.logger.Info("Starting JSON-RPC server over stdio")
+	return s.Serve(StdioTransport())
+}
 
-	reader := bufio.NewReader(os.Stdin)
-	writer := bufio.NewWriter(os.Stdout)
+// Serve reads newline-delimited requests from t and writes responses and
+// notifications to it until the transport reaches EOF, in which case it
+// returns nil, or Shutdown is called, in which case it returns
+// ErrServerClosed. Serve does not close t.
+func (s *Server) Serve(t Transport) error {
+	conn := s.addConnection(bufio.NewWriter(t))
+	defer s.removeConnection(conn)
 
-	defer writer.Flush()
+	// Read in a separate goroutine so a blocked read does not prevent the
+	// loop from noticing a shutdown. Once the loop has stopped, the reader
+	// answers every further request itself with a shutting-down error, so
+	// none is left without a response.
+	lines := make(chan []byte)
+	readErr := make(chan error, 1)
+	go func() {
+		reader := bufio.NewReader(t)
+		for {
+			line, err := reader.ReadBytes('\n')
+			if err != nil {
+				readErr <- err
+				return
+			}
+			select {
+			case lines <- line:
+			case <-s.quit:
+				s.handleLine(line, conn)
+			}
+		}
+	}()
 
 	for {
-		line, err := reader.ReadBytes('\n')
-		if err != nil {
-			if err == io.EOF {
-				s.logger.Info("JSON-RPC server over stdio stopped")
+		select {
+		case <-s.quit:
+			s.logger.Info("JSON-RPC server stopped accepting requests")
+			return ErrServerClosed
+		case err := <-readErr:
+			if err == io.EOF || errors.Is(err, io.ErrClosedPipe) {
+				s.logger.Info("JSON-RPC transport closed")
 				return nil
 			}
 			s.logger.WithError(err).Error("Failed to read request")
 			return err
+		case line := <-lines:
+			s.handleLine(line, conn)
 		}
-		s.logger.WithField("request", string(line)).Debug("Read request")
-		var req Request
-		if err := json.Unmarshal(line, &req); err != nil {
-			s.logger.WithError(err).Error("Failed to unmarshal request")
-			res := NewErrorResponse(nil, NewParseError("Failed to unmarshal request", err))
-			s.writeResponse(writer, res)
-			continue
-		}
-		resp := s.HandleRequest(&req)
+	}
+}
+
+// handleLine decodes a single request line, dispatches it and writes the response.
+func (s *Server) handleLine(line []byte, conn *connection) {
+	s.logger.WithField("request", string(line)).Debug("Read request")
+	if s.recorder != nil {
+		s.recorder.RecordMessage(s.baseCtx, Inbound, bytes.TrimSpace(line))
+	}
+	var req Request
+	if err := json.Unmarshal(line, &req); err != nil {
+		s.logger.WithError(err).Error("Failed to unmarshal request")
+		res := NewErrorResponse(nil, NewParseError("Failed to unmarshal request", err))
+		s.writeMessage(conn, res)
+		return
+	}
+
+	if !s.acquire() {
 		if !req.IsNotification() {
-			s.writeResponse(writer, resp)
+			s.writeMessage(conn, NewErrorResponse(req.ID, NewShuttingDownError()))
+		}
+		return
+	}
+	defer s.inFlight.Done()
+
+	ctx := context.WithValue(s.baseCtx, connectionKey{}, conn)
+	resp := s.HandleRequest(ctx, &req)
+	if !req.IsNotification() {
+		s.writeMessage(conn, resp)
+	}
+}
+
+// acquire registers a new in-flight request. It returns false once the
+// server is shutting down and no new requests may be started.
+func (s *Server) acquire() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closing {
+		return false
+	}
+	s.inFlight.Add(1)
+	return true
+}
+
+// Shutdown stops accepting new requests and waits for in-flight requests to
+// finish and their responses to be written. If ctx expires first, the
+// contexts of in-flight handlers are cancelled and ctx.Err() is returned.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.mu.Lock()
+	if !s.closing {
+		s.closing = true
+		close(s.quit)
+	}
+	s.mu.Unlock()
+
+	s.logger.Info("Shutting down JSON-RPC server, waiting for in-flight requests")
+
+	done := make(chan struct{})
+	go func() {
+		s.inFlight.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		s.cancel()
+		s.logger.Info("JSON-RPC server shut down cleanly")
+		return nil
+	case <-ctx.Done():
+		s.cancel()
+		s.logger.WithError(ctx.Err()).Warn("Shutdown deadline exceeded, cancelled in-flight requests")
+		return ctx.Err()
+	}
+}
+
+// Notify sends a server-initiated notification to every connected client.
+func (s *Server) Notify(method string, params interface{}) error {
+	msg := &Request{JSONRPC: "2.0", Method: method}
+	if params != nil {
+		raw, err := json.Marshal(params)
+		if err != nil {
+			return fmt.Errorf("marshal %s params: %w", method, err)
 		}
+		msg.Params = raw
 	}
 
+	s.connMu.Lock()
+	conns := make([]*connection, 0, len(s.conns))
+	for c := range s.conns {
+		conns = append(conns, c)
+	}
+	s.connMu.Unlock()
+
+	s.logger.WithFields(logrus.Fields{
+		"method":      method,
+		"connections": len(conns),
+	}).Debug("Sending notification")
+
+	for _, c := range conns {
+		s.writeMessage(c, msg)
+	}
+	return nil
 }
 
-func (s *Server) writeResponse(writer *bufio.Writer, resp *Response) {
-	respBytes, err := json.Marshal(resp)
+// NotifyCaller sends a notification to the client that made the request
+// ctx belongs to, such as progress for that request. It returns
+// ErrNoCaller for requests served by ServeMessage or HandleMessage.
+func (s *Server) NotifyCaller(ctx context.Context, method string, params interface{}) error {
+	c, ok := ctx.Value(connectionKey{}).(*connection)
+	if !ok {
+		return ErrNoCaller
+	}
+	s.connMu.Lock()
+	_, open := s.conns[c]
+	s.connMu.Unlock()
+	if !open {
+		return ErrNoCaller
+	}
+
+	msg := &Request{JSONRPC: "2.0", Method: method}
+	if params != nil {
+		raw, err := json.Marshal(params)
+		if err != nil {
+			return fmt.Errorf("marshal %s params: %w", method, err)
+		}
+		msg.Params = raw
+	}
+	s.writeMessage(c, msg)
+	return nil
+}
+
+func (s *Server) addConnection(writer *bufio.Writer) *connection {
+	c := &connection{writer: writer}
+	s.connMu.Lock()
+	s.conns[c] = struct{}{}
+	s.connMu.Unlock()
+	return c
+}
+
+func (s *Server) removeConnection(c *connection) {
+	s.connMu.Lock()
+	delete(s.conns, c)
+	s.connMu.Unlock()
+
+	c.mu.Lock()
+	c.writer.Flush()
+	c.mu.Unlock()
+}
+
+// writeMessage writes a response or notification as a single line.
+func (s *Server) writeMessage(c *connection, msg interface{}) {
+	msgBytes, err := json.Marshal(msg)
 	if err != nil {
-		s.logger.WithError(err).Error("Failed to marshal response")
+		s.logger.WithError(err).Error("Failed to marshal message")
 		return
 	}
-	writer.Write(respBytes)
-	writer.Write([]byte("\n"))
-	writer.Flush()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if s.recorder != nil {
+		s.recorder.RecordMessage(s.baseCtx, Outbound, msgBytes)
+	}
+	c.writer.Write(msgBytes)
+	c.writer.Write([]byte("\n"))
+	c.writer.Flush()
 }
-
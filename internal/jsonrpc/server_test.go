@@ -0,0 +1,159 @@
+package jsonrpc
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// testConn is the client side of a server served over a pipe.
+type testConn struct {
+	t      *testing.T
+	w      io.Writer
+	r      *bufio.Reader
+	served chan error
+}
+
+func serveTest(t *testing.T, s *Server) *testConn {
+	t.Helper()
+	clientSide, serverSide := NewPipeTransport()
+	t.Cleanup(func() { clientSide.Close() })
+
+	served := make(chan error, 1)
+	go func() {
+		served <- s.Serve(serverSide)
+	}()
+	return &testConn{t: t, w: clientSide, r: bufio.NewReader(clientSide), served: served}
+}
+
+func (c *testConn) send(line string) {
+	c.t.Helper()
+	if _, err := io.WriteString(c.w, line+"\n"); err != nil {
+		c.t.Fatalf("write request: %v", err)
+	}
+}
+
+func (c *testConn) receive() *Response {
+	c.t.Helper()
+	line, err := c.r.ReadBytes('\n')
+	if err != nil {
+		c.t.Fatalf("read response: %v", err)
+	}
+	var resp Response
+	if err := json.Unmarshal(line, &resp); err != nil {
+		c.t.Fatalf("decode response %q: %v", line, err)
+	}
+	return &resp
+}
+
+func newTestServer() *Server {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	return NewServer(logger)
+}
+
+// blockingMethod registers a method that signals started and then waits
+// for release or for its context to end.
+func blockingMethod(s *Server) (started <-chan struct{}, release chan<- struct{}) {
+	startedCh := make(chan struct{})
+	releaseCh := make(chan struct{})
+	s.RegisterMethod("block", func(ctx context.Context, _ json.RawMessage) (interface{}, *Error) {
+		close(startedCh)
+		select {
+		case <-releaseCh:
+			return "released", nil
+		case <-ctx.Done():
+			return nil, NewInternalError("cancelled", ctx.Err().Error())
+		}
+	})
+	return startedCh, releaseCh
+}
+
+func TestShutdownWaitsForInFlightRequests(t *testing.T) {
+	s := newTestServer()
+	started, release := blockingMethod(s)
+	conn := serveTest(t, s)
+
+	conn.send(`{"jsonrpc":"2.0","id":1,"method":"block"}`)
+	<-started
+
+	shutdown := make(chan error, 1)
+	go func() {
+		shutdown <- s.Shutdown(context.Background())
+	}()
+
+	// A request read while shutting down is answered, not dropped.
+	conn.send(`{"jsonrpc":"2.0","id":2,"method":"block"}`)
+	if resp := conn.receive(); resp.Error == nil || resp.Error.Code != ErrorShuttingDown {
+		t.Fatalf("request during shutdown = %+v, want a shutting-down error", resp)
+	}
+
+	select {
+	case err := <-shutdown:
+		t.Fatalf("Shutdown returned %v before the in-flight request finished", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+	if resp := conn.receive(); resp.Error != nil || resp.Result != "released" {
+		t.Errorf("in-flight request = %+v, want its result", resp)
+	}
+	if err := <-shutdown; err != nil {
+		t.Errorf("Shutdown = %v, want nil", err)
+	}
+	if err := <-conn.served; !errors.Is(err, ErrServerClosed) {
+		t.Errorf("Serve = %v, want ErrServerClosed", err)
+	}
+}
+
+func TestShutdownDeadlineCancelsInFlightRequests(t *testing.T) {
+	s := newTestServer()
+	started, _ := blockingMethod(s)
+	conn := serveTest(t, s)
+
+	conn.send(`{"jsonrpc":"2.0","id":1,"method":"block"}`)
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	shutdown := make(chan error, 1)
+	go func() {
+		shutdown <- s.Shutdown(ctx)
+	}()
+
+	// The cancelled handler still gets to answer its caller.
+	if resp := conn.receive(); resp.Error == nil || resp.Error.Message != "cancelled" {
+		t.Errorf("in-flight request = %+v, want the cancellation error", resp)
+	}
+	if err := <-shutdown; !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Shutdown = %v, want DeadlineExceeded", err)
+	}
+}
+
+func TestServeMessageAfterShutdown(t *testing.T) {
+	s := newTestServer()
+	s.RegisterMethod("ping", func(context.Context, json.RawMessage) (interface{}, *Error) {
+		return "pong", nil
+	})
+
+	if err := s.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+
+	var resp Response
+	if err := json.Unmarshal(s.ServeMessage(context.Background(), []byte(`{"jsonrpc":"2.0","id":1,"method":"ping"}`)), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Error == nil || resp.Error.Code != ErrorShuttingDown {
+		t.Errorf("ServeMessage after Shutdown = %+v, want a shutting-down error", resp)
+	}
+	if got := s.ServeMessage(context.Background(), []byte(`{"jsonrpc":"2.0","method":"ping"}`)); got != nil {
+		t.Errorf("notification after Shutdown answered with %s", got)
+	}
+}
@@ -0,0 +1,94 @@
+package jsonrpc
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestMetaCarrier(t *testing.T) {
+	tests := []struct {
+		name   string
+		params string
+		want   map[string]string
+	}{
+		{"trace context", `{"_meta":{"traceparent":"00-abc-def-01","tracestate":"vendor=1"}}`,
+			map[string]string{"traceparent": "00-abc-def-01", "tracestate": "vendor=1"}},
+		{"other fields kept", `{"name":"x","_meta":{"progressToken":"p1","traceparent":"t"}}`,
+			map[string]string{"progressToken": "p1", "traceparent": "t"}},
+		{"non-string values skipped", `{"_meta":{"progressToken":7,"traceparent":"t"}}`,
+			map[string]string{"traceparent": "t"}},
+		{"no _meta", `{"name":"x"}`, map[string]string{}},
+		{"_meta not an object", `{"_meta":"traceparent"}`, map[string]string{}},
+		{"array params", `["traceparent"]`, map[string]string{}},
+		{"no params", ``, map[string]string{}},
+		{"malformed", `{"_meta":`, map[string]string{}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := metaCarrier(json.RawMessage(tc.params))
+			if len(got) != len(tc.want) {
+				t.Fatalf("metaCarrier = %v, want %v", got, tc.want)
+			}
+			for key, value := range tc.want {
+				if got[key] != value {
+					t.Errorf("metaCarrier[%q] = %q, want %q", key, got[key], value)
+				}
+			}
+		})
+	}
+}
+
+// The global tracer provider can only be installed once for the package's
+// tracer, so this is the package's one test of recorded spans.
+func TestHandleRequestContinuesTraceFromMeta(t *testing.T) {
+	spans := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans))
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() { provider.Shutdown(context.Background()) })
+
+	s := newTestServer()
+	var handlerSpan trace.SpanContext
+	s.RegisterMethod("tools/call", func(ctx context.Context, _ json.RawMessage) (interface{}, *Error) {
+		handlerSpan = trace.SpanContextFromContext(ctx)
+		return "ok", nil
+	})
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	const parentID = "00f067aa0ba902b7"
+	s.HandleRequest(context.Background(), &Request{
+		JSONRPC: "2.0",
+		ID:      1,
+		Method:  "tools/call",
+		Params:  json.RawMessage(`{"name":"list_orders","_meta":{"traceparent":"00-` + traceID + `-` + parentID + `-01"}}`),
+	})
+	s.HandleRequest(context.Background(), &Request{JSONRPC: "2.0", ID: 2, Method: "tools/call"})
+
+	ended := spans.Ended()
+	if len(ended) != 2 {
+		t.Fatalf("ended spans = %d, want one per request", len(ended))
+	}
+	continued, fresh := ended[0], ended[1]
+	if got := continued.SpanContext().TraceID().String(); got != traceID {
+		t.Errorf("trace ID = %s, want the client's %s", got, traceID)
+	}
+	if got := continued.Parent().SpanID().String(); got != parentID || !continued.Parent().IsRemote() {
+		t.Errorf("parent = %s (remote: %v), want the client's span %s", got, continued.Parent().IsRemote(), parentID)
+	}
+	if continued.SpanKind() != trace.SpanKindServer || continued.Name() != "tools/call" {
+		t.Errorf("span = %s %s, want a server span named tools/call", continued.SpanKind(), continued.Name())
+	}
+	if fresh.Parent().IsValid() || fresh.SpanContext().TraceID().String() == traceID {
+		t.Error("a request without _meta joined another trace")
+	}
+	if handlerSpan.SpanID() != fresh.SpanContext().SpanID() {
+		t.Error("the handler did not run inside the request's span")
+	}
+}
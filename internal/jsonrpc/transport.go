@@ -0,0 +1,68 @@
+package jsonrpc
+
+import (
+	"io"
+	"os"
+)
+
+// Transport is a bidirectional stream carrying newline-delimited JSON-RPC
+// messages. The server reads requests from it and writes responses and
+// notifications to it; closing it ends the session.
+type Transport interface {
+	io.Reader
+	io.Writer
+	io.Closer
+}
+
+// streamTransport pairs an independent reader and writer.
+type streamTransport struct {
+	io.Reader
+	io.Writer
+}
+
+// NewStreamTransport combines r and w into a Transport. Closing it closes
+// whichever of the two implement io.Closer.
+func NewStreamTransport(r io.Reader, w io.Writer) Transport {
+	return &streamTransport{Reader: r, Writer: w}
+}
+
+// StdioTransport returns a transport over the process's stdin and stdout.
+func StdioTransport() Transport {
+	return NewStreamTransport(os.Stdin, os.Stdout)
+}
+
+func (t *streamTransport) Close() error {
+	var err error
+	if c, ok := t.Writer.(io.Closer); ok {
+		err = c.Close()
+	}
+	// The reader may be the same object as the writer (a net.Conn, say),
+	// so a second close failing is expected and ignored.
+	if c, ok := t.Reader.(io.Closer); ok {
+		c.Close()
+	}
+	return err
+}
+
+// NewPipeTransport returns two connected in-process transports: whatever
+// is written to one is read from the other. Serve the server side and hand
+// the client side to an MCP client to run the full protocol without a
+// subprocess. Closing either side ends the session for both.
+func NewPipeTransport() (client, server Transport) {
+	toServer, fromClient := io.Pipe()
+	toClient, fromServer := io.Pipe()
+	client = &pipeTransport{PipeReader: toClient, PipeWriter: fromClient}
+	server = &pipeTransport{PipeReader: toServer, PipeWriter: fromServer}
+	return client, server
+}
+
+type pipeTransport struct {
+	*io.PipeReader
+	*io.PipeWriter
+}
+
+// Close closes both directions so the peer sees EOF and pending writes fail.
+func (t *pipeTransport) Close() error {
+	t.PipeWriter.Close()
+	return t.PipeReader.Close()
+}
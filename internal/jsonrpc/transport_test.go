@@ -0,0 +1,123 @@
+package jsonrpc
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"testing"
+	"time"
+)
+
+// streamPair returns two transports built with NewStreamTransport over a
+// pair of pipes, connected as NewPipeTransport connects its two sides.
+func streamPair() (client, server Transport) {
+	toServer, fromClient := io.Pipe()
+	toClient, fromServer := io.Pipe()
+	return NewStreamTransport(toClient, fromClient), NewStreamTransport(toServer, fromServer)
+}
+
+// receiveNotification reads one message and decodes it as a notification.
+func (c *testConn) receiveNotification() *Request {
+	c.t.Helper()
+	line, err := c.r.ReadBytes('\n')
+	if err != nil {
+		c.t.Fatalf("read notification: %v", err)
+	}
+	var req Request
+	if err := json.Unmarshal(line, &req); err != nil || req.Method == "" || req.ID != nil {
+		c.t.Fatalf("got %s, want a notification", line)
+	}
+	return &req
+}
+
+func TestTransportsCarryTheProtocol(t *testing.T) {
+	for _, transport := range []struct {
+		name string
+		pair func() (client, server Transport)
+	}{
+		{"pipe", NewPipeTransport},
+		{"stream", streamPair},
+	} {
+		t.Run(transport.name, func(t *testing.T) {
+			s := newTestServer()
+			s.RegisterMethod("echo", func(_ context.Context, params json.RawMessage) (interface{}, *Error) {
+				var text string
+				if err := json.Unmarshal(params, &text); err != nil {
+					return nil, NewInvalidParamsError("want a string", err.Error())
+				}
+				return text, nil
+			})
+			s.RegisterMethod("work", func(ctx context.Context, _ json.RawMessage) (interface{}, *Error) {
+				if err := s.NotifyCaller(ctx, "progress", map[string]int{"done": 1}); err != nil {
+					return nil, NewInternalError("notify", err.Error())
+				}
+				return "worked", nil
+			})
+
+			clientSide, serverSide := transport.pair()
+			served := make(chan error, 1)
+			go func() {
+				served <- s.Serve(serverSide)
+			}()
+			conn := &testConn{t: t, w: clientSide, r: bufio.NewReader(clientSide), served: served}
+
+			conn.send(`{"jsonrpc":"2.0","id":1,"method":"echo","params":"hello"}`)
+			if resp := conn.receive(); resp.Error != nil || resp.Result != "hello" || resp.ID != 1.0 {
+				t.Errorf("echo = %+v, want hello for id 1", resp)
+			}
+
+			conn.send(`{"jsonrpc":"2.0","id":2,"method":"work"}`)
+			if note := conn.receiveNotification(); note.Method != "progress" || string(note.Params) != `{"done":1}` {
+				t.Errorf("notification = %s %s, want progress", note.Method, note.Params)
+			}
+			if resp := conn.receive(); resp.Error != nil || resp.Result != "worked" {
+				t.Errorf("work = %+v, want its result after the progress", resp)
+			}
+
+			// Pipes are synchronous: the write completes as the client reads.
+			notified := make(chan error, 1)
+			go func() {
+				notified <- s.Notify("changed", nil)
+			}()
+			if note := conn.receiveNotification(); note.Method != "changed" {
+				t.Errorf("broadcast = %s, want changed", note.Method)
+			}
+			if err := <-notified; err != nil {
+				t.Fatal(err)
+			}
+
+			conn.send(`{"jsonrpc":"2.0","id":3,"method":"missing"}`)
+			if resp := conn.receive(); resp.Error == nil || resp.Error.Code != ErrorMethodNotFound {
+				t.Errorf("unknown method = %+v, want method not found", resp)
+			}
+
+			// Closing the client's side is EOF for the server.
+			clientSide.Close()
+			select {
+			case err := <-served:
+				if err != nil {
+					t.Errorf("Serve = %v, want nil once the client closes", err)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("Serve kept running after the client closed")
+			}
+		})
+	}
+}
+
+func TestPipeTransportCloseEndsBothSides(t *testing.T) {
+	clientSide, serverSide := NewPipeTransport()
+	clientSide.Close()
+
+	if _, err := serverSide.Read(make([]byte, 1)); err != io.EOF {
+		t.Errorf("server read after the client closed = %v, want EOF", err)
+	}
+	if _, err := serverSide.Write([]byte("{}\n")); !errors.Is(err, io.ErrClosedPipe) {
+		t.Errorf("server write after the client closed = %v, want ErrClosedPipe", err)
+	}
+	if _, err := clientSide.Write([]byte("{}\n")); !errors.Is(err, io.ErrClosedPipe) {
+		t.Errorf("client write after closing = %v, want ErrClosedPipe", err)
+	}
+}
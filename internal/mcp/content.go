@@ -0,0 +1,228 @@
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// ---- Content types ----
+
+// Content type discriminators.
+const (
+	ContentTypeText         = "text"
+	ContentTypeImage        = "image"
+	ContentTypeAudio        = "audio"
+	ContentTypeResource     = "resource"
+	ContentTypeResourceLink = "resource_link"
+)
+
+// ---- Annotations ----
+
+// Role identifies the intended reader of a piece of content.
+type Role string
+
+const (
+	RoleUser      Role = "user"
+	RoleAssistant Role = "assistant"
+)
+
+// Annotations tell the client how content is meant to be used or displayed.
+type Annotations struct {
+	Audience     []Role   `json:"audience,omitempty"`     // who the content is intended for
+	Priority     *float64 `json:"priority,omitempty"`     // 0 (optional) to 1 (required)
+	LastModified string   `json:"lastModified,omitempty"` // ISO 8601 timestamp
+}
+
+// withAudience returns a copy of a with the audience replaced. a may be nil.
+func (a *Annotations) withAudience(audience []Role) *Annotations {
+	out := a.clone()
+	out.Audience = append([]Role(nil), audience...)
+	return out
+}
+
+// withPriority returns a copy of a with the priority clamped to [0, 1]. a may be nil.
+func (a *Annotations) withPriority(priority float64) *Annotations {
+	out := a.clone()
+	priority = min(max(priority, 0), 1)
+	out.Priority = &priority
+	return out
+}
+
+// withLastModified returns a copy of a with the modification time set. a may be nil.
+func (a *Annotations) withLastModified(t time.Time) *Annotations {
+	out := a.clone()
+	out.LastModified = t.UTC().Format(time.RFC3339)
+	return out
+}
+
+func (a *Annotations) clone() *Annotations {
+	if a == nil {
+		return &Annotations{}
+	}
+	out := *a
+	return &out
+}
+
+// ---- Content blocks ----
+
+// Content represents a content block in an MCP response.
+// Only the fields belonging to Type are serialized.
+type Content struct {
+	Type        string            `json:"type"`                  // one of the ContentType* constants
+	Text        string            `json:"text,omitempty"`        // for type "text"
+	MimeType    string            `json:"mimeType,omitempty"`    // for types "image", "audio" and "resource_link"
+	Data        string            `json:"data,omitempty"`        // for types "image" and "audio" (base64)
+	Resource    *ResourceContents `json:"resource,omitempty"`    // for type "resource"
+	URI         string            `json:"uri,omitempty"`         // for type "resource_link"
+	Name        string            `json:"name,omitempty"`        // for type "resource_link"
+	Description string            `json:"description,omitempty"` // for type "resource_link"
+	Annotations *Annotations      `json:"annotations,omitempty"`
+}
+
+// NewTextContent creates a text content block.
+func NewTextContent(text string) Content {
+	return Content{
+		Type: ContentTypeText,
+		Text: text,
+	}
+}
+
+// NewImageContent creates an image content block with base64-encoded data.
+func NewImageContent(mimeType, base64Data string) Content {
+	return Content{
+		Type:     ContentTypeImage,
+		MimeType: mimeType,
+		Data:     base64Data,
+	}
+}
+
+// NewAudioContent creates an audio content block with base64-encoded data.
+func NewAudioContent(mimeType, base64Data string) Content {
+	return Content{
+		Type:     ContentTypeAudio,
+		MimeType: mimeType,
+		Data:     base64Data,
+	}
+}
+
+// NewEmbeddedResourceContent creates a content block that embeds the
+// contents of a resource (text or blob) directly in the response.
+func NewEmbeddedResourceContent(resource ResourceContents) Content {
+	return Content{
+		Type:     ContentTypeResource,
+		Resource: &resource,
+	}
+}
+
+// NewResourceLinkContent creates a content block that points at a resource
+// the client can read later with "resources/read".
+func NewResourceLinkContent(uri, name, description, mimeType string) Content {
+	return Content{
+		Type:        ContentTypeResourceLink,
+		URI:         uri,
+		Name:        name,
+		Description: description,
+		MimeType:    mimeType,
+	}
+}
+
+// forProtocolVersion returns content as a client speaking version can parse
+// it: audio and resource_link blocks, which older versions do not have,
+// become text. It reports whether anything was converted; content itself
+// is never modified. Versions are dates, so they compare as strings.
+func forProtocolVersion(content []Content, version string) ([]Content, bool) {
+	var out []Content
+	for i, c := range content {
+		var text string
+		switch {
+		case c.Type == ContentTypeResourceLink && version < resourceLinkSince:
+			text = fmt.Sprintf("%s: %s", c.Name, c.URI)
+			if c.Description != "" {
+				text += "\n" + c.Description
+			}
+		case c.Type == ContentTypeAudio && version < audioSince:
+			text = fmt.Sprintf("[%s audio omitted: not supported by protocol version %s]", c.MimeType, version)
+		default:
+			if out != nil {
+				out = append(out, c)
+			}
+			continue
+		}
+		if out == nil {
+			out = append(make([]Content, 0, len(content)), content[:i]...)
+		}
+		converted := NewTextContent(text)
+		converted.Annotations = c.Annotations
+		out = append(out, converted)
+	}
+	if out == nil {
+		return content, false
+	}
+	return out, true
+}
+
+// NewErrorContent creates a text content block marked as an error.
+func NewErrorContent(text string) (Content, bool) {
+	return Content{
+		Type: ContentTypeText,
+		Text: text,
+	}, true
+}
+
+// WithAudience returns a copy of the content annotated for the given readers.
+func (c Content) WithAudience(audience ...Role) Content {
+	c.Annotations = c.Annotations.withAudience(audience)
+	return c
+}
+
+// WithPriority returns a copy of the content annotated with a priority
+// between 0 (entirely optional) and 1 (effectively required).
+func (c Content) WithPriority(priority float64) Content {
+	c.Annotations = c.Annotations.withPriority(priority)
+	return c
+}
+
+// WithLastModified returns a copy of the content annotated with its modification time.
+func (c Content) WithLastModified(t time.Time) Content {
+	c.Annotations = c.Annotations.withLastModified(t)
+	return c
+}
+
+// MarshalJSON emits the wire shape for the content's type, dropping fields
+// that belong to other variants.
+func (c Content) MarshalJSON() ([]byte, error) {
+	switch c.Type {
+	case ContentTypeText:
+		return json.Marshal(struct {
+			Type        string       `json:"type"`
+			Text        string       `json:"text"`
+			Annotations *Annotations `json:"annotations,omitempty"`
+		}{c.Type, c.Text, c.Annotations})
+	case ContentTypeImage, ContentTypeAudio:
+		return json.Marshal(struct {
+			Type        string       `json:"type"`
+			Data        string       `json:"data"`
+			MimeType    string       `json:"mimeType"`
+			Annotations *Annotations `json:"annotations,omitempty"`
+		}{c.Type, c.Data, c.MimeType, c.Annotations})
+	case ContentTypeResource:
+		return json.Marshal(struct {
+			Type        string            `json:"type"`
+			Resource    *ResourceContents `json:"resource"`
+			Annotations *Annotations      `json:"annotations,omitempty"`
+		}{c.Type, c.Resource, c.Annotations})
+	case ContentTypeResourceLink:
+		return json.Marshal(struct {
+			Type        string       `json:"type"`
+			URI         string       `json:"uri"`
+			Name        string       `json:"name"`
+			Description string       `json:"description,omitempty"`
+			MimeType    string       `json:"mimeType,omitempty"`
+			Annotations *Annotations `json:"annotations,omitempty"`
+		}{c.Type, c.URI, c.Name, c.Description, c.MimeType, c.Annotations})
+	default:
+		type plain Content
+		return json.Marshal(plain(c))
+	}
+}
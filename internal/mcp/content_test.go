@@ -0,0 +1,91 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestForProtocolVersion(t *testing.T) {
+	link := NewResourceLinkContent("store://orders/1", "Order #1", "pending, $25.00", "application/json")
+	audio := NewAudioContent("audio/wav", "UklGRg==")
+	text := NewTextContent("Found 1 orders")
+
+	tests := []struct {
+		version string
+		want    []string // content types
+	}{
+		{"2025-06-18", []string{ContentTypeText, ContentTypeResourceLink, ContentTypeAudio}},
+		{"2025-03-26", []string{ContentTypeText, ContentTypeText, ContentTypeAudio}},
+		{"2024-11-05", []string{ContentTypeText, ContentTypeText, ContentTypeText}},
+	}
+	for _, tc := range tests {
+		content := []Content{text, link.WithAudience(RoleAssistant), audio}
+		got, converted := forProtocolVersion(content, tc.version)
+
+		var types []string
+		for _, c := range got {
+			types = append(types, c.Type)
+		}
+		if fmt.Sprint(types) != fmt.Sprint(tc.want) {
+			t.Errorf("%s: content types = %v, want %v", tc.version, types, tc.want)
+		}
+		if converted != (tc.version != ProtocolVersion) {
+			t.Errorf("%s: converted = %v", tc.version, converted)
+		}
+		if content[1].Type != ContentTypeResourceLink {
+			t.Errorf("%s: the input content was modified", tc.version)
+		}
+	}
+
+	got, _ := forProtocolVersion([]Content{link.WithAudience(RoleAssistant)}, "2024-11-05")
+	if got[0].Text != "Order #1: store://orders/1\npending, $25.00" || got[0].Annotations == nil {
+		t.Errorf("link as text = %+v, want the name, URI and description, keeping annotations", got[0])
+	}
+}
+
+func TestToolCallFollowsNegotiatedVersion(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	server := NewServer("test", "1.0.0", logger)
+	err := server.RegisterTool(Tool{Name: "list_orders", InputSchema: InputSchema{Type: "object"}},
+		func(context.Context, map[string]interface{}) (*ToolCallResult, error) {
+			return &ToolCallResult{Content: []Content{
+				NewResourceLinkContent("store://orders/1", "Order #1", "", ""),
+			}}, nil
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	call := func(session, version string) string {
+		t.Helper()
+		ctx := ContextWithSessionID(context.Background(), session)
+		server.HandleMessage(ctx, []byte(`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"`+version+`","capabilities":{},"clientInfo":{"name":"t","version":"1"}}}`))
+		resp := server.HandleMessage(ctx, []byte(`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"list_orders"}}`))
+		var decoded struct {
+			Result ToolCallResult `json:"result"`
+		}
+		if err := json.Unmarshal(resp, &decoded); err != nil || len(decoded.Result.Content) != 1 {
+			t.Fatalf("tools/call = %s", resp)
+		}
+		return decoded.Result.Content[0].Type
+	}
+
+	if got := call("old", "2024-11-05"); got != ContentTypeText {
+		t.Errorf("content for a 2024-11-05 session = %s, want text", got)
+	}
+	if got := call("new", ProtocolVersion); got != ContentTypeResourceLink {
+		t.Errorf("content for a %s session = %s, want resource_link", ProtocolVersion, got)
+	}
+
+	server.EndSession("old")
+	ctx := ContextWithSessionID(context.Background(), "old")
+	if got := server.registry.protocolVersion(ctx); got != ProtocolVersion {
+		t.Errorf("version after EndSession = %s, want the latest", got)
+	}
+}
@@ -0,0 +1,217 @@
+package mcp
+
+import (
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ---- Namespaces ----
+
+// NamespaceSeparator joins a namespace and a tool or prompt name,
+// e.g. "store.products" + "list" becomes "store.products.list".
+const NamespaceSeparator = "."
+
+// namespacePattern limits namespaces to characters allowed in MCP tool names.
+var namespacePattern = regexp.MustCompile(`^[A-Za-z0-9_-]+(\.[A-Za-z0-9_-]+)*$`)
+
+// mount records the entries a Mount call added, so they can be replaced.
+type mount struct {
+	tools     []string
+	resources []string
+	templates []string
+	prompts   []string
+}
+
+// contents is a copy of a registry's entries, so a mount can read a child
+// without holding its lock while it locks the parent.
+type contents struct {
+	tools            map[string]Tool
+	toolHandlers     map[string]ToolHandler
+	resources        map[string]Resource
+	resourceHandlers map[string]ResourceHandler
+	templates        []resourceTemplate
+	prompts          map[string]Prompt
+	promptHandlers   map[string]PromptHandler
+}
+
+// contents copies r's entries under its read lock.
+func (r *Registry) contents() *contents {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return &contents{
+		tools:            maps.Clone(r.tools),
+		toolHandlers:     maps.Clone(r.toolHandlers),
+		resources:        maps.Clone(r.resources),
+		resourceHandlers: maps.Clone(r.resourceHandlers),
+		templates:        slices.Clone(r.templates),
+		prompts:          maps.Clone(r.prompts),
+		promptHandlers:   maps.Clone(r.promptHandlers),
+	}
+}
+
+// Namespaced returns name qualified with the given namespace.
+func Namespaced(namespace, name string) string {
+	if namespace == "" {
+		return name
+	}
+	return namespace + NamespaceSeparator + name
+}
+
+// Mount copies every tool, resource, resource template, and prompt of child
+// into r. Tool and prompt names are prefixed with the namespace; resource
+// URIs and URI templates are already globally unique and are kept as-is. Nothing is added if any
+// entry collides with an existing one, and the returned error wraps
+// ErrAlreadyRegistered.
+//
+// Mount takes a snapshot: entries registered on child afterwards are not
+// visible through r. Use Remount to refresh them.
+func (r *Registry) Mount(namespace string, child *Registry) error {
+	return r.mount(namespace, child, false)
+}
+
+// Remount atomically replaces everything previously mounted under namespace
+// with the current contents of child. If the new entries collide with
+// others, the previous mount is kept and an error is returned.
+func (r *Registry) Remount(namespace string, child *Registry) error {
+	return r.mount(namespace, child, true)
+}
+
+// Unmount removes everything previously mounted under namespace.
+func (r *Registry) Unmount(namespace string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.removeMountLocked(namespace)
+}
+
+func (r *Registry) mount(namespace string, child *Registry, replace bool) error {
+	if !namespacePattern.MatchString(namespace) {
+		return fmt.Errorf("invalid namespace %q", namespace)
+	}
+	if child == r {
+		return fmt.Errorf("cannot mount a registry into itself")
+	}
+
+	// Copy the child before locking r, so two registries mounted into each
+	// other cannot deadlock.
+	c := child.contents()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	previous, mounted := r.mounts[namespace]
+	if mounted && !replace {
+		return fmt.Errorf("namespace %q: %w", namespace, ErrAlreadyRegistered)
+	}
+
+	// Entries owned by the mount being replaced do not count as collisions.
+	owned := map[string]bool{}
+	if previous != nil {
+		for _, name := range previous.tools {
+			owned["tool:"+name] = true
+		}
+		for _, uri := range previous.resources {
+			owned["resource:"+uri] = true
+		}
+		for _, uri := range previous.templates {
+			owned["template:"+uri] = true
+		}
+		for _, name := range previous.prompts {
+			owned["prompt:"+name] = true
+		}
+	}
+
+	// Check every entry first so a failed mount leaves r untouched.
+	for name := range c.tools {
+		full := Namespaced(namespace, name)
+		if _, exists := r.tools[full]; exists && !owned["tool:"+full] {
+			return fmt.Errorf("mount %q: tool %q: %w", namespace, full, ErrAlreadyRegistered)
+		}
+	}
+	for uri := range c.resources {
+		if _, exists := r.resources[uri]; exists && !owned["resource:"+uri] {
+			return fmt.Errorf("mount %q: resource %q: %w", namespace, uri, ErrAlreadyRegistered)
+		}
+	}
+	for _, t := range c.templates {
+		if r.hasTemplateLocked(t.URITemplate) && !owned["template:"+t.URITemplate] {
+			return fmt.Errorf("mount %q: resource template %q: %w", namespace, t.URITemplate, ErrAlreadyRegistered)
+		}
+	}
+	for name := range c.prompts {
+		full := Namespaced(namespace, name)
+		if _, exists := r.prompts[full]; exists && !owned["prompt:"+full] {
+			return fmt.Errorf("mount %q: prompt %q: %w", namespace, full, ErrAlreadyRegistered)
+		}
+	}
+
+	r.removeMountLocked(namespace)
+
+	m := &mount{}
+	for name, tool := range c.tools {
+		tool.Name = Namespaced(namespace, name)
+		r.tools[tool.Name] = tool
+		r.toolHandlers[tool.Name] = c.toolHandlers[name]
+		m.tools = append(m.tools, tool.Name)
+	}
+	for uri, resource := range c.resources {
+		r.resources[uri] = resource
+		r.resourceHandlers[uri] = c.resourceHandlers[uri]
+		m.resources = append(m.resources, uri)
+	}
+	for _, t := range c.templates {
+		r.templates = append(r.templates, t)
+		m.templates = append(m.templates, t.URITemplate)
+	}
+	for name, prompt := range c.prompts {
+		prompt.Name = Namespaced(namespace, name)
+		r.prompts[prompt.Name] = prompt
+		r.promptHandlers[prompt.Name] = c.promptHandlers[name]
+		m.prompts = append(m.prompts, prompt.Name)
+	}
+	r.mounts[namespace] = m
+
+	r.logger.WithFields(logrus.Fields{
+		"namespace": namespace,
+		"tools":     len(m.tools),
+		"resources": len(m.resources),
+		"templates": len(m.templates),
+		"prompts":   len(m.prompts),
+	}).Info("Mounted registry")
+
+	return nil
+}
+
+// removeMountLocked deletes the entries added under namespace. r.mu must be held.
+func (r *Registry) removeMountLocked(namespace string) {
+	m, ok := r.mounts[namespace]
+	if !ok {
+		return
+	}
+	for _, name := range m.tools {
+		delete(r.tools, name)
+		delete(r.toolHandlers, name)
+	}
+	for _, uri := range m.resources {
+		delete(r.resources, uri)
+		delete(r.resourceHandlers, uri)
+	}
+	r.templates = slices.DeleteFunc(r.templates, func(t resourceTemplate) bool {
+		return slices.Contains(m.templates, t.URITemplate)
+	})
+	for _, name := range m.prompts {
+		delete(r.prompts, name)
+		delete(r.promptHandlers, name)
+	}
+	delete(r.mounts, namespace)
+}
+
+// hasTemplateLocked reports whether uriTemplate is registered. r.mu must be held.
+func (r *Registry) hasTemplateLocked(uriTemplate string) bool {
+	return slices.ContainsFunc(r.templates, func(t resourceTemplate) bool {
+		return t.URITemplate == uriTemplate
+	})
+}
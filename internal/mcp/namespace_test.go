@@ -0,0 +1,262 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func newTestRegistry() *Registry {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	return NewRegistry(ClientInfo{}, "", logger)
+}
+
+// entries lists what to register on a test registry.
+type entries struct {
+	tools, resources, templates, prompts []string
+}
+
+func registryWith(t *testing.T, e entries) *Registry {
+	t.Helper()
+	r := newTestRegistry()
+	for _, name := range e.tools {
+		err := r.RegisterTool(Tool{Name: name, InputSchema: InputSchema{Type: "object"}},
+			func(context.Context, map[string]interface{}) (*ToolCallResult, error) {
+				return &ToolCallResult{Content: []Content{NewTextContent(name)}}, nil
+			})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	read := func(_ context.Context, uri string) (*ReadResourceResult, error) {
+		return &ReadResourceResult{Contents: []ResourceContents{NewTextResourceContents(uri, "text/plain", uri)}}, nil
+	}
+	for _, uri := range e.resources {
+		if err := r.RegisterResource(Resource{URI: uri, Name: uri}, read); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, uri := range e.templates {
+		if err := r.RegisterResourceTemplate(ResourceTemplate{URITemplate: uri, Name: uri}, read); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, name := range e.prompts {
+		err := r.RegisterPrompt(Prompt{Name: name}, func(context.Context, map[string]string) (*GetPromptResult, error) {
+			return &GetPromptResult{}, nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return r
+}
+
+// listing summarizes everything a registry lists.
+func listing(r *Registry) string {
+	var tools, resources, templates, prompts []string
+	for _, tool := range r.Tools() {
+		tools = append(tools, tool.Name)
+	}
+	for _, resource := range r.Resources() {
+		resources = append(resources, resource.URI)
+	}
+	for _, template := range r.ResourceTemplates() {
+		templates = append(templates, template.URITemplate)
+	}
+	for _, prompt := range r.Prompts() {
+		prompts = append(prompts, prompt.Name)
+	}
+	return fmt.Sprintf("tools=%v resources=%v templates=%v prompts=%v", tools, resources, templates, prompts)
+}
+
+func TestMount(t *testing.T) {
+	child := entries{
+		tools:     []string{"list"},
+		resources: []string{"store://catalog"},
+		templates: []string{"store://products/{id}"},
+		prompts:   []string{"browse"},
+	}
+
+	tests := []struct {
+		name      string
+		parent    entries
+		mounted   map[string]entries // mounted on the parent first
+		namespace string
+		remount   bool
+		wantErr   bool
+		want      string // listing after a successful mount
+	}{
+		{
+			name:      "into an empty registry",
+			namespace: "store",
+			want:      "tools=[store.list] resources=[store://catalog] templates=[store://products/{id}] prompts=[store.browse]",
+		},
+		{
+			name:      "nested namespace",
+			namespace: "store.catalog",
+			want:      "tools=[store.catalog.list] resources=[store://catalog] templates=[store://products/{id}] prompts=[store.catalog.browse]",
+		},
+		{
+			name:      "next to the parent's own entries",
+			parent:    entries{tools: []string{"list"}, prompts: []string{"browse"}},
+			namespace: "store",
+			want:      "tools=[list store.list] resources=[store://catalog] templates=[store://products/{id}] prompts=[browse store.browse]",
+		},
+		{name: "invalid namespace", namespace: "store/catalog", wantErr: true},
+		{name: "empty namespace", namespace: "", wantErr: true},
+		{name: "tool collision", parent: entries{tools: []string{"store.list"}}, namespace: "store", wantErr: true},
+		{name: "resource collision", parent: entries{resources: []string{"store://catalog"}}, namespace: "store", wantErr: true},
+		{name: "template collision", parent: entries{templates: []string{"store://products/{id}"}}, namespace: "store", wantErr: true},
+		{name: "prompt collision", parent: entries{prompts: []string{"store.browse"}}, namespace: "store", wantErr: true},
+		{
+			name:      "namespace already mounted",
+			mounted:   map[string]entries{"store": {tools: []string{"old"}}},
+			namespace: "store",
+			wantErr:   true,
+		},
+		{
+			name:      "remount replaces the previous mount",
+			mounted:   map[string]entries{"store": {tools: []string{"old"}, templates: []string{"store://products/{id}"}}},
+			namespace: "store",
+			remount:   true,
+			want:      "tools=[store.list] resources=[store://catalog] templates=[store://products/{id}] prompts=[store.browse]",
+		},
+		{
+			name:      "remount of a namespace not mounted yet",
+			namespace: "store",
+			remount:   true,
+			want:      "tools=[store.list] resources=[store://catalog] templates=[store://products/{id}] prompts=[store.browse]",
+		},
+		{
+			name:      "remount colliding with another mount",
+			mounted:   map[string]entries{"other": {templates: []string{"store://products/{id}"}}},
+			namespace: "store",
+			remount:   true,
+			wantErr:   true,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			parent := registryWith(t, tc.parent)
+			for namespace, e := range tc.mounted {
+				if err := parent.Mount(namespace, registryWith(t, e)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			before := listing(parent)
+
+			mount := parent.Mount
+			if tc.remount {
+				mount = parent.Remount
+			}
+			err := mount(tc.namespace, registryWith(t, child))
+
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("mount succeeded: %s", listing(parent))
+				}
+				if after := listing(parent); after != before {
+					t.Errorf("failed mount changed the registry:\n got %s\nwant %s", after, before)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("mount: %v", err)
+			}
+			if got := listing(parent); got != tc.want {
+				t.Errorf("after mount:\n got %s\nwant %s", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestMountCollisionWrapsErrAlreadyRegistered(t *testing.T) {
+	parent := registryWith(t, entries{tools: []string{"store.list"}})
+	err := parent.Mount("store", registryWith(t, entries{tools: []string{"list"}}))
+	if !errors.Is(err, ErrAlreadyRegistered) {
+		t.Errorf("Mount = %v, want ErrAlreadyRegistered", err)
+	}
+	if err := parent.Mount("self", parent); err == nil {
+		t.Error("a registry was mounted into itself")
+	}
+}
+
+func TestMountedTemplatesServeReads(t *testing.T) {
+	parent := newTestRegistry()
+	if err := parent.Mount("store", registryWith(t, entries{templates: []string{"store://products/{id}"}})); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := parent.ReadResource(context.Background(), "store://products/42")
+	if err != nil || len(result.Contents) != 1 || result.Contents[0].Text != "store://products/42" {
+		t.Fatalf("ReadResource through a mounted template = %+v, %v", result, err)
+	}
+
+	parent.Unmount("store")
+	if _, err := parent.ReadResource(context.Background(), "store://products/42"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("ReadResource after Unmount = %v, want ErrNotFound", err)
+	}
+	if got := listing(parent); got != "tools=[] resources=[] templates=[] prompts=[]" {
+		t.Errorf("after Unmount: %s", got)
+	}
+}
+
+func TestMountIsASnapshot(t *testing.T) {
+	parent := newTestRegistry()
+	child := registryWith(t, entries{tools: []string{"list"}})
+	if err := parent.Mount("store", child); err != nil {
+		t.Fatal(err)
+	}
+	if err := child.RegisterResourceTemplate(ResourceTemplate{URITemplate: "store://orders/{id}"}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := len(parent.ResourceTemplates()); got != 0 {
+		t.Errorf("templates added to the child after Mount = %d, want 0 until Remount", got)
+	}
+	if err := parent.Remount("store", child); err != nil {
+		t.Fatal(err)
+	}
+	if got := len(parent.ResourceTemplates()); got != 1 {
+		t.Errorf("templates after Remount = %d, want 1", got)
+	}
+}
+
+// A mount waiting for the parent's lock must not hold the child's: with
+// registries mounted into each other, that is a lock-order deadlock.
+func TestMountReleasesChildBeforeLockingParent(t *testing.T) {
+	parent := newTestRegistry()
+	child := registryWith(t, entries{tools: []string{"list"}})
+
+	// Hold the parent busy, as a concurrent mount into it would.
+	parent.mu.RLock()
+	mounted := make(chan error, 1)
+	go func() {
+		mounted <- parent.Mount("store", child)
+	}()
+	time.Sleep(50 * time.Millisecond) // let Mount reach the parent's lock
+
+	registered := make(chan error, 1)
+	go func() {
+		registered <- child.RegisterTool(Tool{Name: "added"}, nil)
+	}()
+	select {
+	case err := <-registered:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the child stayed locked while Mount waited for the parent")
+	}
+
+	parent.mu.RUnlock()
+	if err := <-mounted; err != nil {
+		t.Fatalf("Mount: %v", err)
+	}
+}
@@ -4,17 +4,17 @@ package mcp
 
 const (
 	// Server → Client notifications
-	NotificationToolsListChanged     = "notifications/tools/listChanged"
-	NotificationResourcesListChanged = "notifications/resources/listChanged"
+	NotificationToolsListChanged     = "notifications/tools/list_changed"
+	NotificationResourcesListChanged = "notifications/resources/list_changed"
 	NotificationResourceUpdated      = "notifications/resources/updated"
-	NotificationPromptsListChanged   = "notifications/prompts/listChanged"
+	NotificationPromptsListChanged   = "notifications/prompts/list_changed"
 	NotificationMessage              = "notifications/message"
 	NotificationProgress             = "notifications/progress"
 
 	// Client → Server notifications
 	NotificationInitialized  = "notifications/initialized"
 	NotificationCancelled    = "notifications/cancelled"
-	NotificationRootsChanged = "notifications/roots/listChanged"
+	NotificationRootsChanged = "notifications/roots/list_changed"
 )
 
 // ---- Method Constants ----
@@ -0,0 +1,95 @@
+package mcp
+
+import (
+	"errors"
+	"fmt"
+	"path"
+)
+
+// ---- Tool policy ----
+
+// ErrToolBlocked is returned when a registered tool is hidden by the
+// registry's ToolPolicy.
+var ErrToolBlocked = errors.New("not available")
+
+// ToolPolicy restricts which registered tools are listed and callable.
+// Patterns are path.Match globs on tool names, e.g. "*_order" or
+// "github.*". The zero value allows every tool.
+type ToolPolicy struct {
+	// ReadOnly allows only tools annotated with ReadOnlyHint.
+	ReadOnly bool
+	// Allow, when not empty, allows only tools matching one of its patterns.
+	Allow []string
+	// Deny blocks tools matching any of its patterns, even if allowed.
+	Deny []string
+	// Disabled blocks the named tools outright.
+	Disabled []string
+}
+
+// Validate checks that every pattern is a well-formed glob.
+func (p ToolPolicy) Validate() error {
+	for _, patterns := range [][]string{p.Allow, p.Deny} {
+		for _, pattern := range patterns {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("tool pattern %q: %w", pattern, err)
+			}
+		}
+	}
+	return nil
+}
+
+// blockReason returns why the policy blocks tool, or "" if it is allowed.
+func (p ToolPolicy) blockReason(tool Tool) string {
+	for _, name := range p.Disabled {
+		if name == tool.Name {
+			return "it is disabled in the server configuration"
+		}
+	}
+	if p.ReadOnly && !tool.IsReadOnly() {
+		return "the server is in read-only mode and this tool can modify state"
+	}
+	if len(p.Allow) > 0 && !matchAny(p.Allow, tool.Name) {
+		return "it is not in the server's tool allowlist"
+	}
+	if matchAny(p.Deny, tool.Name) {
+		return "it is in the server's tool denylist"
+	}
+	return ""
+}
+
+func matchAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// SetToolPolicy replaces the policy deciding which tools are listed and
+// callable, and reports whether the visible tool set changed. It applies
+// to tools registered or mounted later as well.
+func (r *Registry) SetToolPolicy(policy ToolPolicy) (bool, error) {
+	if err := policy.Validate(); err != nil {
+		return false, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	changed := false
+	for name, tool := range r.tools {
+		was, now := r.policy.blockReason(tool), policy.blockReason(tool)
+		if (was == "") == (now == "") {
+			continue
+		}
+		changed = true
+		if now != "" {
+			r.logger.WithField("tool", name).Info("Tool blocked by policy")
+		} else {
+			r.logger.WithField("tool", name).Info("Tool allowed by policy")
+		}
+	}
+	r.policy = policy
+	return changed, nil
+}
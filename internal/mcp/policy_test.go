@@ -0,0 +1,116 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestToolPolicy(t *testing.T) {
+	readOnly := Tool{Name: "list_orders", Annotations: &ToolAnnotations{ReadOnlyHint: true}}
+	mutating := Tool{Name: "place_order"}
+	namespaced := Tool{Name: "github.create_issue"}
+
+	tests := []struct {
+		name    string
+		policy  ToolPolicy
+		tool    Tool
+		blocked string // part of the reason; "" if allowed
+	}{
+		{"zero value allows", ToolPolicy{}, mutating, ""},
+		{"read-only allows read-only tools", ToolPolicy{ReadOnly: true}, readOnly, ""},
+		{"read-only blocks mutating tools", ToolPolicy{ReadOnly: true}, mutating, "read-only mode"},
+		{"allow glob matches", ToolPolicy{Allow: []string{"*_order"}}, mutating, ""},
+		{"allow glob misses", ToolPolicy{Allow: []string{"*_orders"}}, mutating, "allowlist"},
+		{"allow exact name", ToolPolicy{Allow: []string{"list_orders", "place_order"}}, mutating, ""},
+		{"star matches namespaced names", ToolPolicy{Allow: []string{"*"}}, namespaced, ""},
+		{"namespace glob", ToolPolicy{Allow: []string{"github.*"}}, namespaced, ""},
+		{"namespace glob misses", ToolPolicy{Allow: []string{"gitlab.*"}}, namespaced, "allowlist"},
+		{"character class", ToolPolicy{Deny: []string{"[pc]*_order"}}, mutating, "denylist"},
+		{"single character", ToolPolicy{Deny: []string{"place_orde?"}}, mutating, "denylist"},
+		{"deny overrides allow", ToolPolicy{Allow: []string{"*"}, Deny: []string{"place_*"}}, mutating, "denylist"},
+		{"deny misses", ToolPolicy{Deny: []string{"cancel_*"}}, mutating, ""},
+		{"disabled by name", ToolPolicy{Disabled: []string{"place_order"}}, mutating, "disabled"},
+		{"disabled is not a glob", ToolPolicy{Disabled: []string{"place_*"}}, mutating, ""},
+		{"disabled even if read-only", ToolPolicy{ReadOnly: true, Disabled: []string{"list_orders"}}, readOnly, "disabled"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			reason := tc.policy.blockReason(tc.tool)
+			if tc.blocked == "" {
+				if reason != "" {
+					t.Errorf("%s blocked: %s", tc.tool.Name, reason)
+				}
+				return
+			}
+			if !strings.Contains(reason, tc.blocked) {
+				t.Errorf("%s block reason = %q, want %q", tc.tool.Name, reason, tc.blocked)
+			}
+		})
+	}
+}
+
+func TestToolPolicyValidate(t *testing.T) {
+	if err := (ToolPolicy{Allow: []string{"*_order", "github.*"}, Deny: []string{"[pc]*"}}).Validate(); err != nil {
+		t.Errorf("Validate = %v, want nil", err)
+	}
+	for _, policy := range []ToolPolicy{
+		{Allow: []string{"[place"}},
+		{Deny: []string{"place_\\"}},
+	} {
+		if err := policy.Validate(); err == nil {
+			t.Errorf("Validate(%+v) = nil, want a bad pattern error", policy)
+		}
+	}
+}
+
+func TestSetToolPolicy(t *testing.T) {
+	r := newTestRegistry()
+	noop := func(context.Context, map[string]interface{}) (*ToolCallResult, error) {
+		return &ToolCallResult{}, nil
+	}
+	for _, tool := range []Tool{
+		{Name: "list_orders", Annotations: &ToolAnnotations{ReadOnlyHint: true}},
+		{Name: "place_order"},
+	} {
+		if err := r.RegisterTool(tool, noop); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := r.SetToolPolicy(ToolPolicy{Deny: []string{"[place"}}); err == nil {
+		t.Error("SetToolPolicy accepted a bad pattern")
+	}
+	changed, err := r.SetToolPolicy(ToolPolicy{ReadOnly: true})
+	if err != nil || !changed {
+		t.Fatalf("SetToolPolicy = %v, %v; want a change", changed, err)
+	}
+	if got := listing(r); got != "tools=[list_orders] resources=[] templates=[] prompts=[]" {
+		t.Errorf("listed under read-only: %s", got)
+	}
+	if _, err := r.CallTool(context.Background(), "place_order", nil); !errors.Is(err, ErrToolBlocked) {
+		t.Errorf("CallTool of a blocked tool = %v, want ErrToolBlocked", err)
+	}
+	if _, err := r.CallTool(context.Background(), "list_orders", nil); err != nil {
+		t.Errorf("CallTool of an allowed tool = %v", err)
+	}
+
+	// Tools registered later follow the policy too.
+	if err := r.RegisterTool(Tool{Name: "cancel_order"}, noop); err != nil {
+		t.Fatal(err)
+	}
+	if got := len(r.Tools()); got != 1 {
+		t.Errorf("tools listed after registering a mutating tool = %d, want 1", got)
+	}
+
+	if changed, _ := r.SetToolPolicy(ToolPolicy{ReadOnly: true, Deny: []string{"place_*"}}); changed {
+		t.Error("a policy hiding the same tools reported a change")
+	}
+	if changed, _ := r.SetToolPolicy(ToolPolicy{}); !changed {
+		t.Error("lifting the policy reported no change")
+	}
+	if got := len(r.Tools()); got != 3 {
+		t.Errorf("tools listed without a policy = %d, want 3", got)
+	}
+}
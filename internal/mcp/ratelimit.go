@@ -0,0 +1,227 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ---- Rate limits ----
+
+// RateLimit is a token bucket: it allows PerMinute calls a minute on
+// average, and bursts of up to Burst calls. A zero PerMinute means no
+// limit; a zero Burst allows a full minute's worth at once.
+type RateLimit struct {
+	PerMinute float64
+	Burst     int
+}
+
+func (l RateLimit) enabled() bool { return l.PerMinute > 0 }
+
+func (l RateLimit) capacity() float64 {
+	if l.Burst > 0 {
+		return float64(l.Burst)
+	}
+	return math.Max(1, l.PerMinute)
+}
+
+// RateLimits limits tool calls. A call must fit within every limit that
+// applies to it; the zero value limits nothing.
+type RateLimits struct {
+	// Global limits the calls of all sessions together.
+	Global RateLimit
+	// Session limits the calls of each session.
+	Session RateLimit
+	// Mutating limits each session's calls to tools that can modify
+	// state, i.e. are not annotated read-only.
+	Mutating RateLimit
+	// Tools limits each session's calls to the named tools.
+	Tools map[string]RateLimit
+}
+
+// stdioSession names the session of callers whose context carries no
+// session ID, i.e. the stdio client.
+const stdioSession = "stdio"
+
+type sessionIDKey struct{}
+
+// ContextWithSessionID returns a copy of ctx whose tool calls count
+// against the rate limits of session id.
+func ContextWithSessionID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, sessionIDKey{}, id)
+}
+
+// SessionIDFromContext returns the session ID set by ContextWithSessionID,
+// or "stdio" if there is none.
+func SessionIDFromContext(ctx context.Context) string {
+	if id, ok := ctx.Value(sessionIDKey{}).(string); ok && id != "" {
+		return id
+	}
+	return stdioSession
+}
+
+// bucket is a token bucket for one scope, e.g. one session's calls.
+type bucket struct {
+	limit  RateLimit
+	tokens float64
+	last   time.Time
+}
+
+// refill adds the tokens earned since the bucket was last used.
+func (b *bucket) refill(now time.Time) {
+	b.tokens = math.Min(b.limit.capacity(), b.tokens+now.Sub(b.last).Minutes()*b.limit.PerMinute)
+	b.last = now
+}
+
+// wait returns how long until the bucket has a token.
+func (b *bucket) wait() time.Duration {
+	if b.tokens >= 1 {
+		return 0
+	}
+	return time.Duration((1 - b.tokens) / b.limit.PerMinute * float64(time.Minute))
+}
+
+// sweepInterval is how often buckets that have refilled completely, and so
+// are no different from new ones, are dropped.
+const sweepInterval = time.Minute
+
+// rateLimiter enforces RateLimits with a bucket per scope.
+type rateLimiter struct {
+	mu        sync.Mutex
+	limits    RateLimits
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+func newRateLimiter() *rateLimiter {
+	return &rateLimiter{buckets: make(map[string]*bucket), now: time.Now}
+}
+
+// rateLimited describes a refused call: which limit and how long to wait.
+type rateLimited struct {
+	Scope      string
+	RetryAfter time.Duration
+}
+
+// take spends a token from every bucket that applies to a call of tool by
+// session. If any is empty nothing is spent, and the bucket that will take
+// longest to refill is reported. usage holds the tokens left per scope.
+func (rl *rateLimiter) take(session string, tool Tool) (limited *rateLimited, usage logrus.Fields) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	type scope struct {
+		name, field, key string
+		limit            RateLimit
+	}
+	var scopes []scope
+	add := func(name, field, key string, limit RateLimit) {
+		if limit.enabled() {
+			scopes = append(scopes, scope{name, field, key, limit})
+		}
+	}
+	add("global", "globalLimit", "global", rl.limits.Global)
+	add("session", "sessionLimit", "session:"+session, rl.limits.Session)
+	if !tool.IsReadOnly() {
+		add("mutating tools", "mutatingLimit", "mutating:"+session, rl.limits.Mutating)
+	}
+	add("tool "+tool.Name, "toolLimit", "tool:"+tool.Name+":"+session, rl.limits.Tools[tool.Name])
+	if len(scopes) == 0 {
+		return nil, nil
+	}
+
+	now := rl.now()
+	rl.sweep(now)
+
+	buckets := make([]*bucket, len(scopes))
+	for i, s := range scopes {
+		b, ok := rl.buckets[s.key]
+		if !ok {
+			b = &bucket{limit: s.limit, tokens: s.limit.capacity(), last: now}
+			rl.buckets[s.key] = b
+		}
+		b.refill(now)
+		buckets[i] = b
+		if wait := b.wait(); wait > 0 && (limited == nil || wait > limited.RetryAfter) {
+			limited = &rateLimited{Scope: s.name, RetryAfter: wait}
+		}
+	}
+	if limited != nil {
+		return limited, nil
+	}
+
+	usage = make(logrus.Fields, len(scopes))
+	for i, b := range buckets {
+		b.tokens--
+		usage[scopes[i].field] = fmt.Sprintf("%.0f/%.0f left", math.Floor(b.tokens), b.limit.capacity())
+	}
+	return nil, usage
+}
+
+// sweep drops full buckets. rl.mu must be held.
+func (rl *rateLimiter) sweep(now time.Time) {
+	if now.Sub(rl.lastSweep) < sweepInterval {
+		return
+	}
+	rl.lastSweep = now
+	for key, b := range rl.buckets {
+		b.refill(now)
+		if b.tokens >= b.limit.capacity() {
+			delete(rl.buckets, key)
+		}
+	}
+}
+
+// SetRateLimits replaces the limits on tool calls. Every bucket starts
+// over full.
+func (r *Registry) SetRateLimits(limits RateLimits) {
+	rl := r.limiter
+	rl.mu.Lock()
+	rl.limits = limits
+	rl.buckets = make(map[string]*bucket)
+	rl.mu.Unlock()
+
+	describe := func(l RateLimit) string {
+		if !l.enabled() {
+			return "unlimited"
+		}
+		return fmt.Sprintf("%g/min, burst %.0f", l.PerMinute, l.capacity())
+	}
+	fields := logrus.Fields{
+		"global":   describe(limits.Global),
+		"session":  describe(limits.Session),
+		"mutating": describe(limits.Mutating),
+	}
+	names := make([]string, 0, len(limits.Tools))
+	for name := range limits.Tools {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fields["tool."+name] = describe(limits.Tools[name])
+	}
+	r.logger.WithFields(fields).Info("Tool rate limits set")
+}
+
+// rateLimitedResult tells the model which limit it hit and how long to
+// wait, in text and as structured content.
+func rateLimitedResult(tool string, limited *rateLimited) *ToolCallResult {
+	seconds := math.Ceil(limited.RetryAfter.Seconds()*10) / 10
+	return &ToolCallResult{
+		Content: []Content{NewTextContent(fmt.Sprintf(
+			"Rate limit exceeded (%s). Wait %.1f seconds before calling %s again.",
+			limited.Scope, seconds, tool))},
+		StructuredContent: map[string]interface{}{
+			"error":               "rate_limited",
+			"scope":               limited.Scope,
+			"retry_after_seconds": seconds,
+		},
+		IsError: true,
+	}
+}
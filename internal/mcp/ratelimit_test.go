@@ -0,0 +1,144 @@
+package mcp
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func newTestLimiter(limits RateLimits) (*rateLimiter, *time.Time) {
+	rl := newRateLimiter()
+	rl.limits = limits
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	rl.now = func() time.Time { return now }
+	return rl, &now
+}
+
+var (
+	readTool  = Tool{Name: "list_products", Annotations: &ToolAnnotations{ReadOnlyHint: true}}
+	writeTool = Tool{Name: "place_order"}
+)
+
+func TestRateLimitBurstAndRefill(t *testing.T) {
+	rl, now := newTestLimiter(RateLimits{Session: RateLimit{PerMinute: 60, Burst: 2}})
+
+	for i := 0; i < 2; i++ {
+		if limited, _ := rl.take("a", readTool); limited != nil {
+			t.Fatalf("call %d limited within the burst: %+v", i+1, limited)
+		}
+	}
+	limited, _ := rl.take("a", readTool)
+	if limited == nil || limited.Scope != "session" || limited.RetryAfter != time.Second {
+		t.Fatalf("third call = %+v, want the session limit with a 1s wait", limited)
+	}
+
+	*now = now.Add(500 * time.Millisecond)
+	if limited, _ := rl.take("a", readTool); limited == nil || limited.RetryAfter != 500*time.Millisecond {
+		t.Fatalf("call after 0.5s = %+v, want a 0.5s wait", limited)
+	}
+
+	*now = now.Add(500 * time.Millisecond)
+	if limited, _ := rl.take("a", readTool); limited != nil {
+		t.Fatalf("call after refilling one token was limited: %+v", limited)
+	}
+}
+
+func TestRateLimitScopes(t *testing.T) {
+	rl, _ := newTestLimiter(RateLimits{
+		Session:  RateLimit{PerMinute: 60, Burst: 10},
+		Mutating: RateLimit{PerMinute: 1, Burst: 1},
+		Tools:    map[string]RateLimit{"list_products": {PerMinute: 2}},
+	})
+
+	if limited, usage := rl.take("a", writeTool); limited != nil || usage["mutatingLimit"] != "0/1 left" {
+		t.Fatalf("first mutating call = %+v, usage %v", limited, usage)
+	}
+	if limited, _ := rl.take("a", writeTool); limited == nil || limited.Scope != "mutating tools" {
+		t.Errorf("second mutating call = %+v, want the mutating limit", limited)
+	}
+	if limited, _ := rl.take("b", writeTool); limited != nil {
+		t.Errorf("another session's mutating call was limited: %+v", limited)
+	}
+
+	// Read-only tools are not mutating, but have a limit of their own.
+	for i := 0; i < 2; i++ {
+		if limited, _ := rl.take("a", readTool); limited != nil {
+			t.Fatalf("read call %d limited: %+v", i+1, limited)
+		}
+	}
+	if limited, _ := rl.take("a", readTool); limited == nil || limited.Scope != "tool list_products" {
+		t.Errorf("third read call = %+v, want the tool limit", limited)
+	}
+}
+
+func TestRateLimitRefusedCallSpendsNothing(t *testing.T) {
+	rl, _ := newTestLimiter(RateLimits{
+		Global:  RateLimit{PerMinute: 60, Burst: 3},
+		Session: RateLimit{PerMinute: 60, Burst: 1},
+	})
+
+	rl.take("a", readTool)
+	if limited, _ := rl.take("a", readTool); limited == nil || limited.Scope != "session" {
+		t.Fatalf("second call = %+v, want the session limit", limited)
+	}
+	// The refused call did not spend a global token, so two remain.
+	for _, session := range []string{"b", "c"} {
+		if limited, _ := rl.take(session, readTool); limited != nil {
+			t.Errorf("session %s limited: %+v", session, limited)
+		}
+	}
+	if limited, _ := rl.take("d", readTool); limited == nil || limited.Scope != "global" {
+		t.Errorf("fourth session's call = %+v, want the global limit", limited)
+	}
+}
+
+func TestRateLimitSweepDropsFullBuckets(t *testing.T) {
+	rl, now := newTestLimiter(RateLimits{Session: RateLimit{PerMinute: 60}})
+
+	rl.take("a", readTool)
+	*now = now.Add(2 * sweepInterval)
+	rl.take("b", readTool)
+
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	if _, ok := rl.buckets["session:a"]; ok {
+		t.Error("the refilled bucket of session a was kept")
+	}
+	if _, ok := rl.buckets["session:b"]; !ok {
+		t.Error("the bucket of session b was dropped")
+	}
+}
+
+func TestCallToolRateLimited(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	r := NewRegistry(ClientInfo{}, "", logger)
+	if err := r.RegisterTool(writeTool, func(context.Context, map[string]interface{}) (*ToolCallResult, error) {
+		return &ToolCallResult{Content: []Content{NewTextContent("ok")}}, nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	r.SetRateLimits(RateLimits{Session: RateLimit{PerMinute: 1}})
+
+	ctx := ContextWithSessionID(context.Background(), "s1")
+	if result, err := r.CallTool(ctx, writeTool.Name, nil); err != nil || result.IsError {
+		t.Fatalf("first call = %+v, %v", result, err)
+	}
+
+	result, err := r.CallTool(ctx, writeTool.Name, nil)
+	if err != nil {
+		t.Fatalf("second call: %v", err)
+	}
+	structured, _ := result.StructuredContent.(map[string]interface{})
+	if !result.IsError || structured["error"] != "rate_limited" || structured["scope"] != "session" {
+		t.Errorf("second call = %+v, want a rate_limited error result", result)
+	}
+
+	other := ContextWithSessionID(context.Background(), "s2")
+	if result, err := r.CallTool(other, writeTool.Name, nil); err != nil || result.IsError {
+		t.Errorf("another session's call = %+v, %v", result, err)
+	}
+}
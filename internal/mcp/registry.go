@@ -1,23 +1,37 @@
 package mcp
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"maps"
+	"regexp"
+	"sort"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/sirupsen/logrus"
 	"github.com/trenchesdeveloper/mcp-server-store/internal/jsonrpc"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
+var tracer = otel.Tracer("github.com/trenchesdeveloper/mcp-server-store/internal/mcp")
+
 // e.g list Products, get Product by ID, create Product, update Product, delete Product
 // ToolHandler is a function that executes a tool and returns the result.
-type ToolHandler func(arguments map[string]interface{}) (*ToolCallResult, error)
+// The context is cancelled if the server is forced to stop while the tool is running.
+type ToolHandler func(ctx context.Context, arguments map[string]interface{}) (*ToolCallResult, error)
 
 // ResourceHandler is a function that reads a resource and returns its contents.
-type ResourceHandler func(uri string) (*ReadResourceResult, error)
+type ResourceHandler func(ctx context.Context, uri string) (*ReadResourceResult, error)
 
 // PromptHandler is a function that resolves a prompt with the given arguments.
-type PromptHandler func(arguments map[string]string) (*GetPromptResult, error)
+type PromptHandler func(ctx context.Context, arguments map[string]string) (*GetPromptResult, error)
 
 // Registry is the central MCP server that registers tools, resources, and prompts,
 // and wires them up as JSON-RPC method handlers.
@@ -32,9 +46,31 @@ type Registry struct {
 	resources        map[string]Resource
 	resourceHandlers map[string]ResourceHandler
 
+	// templates serve reads of URIs that are not registered resources, in
+	// registration order.
+	templates []resourceTemplate
+
 	prompts        map[string]Prompt
 	promptHandlers map[string]PromptHandler
 
+	// mounts records which entries were added by Mount, keyed by namespace.
+	mounts map[string]*mount
+
+	// policy hides registered tools from tools/list and blocks calls to
+	// them, e.g. in read-only mode. See SetToolPolicy.
+	policy ToolPolicy
+
+	// limiter enforces the rate limits on tool calls. See SetRateLimits.
+	limiter *rateLimiter
+
+	// versions holds the protocol version negotiated with each session
+	// that has initialized, keyed by session ID. See EndSession.
+	versions map[string]string
+
+	// listChanged advertises list_changed notifications for tools,
+	// resources, and prompts, e.g. when entries are mounted at runtime.
+	listChanged bool
+
 	logger *logrus.Logger
 	mu     sync.RWMutex
 }
@@ -50,37 +86,101 @@ func NewRegistry(serverInfo ClientInfo, instructions string, logger *logrus.Logg
 		resourceHandlers: make(map[string]ResourceHandler),
 		prompts:          make(map[string]Prompt),
 		promptHandlers:   make(map[string]PromptHandler),
+		mounts:           make(map[string]*mount),
+		versions:         make(map[string]string),
+		limiter:          newRateLimiter(),
 		logger:           logger,
 	}
 }
 
 // ---- Registration methods ----
 
+// ErrAlreadyRegistered is returned when a tool, resource, or prompt name
+// collides with one that is already registered.
+var ErrAlreadyRegistered = errors.New("already registered")
+
 // RegisterTool adds a tool and its handler to the registry.
-func (r *Registry) RegisterTool(tool Tool, handler ToolHandler) {
+// It returns an error wrapping ErrAlreadyRegistered if the name is taken.
+func (r *Registry) RegisterTool(tool Tool, handler ToolHandler) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
+	if _, exists := r.tools[tool.Name]; exists {
+		return fmt.Errorf("tool %q: %w", tool.Name, ErrAlreadyRegistered)
+	}
 	r.tools[tool.Name] = tool
 	r.toolHandlers[tool.Name] = handler
 	r.logger.WithField("tool", tool.Name).Info("Registered tool")
+	return nil
 }
 
 // RegisterResource adds a resource and its handler to the registry.
-func (r *Registry) RegisterResource(resource Resource, handler ResourceHandler) {
+// It returns an error wrapping ErrAlreadyRegistered if the URI is taken.
+func (r *Registry) RegisterResource(resource Resource, handler ResourceHandler) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
+	if _, exists := r.resources[resource.URI]; exists {
+		return fmt.Errorf("resource %q: %w", resource.URI, ErrAlreadyRegistered)
+	}
 	r.resources[resource.URI] = resource
 	r.resourceHandlers[resource.URI] = handler
 	r.logger.WithField("resource", resource.URI).Info("Registered resource")
+	return nil
+}
+
+// resourceTemplate is a registered ResourceTemplate with its handler.
+type resourceTemplate struct {
+	ResourceTemplate
+	pattern *regexp.Regexp
+	handler ResourceHandler
+}
+
+// templateVariable matches a {variable} in a URI template.
+var templateVariable = regexp.MustCompile(`\{[^{}/]+\}`)
+
+// RegisterResourceTemplate adds a template for resources whose URIs are
+// only known when they are read, such as "store://orders/{id}", and the
+// handler that reads them. Each {variable} matches one path segment. Reads
+// of URIs that are not registered resources go to the first matching
+// template. It returns an error wrapping ErrAlreadyRegistered if the
+// template is taken.
+func (r *Registry) RegisterResourceTemplate(template ResourceTemplate, handler ResourceHandler) error {
+	var pattern strings.Builder
+	pattern.WriteString("^")
+	last := 0
+	for _, loc := range templateVariable.FindAllStringIndex(template.URITemplate, -1) {
+		pattern.WriteString(regexp.QuoteMeta(template.URITemplate[last:loc[0]]))
+		pattern.WriteString("[^/]+")
+		last = loc[1]
+	}
+	pattern.WriteString(regexp.QuoteMeta(template.URITemplate[last:]))
+	pattern.WriteString("$")
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.hasTemplateLocked(template.URITemplate) {
+		return fmt.Errorf("resource template %q: %w", template.URITemplate, ErrAlreadyRegistered)
+	}
+	r.templates = append(r.templates, resourceTemplate{
+		ResourceTemplate: template,
+		pattern:          regexp.MustCompile(pattern.String()),
+		handler:          handler,
+	})
+	r.logger.WithField("template", template.URITemplate).Info("Registered resource template")
+	return nil
 }
 
 // RegisterPrompt adds a prompt and its handler to the registry.
-func (r *Registry) RegisterPrompt(prompt Prompt, handler PromptHandler) {
+// It returns an error wrapping ErrAlreadyRegistered if the name is taken.
+func (r *Registry) RegisterPrompt(prompt Prompt, handler PromptHandler) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
+	if _, exists := r.prompts[prompt.Name]; exists {
+		return fmt.Errorf("prompt %q: %w", prompt.Name, ErrAlreadyRegistered)
+	}
 	r.prompts[prompt.Name] = prompt
 	r.promptHandlers[prompt.Name] = handler
 	r.logger.WithField("prompt", prompt.Name).Info("Registered prompt")
+	return nil
 }
 
 // ---- Wire up to JSON-RPC server ----
@@ -100,6 +200,7 @@ func (r *Registry) RegisterHandlers(server *jsonrpc.Server) {
 
 	if r.capabilities.Resources != nil {
 		server.RegisterMethod(MethodResourcesList, r.handleResourcesList)
+		server.RegisterMethod(MethodResourcesTemplateList, r.handleResourceTemplatesList)
 		server.RegisterMethod(MethodResourcesRead, r.handleResourcesRead)
 	}
 
@@ -114,19 +215,31 @@ func (r *Registry) RegisterHandlers(server *jsonrpc.Server) {
 
 // ---- Capability builder ----
 
+// SetListChanged controls whether the registry advertises list_changed
+// notifications. When enabled, tools, resources, and prompts are always
+// advertised so clients can discover entries that appear later.
+func (r *Registry) SetListChanged(enabled bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.listChanged = enabled
+}
+
 func (r *Registry) buildCapabilities() ServerCapabilities {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
 	caps := ServerCapabilities{
 		Logging: &LoggingCapability{},
 	}
 
-	if len(r.tools) > 0 {
-		caps.Tools = &ToolCapability{ListChanged: false}
+	if len(r.tools) > 0 || r.listChanged {
+		caps.Tools = &ToolCapability{ListChanged: r.listChanged}
 	}
-	if len(r.resources) > 0 {
-		caps.Resources = &ResourceCapability{Subscribe: false, ListChanged: false}
+	if len(r.resources) > 0 || len(r.templates) > 0 || r.listChanged {
+		caps.Resources = &ResourceCapability{Subscribe: false, ListChanged: r.listChanged}
 	}
-	if len(r.prompts) > 0 {
-		caps.Prompts = &PromptCapability{ListChanged: false}
+	if len(r.prompts) > 0 || r.listChanged {
+		caps.Prompts = &PromptCapability{ListChanged: r.listChanged}
 	}
 
 	return caps
@@ -134,7 +247,7 @@ func (r *Registry) buildCapabilities() ServerCapabilities {
 
 // ---- Handler implementations ----
 
-func (r *Registry) handleInitialize(params json.RawMessage) (interface{}, *jsonrpc.Error) {
+func (r *Registry) handleInitialize(ctx context.Context, params json.RawMessage) (interface{}, *jsonrpc.Error) {
 	var req InitializeRequest
 	if err := json.Unmarshal(params, &req); err != nil {
 		return nil, jsonrpc.NewInvalidParamsError("Invalid initialize params", err.Error())
@@ -147,110 +260,107 @@ func (r *Registry) handleInitialize(params json.RawMessage) (interface{}, *jsonr
 	}).Info("Client initializing")
 
 	return &InitializeResult{
-		ProtocolVersion: ProtocolVersion,
+		ProtocolVersion: r.negotiate(ctx, req.ProtocolVersion),
 		Capabilities:    r.capabilities,
 		ServerInfo:      r.serverInfo,
 		Instructions:    r.instructions,
 	}, nil
 }
 
-func (r *Registry) handlePing(_ json.RawMessage) (interface{}, *jsonrpc.Error) {
+// negotiate picks the protocol version for the session ctx belongs to and
+// records it for rendering the session's tool results.
+func (r *Registry) negotiate(ctx context.Context, requested string) string {
+	version := negotiateProtocolVersion(requested)
+	r.mu.Lock()
+	r.versions[SessionIDFromContext(ctx)] = version
+	r.mu.Unlock()
+	return version
+}
+
+// protocolVersion returns the version negotiated with the session ctx
+// belongs to, or the latest one if the session has not initialized.
+func (r *Registry) protocolVersion(ctx context.Context) string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if version, ok := r.versions[SessionIDFromContext(ctx)]; ok {
+		return version
+	}
+	return ProtocolVersion
+}
+
+// EndSession forgets what was negotiated with a session that has closed.
+func (r *Registry) EndSession(id string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.versions, id)
+}
+
+func (r *Registry) handlePing(_ context.Context, _ json.RawMessage) (interface{}, *jsonrpc.Error) {
 	return &PingResult{}, nil
 }
 
-func (r *Registry) handleInitializedNotification(_ json.RawMessage) (interface{}, *jsonrpc.Error) {
+func (r *Registry) handleInitializedNotification(_ context.Context, _ json.RawMessage) (interface{}, *jsonrpc.Error) {
 	r.logger.Info("Client initialized successfully")
 	return nil, nil
 }
 
 // ---- Tool handlers ----
 
-func (r *Registry) handleToolsList(_ json.RawMessage) (interface{}, *jsonrpc.Error) {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
-
-	r.logger.WithField("count", len(r.tools)).Info("Listing tools")
-
-	tools := make([]Tool, 0, len(r.tools))
-	for _, tool := range r.tools {
-		tools = append(tools, tool)
-	}
-
+func (r *Registry) handleToolsList(_ context.Context, _ json.RawMessage) (interface{}, *jsonrpc.Error) {
+	tools := r.Tools()
+	r.logger.WithField("count", len(tools)).Info("Listing tools")
 	return &ToolListResult{Tools: tools}, nil
 }
 
-func (r *Registry) handleToolsCall(params json.RawMessage) (interface{}, *jsonrpc.Error) {
+func (r *Registry) handleToolsCall(ctx context.Context, params json.RawMessage) (interface{}, *jsonrpc.Error) {
 	var req ToolCallParams
 	if err := json.Unmarshal(params, &req); err != nil {
 		r.logger.WithError(err).Error("Failed to parse tool call params")
 		return nil, jsonrpc.NewInvalidParamsError("Invalid tool call params", err.Error())
 	}
 
-	r.logger.WithFields(logrus.Fields{
-		"tool":      req.Name,
-		"arguments": req.Arguments,
-	}).Info("Calling tool")
-
-	r.mu.RLock()
-	handler, ok := r.toolHandlers[req.Name]
-	r.mu.RUnlock()
-
-	if !ok {
-		r.logger.WithField("tool", req.Name).Warn("Tool not found")
+	if req.Meta != nil && req.Meta.ProgressToken != nil {
+		ctx = ContextWithProgressToken(ctx, req.Meta.ProgressToken)
+	}
+	result, err := r.CallTool(ctx, req.Name, req.Arguments)
+	if errors.Is(err, ErrToolBlocked) {
+		return nil, jsonrpc.NewInvalidParamsError(err.Error(), nil)
+	}
+	if err != nil {
 		return nil, jsonrpc.NewInvalidParamsError(
 			fmt.Sprintf("Tool '%s' not found", req.Name), nil,
 		)
 	}
-
-	result, err := handler(req.Arguments)
-	if err != nil {
-		r.logger.WithFields(logrus.Fields{
-			"tool":  req.Name,
-			"error": err.Error(),
-		}).Error("Tool execution failed")
-		// Return the error as a tool result with isError=true, not a JSON-RPC error.
-		return &ToolCallResult{
-			Content: []Content{NewTextContent(err.Error())},
-			IsError: true,
-		}, nil
+	if content, converted := forProtocolVersion(result.Content, r.protocolVersion(ctx)); converted {
+		rendered := *result
+		rendered.Content = content
+		return &rendered, nil
 	}
-
-	r.logger.WithField("tool", req.Name).Info("Tool executed successfully")
-
 	return result, nil
 }
 
 // ---- Resource handlers ----
 
-func (r *Registry) handleResourcesList(_ json.RawMessage) (interface{}, *jsonrpc.Error) {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
-
-	resources := make([]Resource, 0, len(r.resources))
-	for _, res := range r.resources {
-		resources = append(resources, res)
-	}
+func (r *Registry) handleResourcesList(_ context.Context, _ json.RawMessage) (interface{}, *jsonrpc.Error) {
+	return &ListResourcesResult{Resources: r.Resources()}, nil
+}
 
-	return &ListResourcesResult{Resources: resources}, nil
+func (r *Registry) handleResourceTemplatesList(_ context.Context, _ json.RawMessage) (interface{}, *jsonrpc.Error) {
+	return &ListResourceTemplatesResult{ResourceTemplates: r.ResourceTemplates()}, nil
 }
 
-func (r *Registry) handleResourcesRead(params json.RawMessage) (interface{}, *jsonrpc.Error) {
+func (r *Registry) handleResourcesRead(ctx context.Context, params json.RawMessage) (interface{}, *jsonrpc.Error) {
 	var req ReadResourceParams
 	if err := json.Unmarshal(params, &req); err != nil {
 		return nil, jsonrpc.NewInvalidParamsError("Invalid resource read params", err.Error())
 	}
 
-	r.mu.RLock()
-	handler, ok := r.resourceHandlers[req.URI]
-	r.mu.RUnlock()
-
-	if !ok {
+	result, err := r.ReadResource(ctx, req.URI)
+	if errors.Is(err, ErrNotFound) {
 		return nil, jsonrpc.NewInvalidParamsError(
 			fmt.Sprintf("Resource '%s' not found", req.URI), nil,
 		)
 	}
-
-	result, err := handler(req.URI)
 	if err != nil {
 		return nil, jsonrpc.NewInternalError("Failed to read resource", err.Error())
 	}
@@ -260,7 +370,77 @@ func (r *Registry) handleResourcesRead(params json.RawMessage) (interface{}, *js
 
 // ---- Prompt handlers ----
 
-func (r *Registry) handlePromptsList(_ json.RawMessage) (interface{}, *jsonrpc.Error) {
+func (r *Registry) handlePromptsList(_ context.Context, _ json.RawMessage) (interface{}, *jsonrpc.Error) {
+	return &ListPromptsResult{Prompts: r.Prompts()}, nil
+}
+
+func (r *Registry) handlePromptsGet(ctx context.Context, params json.RawMessage) (interface{}, *jsonrpc.Error) {
+	var req GetPromptParams
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, jsonrpc.NewInvalidParamsError("Invalid prompt get params", err.Error())
+	}
+
+	result, err := r.GetPrompt(ctx, req.Name, req.Arguments)
+	if errors.Is(err, ErrNotFound) {
+		return nil, jsonrpc.NewInvalidParamsError(
+			fmt.Sprintf("Prompt '%s' not found", req.Name), nil,
+		)
+	}
+	if err != nil {
+		return nil, jsonrpc.NewInternalError("Failed to get prompt", err.Error())
+	}
+
+	return result, nil
+}
+
+// ---- In-process access ----
+
+// ErrNotFound is returned when a tool, resource, or prompt is not registered.
+var ErrNotFound = errors.New("not found")
+
+// Tools returns every enabled tool, sorted by name.
+func (r *Registry) Tools() []Tool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	tools := make([]Tool, 0, len(r.tools))
+	for _, tool := range r.tools {
+		if r.policy.blockReason(tool) == "" {
+			tools = append(tools, tool)
+		}
+	}
+	sort.Slice(tools, func(i, j int) bool { return tools[i].Name < tools[j].Name })
+	return tools
+}
+
+// Resources returns every registered resource, sorted by URI.
+func (r *Registry) Resources() []Resource {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	resources := make([]Resource, 0, len(r.resources))
+	for _, res := range r.resources {
+		resources = append(resources, res)
+	}
+	sort.Slice(resources, func(i, j int) bool { return resources[i].URI < resources[j].URI })
+	return resources
+}
+
+// ResourceTemplates returns the registered resource templates, sorted by
+// URI template.
+func (r *Registry) ResourceTemplates() []ResourceTemplate {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	templates := make([]ResourceTemplate, 0, len(r.templates))
+	for _, t := range r.templates {
+		templates = append(templates, t.ResourceTemplate)
+	}
+	sort.Slice(templates, func(i, j int) bool { return templates[i].URITemplate < templates[j].URITemplate })
+	return templates
+}
+
+// Prompts returns every registered prompt, sorted by name.
+func (r *Registry) Prompts() []Prompt {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
@@ -268,30 +448,227 @@ func (r *Registry) handlePromptsList(_ json.RawMessage) (interface{}, *jsonrpc.E
 	for _, p := range r.prompts {
 		prompts = append(prompts, p)
 	}
-
-	return &ListPromptsResult{Prompts: prompts}, nil
+	sort.Slice(prompts, func(i, j int) bool { return prompts[i].Name < prompts[j].Name })
+	return prompts
 }
 
-func (r *Registry) handlePromptsGet(params json.RawMessage) (interface{}, *jsonrpc.Error) {
-	var req GetPromptParams
-	if err := json.Unmarshal(params, &req); err != nil {
-		return nil, jsonrpc.NewInvalidParamsError("Invalid prompt get params", err.Error())
+// CallTool runs a tool by name. A failing tool, or a call refused by the
+// rate limits, is reported as a result with IsError set; an error is only
+// returned (wrapping ErrNotFound) when no such tool is registered, or
+// wrapping ErrToolBlocked when the tool policy hides it. The call is traced
+// in a span of its own.
+func (r *Registry) CallTool(ctx context.Context, name string, arguments map[string]interface{}) (*ToolCallResult, error) {
+	ctx, span := tracer.Start(ctx, "execute_tool "+name, trace.WithAttributes(
+		attribute.String("gen_ai.operation.name", "execute_tool"),
+		attribute.String("gen_ai.tool.name", name),
+		attribute.String("mcp.session.id", SessionIDFromContext(ctx)),
+	))
+	defer span.End()
+
+	result, err := r.callTool(ctx, name, arguments)
+	switch {
+	case err != nil:
+		span.SetStatus(codes.Error, err.Error())
+	case result.IsError:
+		var text string
+		if len(result.Content) > 0 {
+			text = result.Content[0].Text
+		}
+		span.SetStatus(codes.Error, text)
 	}
+	return result, err
+}
 
+func (r *Registry) callTool(ctx context.Context, name string, arguments map[string]interface{}) (*ToolCallResult, error) {
 	r.mu.RLock()
-	handler, ok := r.promptHandlers[req.Name]
+	handler, ok := r.toolHandlers[name]
+	tool := r.tools[name]
+	blocked := r.policy.blockReason(tool)
 	r.mu.RUnlock()
 
+	r.logger.WithFields(logrus.Fields{
+		"tool":      name,
+		"arguments": redactArguments(tool, arguments),
+	}).Info("Calling tool")
+
 	if !ok {
-		return nil, jsonrpc.NewInvalidParamsError(
-			fmt.Sprintf("Prompt '%s' not found", req.Name), nil,
-		)
+		r.logger.WithField("tool", name).Warn("Tool not found")
+		return nil, fmt.Errorf("tool %q: %w", name, ErrNotFound)
+	}
+	if blocked != "" {
+		r.logger.WithFields(logrus.Fields{
+			"tool":   name,
+			"reason": blocked,
+		}).Warn("Tool call blocked by policy")
+		return nil, fmt.Errorf("tool %q is %w: %s", name, ErrToolBlocked, blocked)
+	}
+
+	session := SessionIDFromContext(ctx)
+	limited, usage := r.limiter.take(session, tool)
+	if limited != nil {
+		r.logger.WithFields(logrus.Fields{
+			"tool":       name,
+			"session":    session,
+			"scope":      limited.Scope,
+			"retryAfter": limited.RetryAfter.Round(time.Millisecond).String(),
+		}).Warn("Tool call rate limited")
+		trace.SpanFromContext(ctx).SetAttributes(attribute.String("mcp.rate_limit.scope", limited.Scope))
+		return rateLimitedResult(name, limited), nil
+	}
+	if usage != nil {
+		r.logger.WithFields(usage).WithFields(logrus.Fields{
+			"tool":    name,
+			"session": session,
+		}).Debug("Rate limit usage")
 	}
 
-	result, err := handler(req.Arguments)
+	result, err := handler(ctx, arguments)
 	if err != nil {
-		return nil, jsonrpc.NewInternalError("Failed to get prompt", err.Error())
+		r.logger.WithFields(logrus.Fields{
+			"tool":  name,
+			"error": err.Error(),
+		}).Error("Tool execution failed")
+		// Return the error as a tool result with isError=true, not a JSON-RPC error.
+		return &ToolCallResult{
+			Content: []Content{NewTextContent(err.Error())},
+			IsError: true,
+		}, nil
 	}
 
+	r.logger.WithField("tool", name).Info("Tool executed successfully")
+
 	return result, nil
 }
+
+// ReadResource reads a registered resource by URI, or one matching a
+// resource template, in a span of its own.
+func (r *Registry) ReadResource(ctx context.Context, uri string) (*ReadResourceResult, error) {
+	ctx, span := tracer.Start(ctx, "read_resource", trace.WithAttributes(
+		attribute.String("mcp.resource.uri", uri),
+		attribute.String("mcp.session.id", SessionIDFromContext(ctx)),
+	))
+	defer span.End()
+
+	r.mu.RLock()
+	handler, ok := r.resourceHandlers[uri]
+	if !ok {
+		for _, t := range r.templates {
+			if t.pattern.MatchString(uri) {
+				handler, ok = t.handler, true
+				break
+			}
+		}
+	}
+	r.mu.RUnlock()
+
+	if !ok {
+		err := fmt.Errorf("resource %q: %w", uri, ErrNotFound)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+	result, err := handler(ctx, uri)
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return result, err
+}
+
+// GetPrompt resolves a registered prompt by name, in a span of its own.
+func (r *Registry) GetPrompt(ctx context.Context, name string, arguments map[string]string) (*GetPromptResult, error) {
+	ctx, span := tracer.Start(ctx, "get_prompt "+name, trace.WithAttributes(
+		attribute.String("mcp.prompt.name", name),
+		attribute.String("mcp.session.id", SessionIDFromContext(ctx)),
+	))
+	defer span.End()
+
+	r.mu.RLock()
+	handler, ok := r.promptHandlers[name]
+	r.mu.RUnlock()
+
+	if !ok {
+		err := fmt.Errorf("prompt %q: %w", name, ErrNotFound)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+	result, err := handler(ctx, arguments)
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return result, err
+}
+
+// redactArguments returns arguments with the values of the tool's
+// sensitive arguments masked, for logging and transcripts. It returns
+// arguments itself if none are sensitive.
+func redactArguments(tool Tool, arguments map[string]interface{}) map[string]interface{} {
+	redacted, _ := redactedArguments(tool, arguments)
+	return redacted
+}
+
+// redactedArguments is redactArguments, also reporting whether anything
+// was masked.
+func redactedArguments(tool Tool, arguments map[string]interface{}) (map[string]interface{}, bool) {
+	var redacted map[string]interface{}
+	for key, prop := range tool.InputSchema.Properties {
+		if _, ok := arguments[key]; !ok || !prop.Sensitive {
+			continue
+		}
+		if redacted == nil {
+			redacted = maps.Clone(arguments)
+		}
+		redacted[key] = "[redacted]"
+	}
+	if redacted == nil {
+		return arguments, false
+	}
+	return redacted, true
+}
+
+// redactingRecorder masks the sensitive arguments of tool calls before
+// passing messages on to the next recorder.
+type redactingRecorder struct {
+	next     jsonrpc.Recorder
+	registry *Registry
+}
+
+func (rr *redactingRecorder) RecordMessage(ctx context.Context, direction jsonrpc.Direction, msg []byte) {
+	if direction == jsonrpc.Inbound {
+		msg = rr.redact(msg)
+	}
+	rr.next.RecordMessage(ctx, direction, msg)
+}
+
+// redact returns msg with the sensitive arguments of a tools/call request
+// masked, or msg itself if there are none.
+func (rr *redactingRecorder) redact(msg []byte) []byte {
+	var req map[string]json.RawMessage
+	if json.Unmarshal(msg, &req) != nil || string(req["method"]) != `"`+MethodToolsCall+`"` {
+		return msg
+	}
+	var params map[string]json.RawMessage
+	var call ToolCallParams
+	if json.Unmarshal(req["params"], &params) != nil || json.Unmarshal(req["params"], &call) != nil {
+		return msg
+	}
+
+	rr.registry.mu.RLock()
+	tool := rr.registry.tools[call.Name]
+	rr.registry.mu.RUnlock()
+
+	redacted, changed := redactedArguments(tool, call.Arguments)
+	if !changed {
+		return msg
+	}
+	var err error
+	if params["arguments"], err = json.Marshal(redacted); err != nil {
+		return msg
+	}
+	if req["params"], err = json.Marshal(params); err != nil {
+		return msg
+	}
+	out, err := json.Marshal(req)
+	if err != nil {
+		return msg
+	}
+	return out
+}
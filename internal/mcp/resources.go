@@ -1,5 +1,7 @@
 package mcp
 
+import "time"
+
 // ---- Resources ----
 
 // Resource represents a known resource that the server can read.
@@ -61,6 +63,45 @@ type ResourceContents struct {
 	MimeType string `json:"mimeType,omitempty"`
 	Text     string `json:"text,omitempty"` // for text resources
 	Blob     string `json:"blob,omitempty"` // for binary resources (base64)
+
+	Annotations *Annotations `json:"annotations,omitempty"`
+}
+
+// NewTextResourceContents creates the contents of a text resource.
+func NewTextResourceContents(uri, mimeType, text string) ResourceContents {
+	return ResourceContents{
+		URI:      uri,
+		MimeType: mimeType,
+		Text:     text,
+	}
+}
+
+// NewBlobResourceContents creates the contents of a binary resource from base64-encoded data.
+func NewBlobResourceContents(uri, mimeType, base64Data string) ResourceContents {
+	return ResourceContents{
+		URI:      uri,
+		MimeType: mimeType,
+		Blob:     base64Data,
+	}
+}
+
+// WithAudience returns a copy of the contents annotated for the given readers.
+func (rc ResourceContents) WithAudience(audience ...Role) ResourceContents {
+	rc.Annotations = rc.Annotations.withAudience(audience)
+	return rc
+}
+
+// WithPriority returns a copy of the contents annotated with a priority
+// between 0 (entirely optional) and 1 (effectively required).
+func (rc ResourceContents) WithPriority(priority float64) ResourceContents {
+	rc.Annotations = rc.Annotations.withPriority(priority)
+	return rc
+}
+
+// WithLastModified returns a copy of the contents annotated with their modification time.
+func (rc ResourceContents) WithLastModified(t time.Time) ResourceContents {
+	rc.Annotations = rc.Annotations.withLastModified(t)
+	return rc
 }
 
 // ---- Resource Subscriptions ----
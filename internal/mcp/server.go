@@ -1,7 +1,9 @@
 package mcp
 
 import (
+	"context"
 	"encoding/json"
+	"sync"
 
 	"github.com/sirupsen/logrus"
 	"github.com/trenchesdeveloper/mcp-server-store/internal/client"
@@ -10,15 +12,20 @@ import (
 
 // Server is the top-level MCP server. It owns the JSON-RPC server and the
 // registry, providing a simple API to register tools/resources/prompts and
-// start serving over stdio.
+// serve them over stdio or any other jsonrpc.Transport.
 type Server struct {
 	rpcServer    *jsonrpc.Server
 	registry     *Registry
 	logger       *logrus.Logger
 	serverInfo   ClientInfo
-	instructions string
+	instructions string // guarded by mu; see SetInstructions
 	capabilities ServerCapabilities
 	httpClient   *client.RestClient
+
+	// handlersOnce guards wiring the MCP methods onto rpcServer.
+	handlersOnce sync.Once
+
+	mu sync.RWMutex
 }
 
 // ServerOption is a functional option for configuring the MCP Server.
@@ -38,6 +45,23 @@ func WithHTTPClient(httpClient *client.RestClient) ServerOption {
 	}
 }
 
+// WithListChanged advertises list_changed notifications, for servers whose
+// tools, resources, or prompts change while a session is open.
+func WithListChanged() ServerOption {
+	return func(s *Server) {
+		s.registry.SetListChanged(true)
+	}
+}
+
+// WithRecorder records every message exchanged with clients, for example
+// into a session transcript. Tool arguments marked Sensitive are redacted
+// before they reach the recorder.
+func WithRecorder(recorder jsonrpc.Recorder) ServerOption {
+	return func(s *Server) {
+		s.rpcServer.SetRecorder(&redactingRecorder{next: recorder, registry: s.registry})
+	}
+}
+
 // NewServer creates a new MCP server with the given name, version, and options.
 func NewServer(name, version string, logger *logrus.Logger, opts ...ServerOption) *Server {
 	serverInfo := ClientInfo{
@@ -62,29 +86,137 @@ func NewServer(name, version string, logger *logrus.Logger, opts ...ServerOption
 // ---- Registration convenience methods ----
 
 // RegisterTool registers a tool with the MCP server.
-func (s *Server) RegisterTool(tool Tool, handler ToolHandler) {
-	s.registry.RegisterTool(tool, handler)
+// It returns an error wrapping ErrAlreadyRegistered if the name is taken.
+func (s *Server) RegisterTool(tool Tool, handler ToolHandler) error {
+	return s.registry.RegisterTool(tool, handler)
 }
 
 // RegisterResource registers a resource with the MCP server.
-func (s *Server) RegisterResource(resource Resource, handler ResourceHandler) {
-	s.registry.RegisterResource(resource, handler)
+func (s *Server) RegisterResource(resource Resource, handler ResourceHandler) error {
+	return s.registry.RegisterResource(resource, handler)
+}
+
+// RegisterResourceTemplate registers a resource template with the MCP
+// server. See Registry.RegisterResourceTemplate.
+func (s *Server) RegisterResourceTemplate(template ResourceTemplate, handler ResourceHandler) error {
+	return s.registry.RegisterResourceTemplate(template, handler)
 }
 
 // RegisterPrompt registers a prompt with the MCP server.
-func (s *Server) RegisterPrompt(prompt Prompt, handler PromptHandler) {
-	s.registry.RegisterPrompt(prompt, handler)
+func (s *Server) RegisterPrompt(prompt Prompt, handler PromptHandler) error {
+	return s.registry.RegisterPrompt(prompt, handler)
 }
 
-// ListTools returns all registered tools.
-func (s *Server) ListTools() []Tool {
-	s.registry.mu.RLock()
-	defer s.registry.mu.RUnlock()
-	tools := make([]Tool, 0, len(s.registry.tools))
-	for _, tool := range s.registry.tools {
-		tools = append(tools, tool)
+// Mount adds the tools, resources, and prompts of a separately built
+// registry under namespace, e.g. Mount("store.products", catalog) exposes
+// catalog's "list" tool as "store.products.list". See Registry.Mount.
+func (s *Server) Mount(namespace string, child *Registry) error {
+	return s.registry.Mount(namespace, child)
+}
+
+// Remount replaces everything previously mounted under namespace with the
+// current contents of child. See Registry.Remount.
+func (s *Server) Remount(namespace string, child *Registry) error {
+	return s.registry.Remount(namespace, child)
+}
+
+// Unmount removes everything previously mounted under namespace.
+func (s *Server) Unmount(namespace string) {
+	s.registry.Unmount(namespace)
+}
+
+// SetToolPolicy replaces the policy deciding which tools are listed and
+// callable, and notifies connected clients if the tool list changed. See
+// Registry.SetToolPolicy.
+func (s *Server) SetToolPolicy(policy ToolPolicy) error {
+	changed, err := s.registry.SetToolPolicy(policy)
+	if err != nil {
+		return err
+	}
+	if changed {
+		if err := s.NotifyToolsListChanged(); err != nil {
+			s.logger.WithError(err).Warn("Failed to notify clients of tool list change")
+		}
 	}
-	return tools
+	return nil
+}
+
+// SetRateLimits replaces the limits on tool calls. See Registry.SetRateLimits.
+func (s *Server) SetRateLimits(limits RateLimits) {
+	s.registry.SetRateLimits(limits)
+}
+
+// SetInstructions replaces the instructions returned during initialize.
+// Sessions that are already initialized keep the instructions they got.
+func (s *Server) SetInstructions(instructions string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.instructions = instructions
+}
+
+// EndSession forgets what was negotiated with a session that has closed,
+// such as its protocol version. Transports serving several sessions call it.
+func (s *Server) EndSession(id string) {
+	s.registry.EndSession(id)
+}
+
+// ListTools returns all enabled tools, sorted by name.
+func (s *Server) ListTools() []Tool {
+	return s.registry.Tools()
+}
+
+// ListResources returns all registered resources, sorted by URI.
+func (s *Server) ListResources() []Resource {
+	return s.registry.Resources()
+}
+
+// ListPrompts returns all registered prompts, sorted by name.
+func (s *Server) ListPrompts() []Prompt {
+	return s.registry.Prompts()
+}
+
+// CallTool runs a registered tool in-process, without going through
+// JSON-RPC. See Registry.CallTool.
+func (s *Server) CallTool(ctx context.Context, name string, arguments map[string]interface{}) (*ToolCallResult, error) {
+	return s.registry.CallTool(ctx, name, arguments)
+}
+
+// ReadResource reads a registered resource in-process.
+func (s *Server) ReadResource(ctx context.Context, uri string) (*ReadResourceResult, error) {
+	return s.registry.ReadResource(ctx, uri)
+}
+
+// GetPrompt resolves a registered prompt in-process.
+func (s *Server) GetPrompt(ctx context.Context, name string, arguments map[string]string) (*GetPromptResult, error) {
+	return s.registry.GetPrompt(ctx, name, arguments)
+}
+
+// ---- Notifications ----
+
+// Notify sends a notification to every connected client.
+func (s *Server) Notify(method string, params interface{}) error {
+	return s.rpcServer.Notify(method, params)
+}
+
+// NotifyCaller sends a notification to the client that made the request
+// ctx belongs to. See jsonrpc.Server.NotifyCaller.
+func (s *Server) NotifyCaller(ctx context.Context, method string, params interface{}) error {
+	return s.rpcServer.NotifyCaller(ctx, method, params)
+}
+
+// NotifyToolsListChanged tells connected clients to re-fetch "tools/list".
+func (s *Server) NotifyToolsListChanged() error {
+	return s.Notify(NotificationToolsListChanged, nil)
+}
+
+// NotifyResourcesListChanged tells connected clients to re-fetch "resources/list".
+func (s *Server) NotifyResourcesListChanged() error {
+	return s.Notify(NotificationResourcesListChanged, nil)
+}
+
+// NotifyPromptsListChanged tells connected clients to re-fetch "prompts/list".
+func (s *Server) NotifyPromptsListChanged() error {
+	return s.Notify(NotificationPromptsListChanged, nil)
 }
 
 // ---- Handler registration ----
@@ -107,6 +239,7 @@ func (s *Server) registerHandlers() {
 	// Resource methods
 	if s.capabilities.Resources != nil {
 		s.rpcServer.RegisterMethod(MethodResourcesList, s.registry.handleResourcesList)
+		s.rpcServer.RegisterMethod(MethodResourcesTemplateList, s.registry.handleResourceTemplatesList)
 		s.rpcServer.RegisterMethod(MethodResourcesRead, s.registry.handleResourcesRead)
 	}
 
@@ -127,7 +260,7 @@ func (s *Server) registerHandlers() {
 
 // handleInitialize handles the "initialize" request from the client.
 // It returns the server info, capabilities, protocol version, and instructions.
-func (s *Server) handleInitialize(params json.RawMessage) (interface{}, *jsonrpc.Error) {
+func (s *Server) handleInitialize(ctx context.Context, params json.RawMessage) (interface{}, *jsonrpc.Error) {
 	var req InitializeRequest
 	if err := json.Unmarshal(params, &req); err != nil {
 		return nil, jsonrpc.NewInvalidParamsError("Invalid initialize params", err.Error())
@@ -139,27 +272,31 @@ func (s *Server) handleInitialize(params json.RawMessage) (interface{}, *jsonrpc
 		"protocolVersion": req.ProtocolVersion,
 	}).Info("Client initializing")
 
+	s.mu.RLock()
+	instructions := s.instructions
+	s.mu.RUnlock()
+
 	return &InitializeResult{
-		ProtocolVersion: ProtocolVersion,
+		ProtocolVersion: s.registry.negotiate(ctx, req.ProtocolVersion),
 		Capabilities:    s.capabilities,
 		ServerInfo:      s.serverInfo,
-		Instructions:    s.instructions,
+		Instructions:    instructions,
 	}, nil
 }
 
 // handlePing handles the "ping" request.
-func (s *Server) handlePing(_ json.RawMessage) (interface{}, *jsonrpc.Error) {
+func (s *Server) handlePing(_ context.Context, _ json.RawMessage) (interface{}, *jsonrpc.Error) {
 	return &PingResult{}, nil
 }
 
 // handleInitializedNotification handles the "notifications/initialized" notification.
-func (s *Server) handleInitializedNotification(_ json.RawMessage) (interface{}, *jsonrpc.Error) {
+func (s *Server) handleInitializedNotification(_ context.Context, _ json.RawMessage) (interface{}, *jsonrpc.Error) {
 	s.logger.Info("Client initialized successfully")
 	return nil, nil
 }
 
 // handleSetLogLevel handles the "logging/setLevel" request from the client.
-func (s *Server) handleSetLogLevel(params json.RawMessage) (interface{}, *jsonrpc.Error) {
+func (s *Server) handleSetLogLevel(_ context.Context, params json.RawMessage) (interface{}, *jsonrpc.Error) {
 	var req SetLevelParams
 	if err := json.Unmarshal(params, &req); err != nil {
 		return nil, jsonrpc.NewInvalidParamsError("Invalid logging params", err.Error())
@@ -192,7 +329,8 @@ func (s *Server) handleSetLogLevel(params json.RawMessage) (interface{}, *jsonrp
 
 // ServeStdio wires up all registered MCP handlers to the JSON-RPC server
 // and starts reading from stdin / writing to stdout.
-// This method blocks until stdin is closed (EOF) or an error occurs.
+// This method blocks until stdin is closed (EOF), an error occurs, or
+// Shutdown is called, in which case jsonrpc.ErrServerClosed is returned.
 func (s *Server) ServeStdio() error {
 	s.logger.WithFields(logrus.Fields{
 		"server":  s.serverInfo.Name,
@@ -200,12 +338,51 @@ func (s *Server) ServeStdio() error {
 	}).Info("Starting MCP server over stdio")
 
 	// Wire up all MCP protocol methods to the JSON-RPC server.
-	s.registerHandlers()
+	s.handlersOnce.Do(s.registerHandlers)
 
 	// Start the stdio read loop.
 	return s.rpcServer.ServeStdio()
 }
 
+// Serve serves the MCP protocol over t, for example one side of
+// jsonrpc.NewPipeTransport when the server is embedded in another program.
+// It blocks until t reaches EOF, an error occurs, or Shutdown is called,
+// and may be called for several transports at once. Serve does not close t.
+func (s *Server) Serve(t jsonrpc.Transport) error {
+	s.logger.WithFields(logrus.Fields{
+		"server":  s.serverInfo.Name,
+		"version": s.serverInfo.Version,
+	}).Info("Starting MCP server")
+
+	s.handlersOnce.Do(s.registerHandlers)
+	return s.rpcServer.Serve(t)
+}
+
+// HandleMessage handles one encoded JSON-RPC message in-process and returns
+// the encoded response, or nil for notifications. Transcript replay uses it
+// to feed recorded requests back into the server.
+func (s *Server) HandleMessage(ctx context.Context, msg []byte) []byte {
+	s.handlersOnce.Do(s.registerHandlers)
+	return s.rpcServer.HandleMessage(ctx, msg)
+}
+
+// ServeMessage handles one encoded JSON-RPC message received outside a
+// stream, such as an HTTP request body, and returns the encoded response or
+// nil for notifications. See jsonrpc.Server.ServeMessage.
+func (s *Server) ServeMessage(ctx context.Context, msg []byte) []byte {
+	s.handlersOnce.Do(s.registerHandlers)
+	return s.rpcServer.ServeMessage(ctx, msg)
+}
+
 func (s *Server) Start() error {
 	return s.ServeStdio()
 }
+
+// Shutdown gracefully stops the server. New requests are rejected, and
+// in-flight tool calls are given until ctx expires to finish and have their
+// responses written. If the deadline is reached, in-flight calls are
+// cancelled and ctx.Err() is returned.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.logger.WithField("server", s.serverInfo.Name).Info("Shutting down MCP server")
+	return s.rpcServer.Shutdown(ctx)
+}
@@ -0,0 +1,84 @@
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/trenchesdeveloper/mcp-server-store/internal/jsonrpc"
+)
+
+// An mcp.Server served over an in-process pipe speaks the whole protocol,
+// from initialize to tool calls, without a subprocess.
+func TestServeOverPipe(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	server := NewServer("test", "1.0.0", logger)
+	err := server.RegisterTool(Tool{Name: "greet", InputSchema: InputSchema{Type: "object"}},
+		func(_ context.Context, arguments map[string]interface{}) (*ToolCallResult, error) {
+			name, _ := arguments["name"].(string)
+			return &ToolCallResult{Content: []Content{NewTextContent("hello " + name)}}, nil
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clientSide, serverSide := jsonrpc.NewPipeTransport()
+	served := make(chan error, 1)
+	go func() {
+		served <- server.Serve(serverSide)
+	}()
+	responses := bufio.NewReader(clientSide)
+
+	call := func(request string, result interface{}) {
+		t.Helper()
+		if _, err := io.WriteString(clientSide, request+"\n"); err != nil {
+			t.Fatal(err)
+		}
+		if result == nil {
+			return
+		}
+		line, err := responses.ReadBytes('\n')
+		if err != nil {
+			t.Fatal(err)
+		}
+		var resp struct {
+			Result json.RawMessage `json:"result"`
+			Error  *jsonrpc.Error  `json:"error"`
+		}
+		if err := json.Unmarshal(line, &resp); err != nil || resp.Error != nil {
+			t.Fatalf("%s answered %s", request, line)
+		}
+		if err := json.Unmarshal(resp.Result, result); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var initialized InitializeResult
+	call(`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-06-18","capabilities":{},"clientInfo":{"name":"test","version":"1"}}}`, &initialized)
+	if initialized.ServerInfo.Name != "test" {
+		t.Errorf("server info = %+v, want test", initialized.ServerInfo)
+	}
+	call(`{"jsonrpc":"2.0","method":"notifications/initialized"}`, nil)
+
+	var listed ToolListResult
+	call(`{"jsonrpc":"2.0","id":2,"method":"tools/list"}`, &listed)
+	if len(listed.Tools) != 1 || listed.Tools[0].Name != "greet" {
+		t.Errorf("tools = %+v, want greet", listed.Tools)
+	}
+
+	var result ToolCallResult
+	call(`{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"greet","arguments":{"name":"alice"}}}`, &result)
+	if len(result.Content) != 1 || !strings.Contains(result.Content[0].Text, "hello alice") {
+		t.Errorf("greet = %+v, want hello alice", result.Content)
+	}
+
+	clientSide.Close()
+	if err := <-served; err != nil {
+		t.Errorf("Serve = %v, want nil once the client closes", err)
+	}
+}
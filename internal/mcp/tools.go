@@ -1,5 +1,10 @@
 package mcp
 
+import (
+	"context"
+	"encoding/json"
+)
+
 // ---- Tools ----
 
 // Tool describes an MCP tool the server exposes.
@@ -7,6 +12,61 @@ type Tool struct {
 	Name        string      `json:"name"`
 	Description string      `json:"description,omitempty"`
 	InputSchema InputSchema `json:"inputSchema"`
+
+	// Annotations describe how the tool behaves, e.g. whether it changes
+	// state. A tool without them is assumed to modify state.
+	Annotations *ToolAnnotations `json:"annotations,omitempty"`
+
+	// RawInputSchema, when set, is sent instead of InputSchema. It keeps
+	// schemas received from other servers intact, including keywords
+	// InputSchema does not model.
+	RawInputSchema json.RawMessage `json:"-"`
+}
+
+// MarshalJSON sends RawInputSchema in place of InputSchema when present.
+func (t Tool) MarshalJSON() ([]byte, error) {
+	type plain Tool
+	if len(t.RawInputSchema) == 0 {
+		return json.Marshal(plain(t))
+	}
+	return json.Marshal(struct {
+		plain
+		InputSchema json.RawMessage `json:"inputSchema"`
+	}{plain(t), t.RawInputSchema})
+}
+
+// UnmarshalJSON decodes a tool and keeps a verbatim copy of its input schema.
+func (t *Tool) UnmarshalJSON(data []byte) error {
+	type plain Tool
+	var decoded struct {
+		plain
+		InputSchema json.RawMessage `json:"inputSchema"`
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	*t = Tool(decoded.plain)
+	if len(decoded.InputSchema) > 0 {
+		t.RawInputSchema = decoded.InputSchema
+		// Best effort: the typed view is informational only.
+		_ = json.Unmarshal(decoded.InputSchema, &t.InputSchema)
+	}
+	return nil
+}
+
+// ToolAnnotations are hints about a tool's behaviour. Clients must not rely
+// on them for security, but the server uses ReadOnlyHint for read-only mode.
+type ToolAnnotations struct {
+	Title           string `json:"title,omitempty"`
+	ReadOnlyHint    bool   `json:"readOnlyHint,omitempty"`
+	DestructiveHint *bool  `json:"destructiveHint,omitempty"` // defaults to true
+	IdempotentHint  bool   `json:"idempotentHint,omitempty"`
+	OpenWorldHint   *bool  `json:"openWorldHint,omitempty"` // defaults to true
+}
+
+// IsReadOnly reports whether the tool is annotated as not modifying state.
+func (t Tool) IsReadOnly() bool {
+	return t.Annotations != nil && t.Annotations.ReadOnlyHint
 }
 
 type InputSchema struct {
@@ -18,6 +78,9 @@ type InputSchema struct {
 type Property struct {
 	Type        string `json:"type"`
 	Description string `json:"description,omitempty"`
+
+	// Sensitive keeps the argument's value, such as a password, out of the logs.
+	Sensitive bool `json:"-"`
 }
 
 // ToolListParams are sent by the client in a "tools/list" request.
@@ -35,10 +98,36 @@ type ToolListResult struct {
 type ToolCallParams struct {
 	Name      string                 `json:"name"`
 	Arguments map[string]interface{} `json:"arguments,omitempty"`
+	Meta      *RequestMeta           `json:"_meta,omitempty"`
+}
+
+// RequestMeta is the "_meta" object a client may add to request params.
+type RequestMeta struct {
+	// ProgressToken asks the server to report progress on the request in
+	// "notifications/progress" tagged with this token.
+	ProgressToken interface{} `json:"progressToken,omitempty"`
+}
+
+type progressTokenKey struct{}
+
+// ContextWithProgressToken returns a copy of ctx for a call whose client
+// asked for progress tagged with token.
+func ContextWithProgressToken(ctx context.Context, token interface{}) context.Context {
+	return context.WithValue(ctx, progressTokenKey{}, token)
+}
+
+// ProgressTokenFromContext returns the progress token of the tool call ctx
+// belongs to, and false if the client did not ask for progress.
+func ProgressTokenFromContext(ctx context.Context) (interface{}, bool) {
+	token := ctx.Value(progressTokenKey{})
+	return token, token != nil
 }
 
 // ToolCallResult is returned by the server after executing a tool.
 type ToolCallResult struct {
 	Content []Content `json:"content"`
-	IsError bool      `json:"isError,omitempty"`
+	// StructuredContent is an optional JSON value mirroring Content for
+	// clients that parse results.
+	StructuredContent interface{} `json:"structuredContent,omitempty"`
+	IsError           bool        `json:"isError,omitempty"`
 }
@@ -1,7 +1,30 @@
 package mcp
 
 // MCP Protocol version
-const ProtocolVersion = "2024-11-05"
+const ProtocolVersion = "2025-06-18"
+
+// SupportedProtocolVersions lists every protocol version the server can speak,
+// newest first. Audio and resource_link content require 2025-03-26 and
+// 2025-06-18 respectively; tool results for sessions on older versions
+// carry them as text instead (see forProtocolVersion).
+var SupportedProtocolVersions = []string{ProtocolVersion, "2025-03-26", "2024-11-05"}
+
+// Protocol versions that introduced content types.
+const (
+	audioSince        = "2025-03-26"
+	resourceLinkSince = "2025-06-18"
+)
+
+// negotiateProtocolVersion returns the client's requested version if it is
+// supported, or the latest version otherwise.
+func negotiateProtocolVersion(requested string) string {
+	for _, v := range SupportedProtocolVersions {
+		if v == requested {
+			return v
+		}
+	}
+	return ProtocolVersion
+}
 
 // ---- Capability types ----
 
@@ -80,39 +103,3 @@ type PaginatedRequest struct {
 type PaginatedResult struct {
 	NextCursor *Cursor `json:"nextCursor,omitempty"`
 }
-
-// ---- Content types ----
-
-// Content represents a content block in an MCP response.
-type Content struct {
-	Type     string `json:"type"`               // "text", "image", "resource"
-	Text     string `json:"text,omitempty"`     // for type "text"
-	MimeType string `json:"mimeType,omitempty"` // for type "image"
-	Data     string `json:"data,omitempty"`     // for type "image" (base64)
-	URI      string `json:"uri,omitempty"`      // for type "resource"
-}
-
-// NewTextContent creates a text content block.
-func NewTextContent(text string) Content {
-	return Content{
-		Type: "text",
-		Text: text,
-	}
-}
-
-// NewImageContent creates an image content block with base64-encoded data.
-func NewImageContent(mimeType, base64Data string) Content {
-	return Content{
-		Type:     "image",
-		MimeType: mimeType,
-		Data:     base64Data,
-	}
-}
-
-// NewErrorContent creates a text content block marked as an error.
-func NewErrorContent(text string) (Content, bool) {
-	return Content{
-		Type: "text",
-		Text: text,
-	}, true
-}
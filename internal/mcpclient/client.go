@@ -0,0 +1,438 @@
+// Package mcpclient is a Go client for MCP servers. It can launch a server
+// as a subprocess and talk to it over stdio, connect to an HTTP endpoint,
+// speak over any pair of streams, or run an mcp.Server in the same process.
+package mcpclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os/exec"
+	"strconv"
+	"sync"
+	"sync/atomic"
+
+	"github.com/sirupsen/logrus"
+	"github.com/trenchesdeveloper/mcp-server-store/internal/jsonrpc"
+	"github.com/trenchesdeveloper/mcp-server-store/internal/mcp"
+)
+
+// ErrClosed is returned for calls on a client whose connection has gone away.
+var ErrClosed = errors.New("mcpclient: connection closed")
+
+// NotificationHandler receives a notification sent by the server.
+// Handlers run on the client's read loop and must not block on calls to
+// the same client; start a goroutine for that.
+type NotificationHandler func(method string, params json.RawMessage)
+
+// message is any JSON-RPC message exchanged with the server:
+// a request, a notification, or a response.
+type message struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *jsonrpc.Error  `json:"error,omitempty"`
+}
+
+// Client is a connection to one MCP server.
+type Client struct {
+	transport  transport
+	logger     *logrus.Entry
+	clientInfo mcp.ClientInfo
+
+	// HTTP settings, only used by NewHTTPClient.
+	httpClient *http.Client
+	headers    map[string]string
+
+	nextID  atomic.Int64
+	mu      sync.Mutex
+	pending map[string]chan *message
+	closed  bool
+
+	handlersMu  sync.RWMutex
+	handlers    map[string][]NotificationHandler
+	anyHandlers []NotificationHandler
+
+	initResult *mcp.InitializeResult
+}
+
+// Option is a functional option for configuring a Client.
+type Option func(*Client)
+
+// WithLogger sets the logger used for client diagnostics.
+func WithLogger(logger *logrus.Logger) Option {
+	return func(c *Client) {
+		c.logger = logrus.NewEntry(logger)
+	}
+}
+
+// WithClientInfo sets the name and version sent during initialize.
+func WithClientInfo(name, version string) Option {
+	return func(c *Client) {
+		c.clientInfo = mcp.ClientInfo{Name: name, Version: version}
+	}
+}
+
+// WithHeader adds a header to every request of an HTTP client.
+func WithHeader(key, value string) Option {
+	return func(c *Client) {
+		c.headers[key] = value
+	}
+}
+
+// WithHTTPClient sets the *http.Client used by an HTTP client.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+func newClient(opts []Option) *Client {
+	discard := logrus.New()
+	discard.SetOutput(io.Discard)
+
+	c := &Client{
+		logger:     logrus.NewEntry(discard),
+		clientInfo: mcp.ClientInfo{Name: "mcp-server-store-client", Version: "0.1.0"},
+		httpClient: &http.Client{},
+		headers:    make(map[string]string),
+		pending:    make(map[string]chan *message),
+		handlers:   make(map[string][]NotificationHandler),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// NewClient creates a client that reads newline-delimited messages from r
+// and writes them to w. If w is an io.Closer it is closed by Close.
+func NewClient(r io.Reader, w io.Writer, opts ...Option) *Client {
+	c := newClient(opts)
+	c.transport = newStreamTransport(r, w, c, nil)
+	return c
+}
+
+// NewInProcessClient serves server over an in-process pipe and returns a
+// client connected to it. Closing the client ends the server session.
+func NewInProcessClient(server *mcp.Server, opts ...Option) *Client {
+	clientSide, serverSide := jsonrpc.NewPipeTransport()
+	c := NewClient(clientSide, clientSide, opts...)
+	go func() {
+		if err := server.Serve(serverSide); err != nil && !errors.Is(err, jsonrpc.ErrServerClosed) {
+			c.logger.WithError(err).Warn("In-process server stopped")
+		}
+		serverSide.Close()
+	}()
+	return c
+}
+
+// NewStdioClient starts cmd and talks to it over its stdin and stdout.
+// The command's stderr is left as configured by the caller. Close ends
+// the process.
+func NewStdioClient(cmd *exec.Cmd, opts ...Option) (*Client, error) {
+	c := newClient(opts)
+	t, err := startCommand(cmd, c)
+	if err != nil {
+		return nil, err
+	}
+	c.transport = t
+	return c, nil
+}
+
+// NewHTTPClient creates a client that posts messages to an MCP HTTP endpoint.
+func NewHTTPClient(url string, opts ...Option) *Client {
+	c := newClient(opts)
+	c.transport = newHTTPTransport(url, c)
+	return c
+}
+
+// ---- Notifications ----
+
+// OnNotification registers a handler for notifications with the given method.
+func (c *Client) OnNotification(method string, handler NotificationHandler) {
+	c.handlersMu.Lock()
+	defer c.handlersMu.Unlock()
+	c.handlers[method] = append(c.handlers[method], handler)
+}
+
+// OnAnyNotification registers a handler for every notification.
+func (c *Client) OnAnyNotification(handler NotificationHandler) {
+	c.handlersMu.Lock()
+	defer c.handlersMu.Unlock()
+	c.anyHandlers = append(c.anyHandlers, handler)
+}
+
+// ---- MCP methods ----
+
+// Initialize performs the MCP handshake and returns the server's
+// capabilities. It must be called before any other MCP method.
+func (c *Client) Initialize(ctx context.Context) (*mcp.InitializeResult, error) {
+	req := &mcp.InitializeRequest{
+		ProtocolVersion: mcp.ProtocolVersion,
+		ClientInfo:      c.clientInfo,
+	}
+	var result mcp.InitializeResult
+	if err := c.Call(ctx, mcp.MethodInitialize, req, &result); err != nil {
+		return nil, fmt.Errorf("initialize: %w", err)
+	}
+	if err := c.Notify(ctx, mcp.NotificationInitialized, nil); err != nil {
+		return nil, fmt.Errorf("initialized notification: %w", err)
+	}
+	if l, ok := c.transport.(listener); ok {
+		l.listen()
+	}
+
+	c.mu.Lock()
+	c.initResult = &result
+	c.mu.Unlock()
+	return &result, nil
+}
+
+// InitializeResult returns the result of Initialize, or nil before it succeeded.
+func (c *Client) InitializeResult() *mcp.InitializeResult {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.initResult
+}
+
+// Ping checks that the server is responsive.
+func (c *Client) Ping(ctx context.Context) error {
+	return c.Call(ctx, mcp.MethodPing, struct{}{}, nil)
+}
+
+// ListTools returns every tool the server offers, following pagination.
+func (c *Client) ListTools(ctx context.Context) ([]mcp.Tool, error) {
+	return listAll(ctx, c, mcp.MethodToolsList, func(r *mcp.ToolListResult) ([]mcp.Tool, *mcp.Cursor) {
+		return r.Tools, r.NextCursor
+	})
+}
+
+// CallTool calls a tool by name. A tool that fails reports it through
+// ToolCallResult.IsError rather than a returned error.
+func (c *Client) CallTool(ctx context.Context, name string, arguments map[string]interface{}) (*mcp.ToolCallResult, error) {
+	return c.callTool(ctx, &mcp.ToolCallParams{Name: name, Arguments: arguments})
+}
+
+// CallToolWithProgress calls a tool and asks the server to report progress
+// in "notifications/progress" tagged with token.
+func (c *Client) CallToolWithProgress(ctx context.Context, name string, arguments map[string]interface{}, token interface{}) (*mcp.ToolCallResult, error) {
+	return c.callTool(ctx, &mcp.ToolCallParams{
+		Name:      name,
+		Arguments: arguments,
+		Meta:      &mcp.RequestMeta{ProgressToken: token},
+	})
+}
+
+func (c *Client) callTool(ctx context.Context, params *mcp.ToolCallParams) (*mcp.ToolCallResult, error) {
+	var result mcp.ToolCallResult
+	if err := c.Call(ctx, mcp.MethodToolsCall, params, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// ListResources returns every resource the server offers, following pagination.
+func (c *Client) ListResources(ctx context.Context) ([]mcp.Resource, error) {
+	return listAll(ctx, c, mcp.MethodResourcesList, func(r *mcp.ListResourcesResult) ([]mcp.Resource, *mcp.Cursor) {
+		return r.Resources, r.NextCursor
+	})
+}
+
+// ReadResource reads a resource by URI.
+func (c *Client) ReadResource(ctx context.Context, uri string) (*mcp.ReadResourceResult, error) {
+	var result mcp.ReadResourceResult
+	if err := c.Call(ctx, mcp.MethodResourcesRead, &mcp.ReadResourceParams{URI: uri}, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// ListPrompts returns every prompt the server offers, following pagination.
+func (c *Client) ListPrompts(ctx context.Context) ([]mcp.Prompt, error) {
+	return listAll(ctx, c, mcp.MethodPromptsList, func(r *mcp.ListPromptsResult) ([]mcp.Prompt, *mcp.Cursor) {
+		return r.Prompts, r.NextCursor
+	})
+}
+
+// GetPrompt resolves a prompt with the given arguments.
+func (c *Client) GetPrompt(ctx context.Context, name string, arguments map[string]string) (*mcp.GetPromptResult, error) {
+	var result mcp.GetPromptResult
+	if err := c.Call(ctx, mcp.MethodPromptsGet, &mcp.GetPromptParams{Name: name, Arguments: arguments}, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// SetLogLevel asks the server to change the level of the logs it sends.
+func (c *Client) SetLogLevel(ctx context.Context, level mcp.LoggingLevel) error {
+	return c.Call(ctx, mcp.MethodLoggingSetLevel, &mcp.SetLevelParams{Level: level}, nil)
+}
+
+// listAll follows pagination cursors until the server has no more pages.
+func listAll[R any, T any](ctx context.Context, c *Client, method string, page func(*R) ([]T, *mcp.Cursor)) ([]T, error) {
+	var all []T
+	var params mcp.PaginatedRequest
+	for {
+		var result R
+		if err := c.Call(ctx, method, &params, &result); err != nil {
+			return nil, fmt.Errorf("%s: %w", method, err)
+		}
+		items, next := page(&result)
+		all = append(all, items...)
+		if next == nil || *next == "" {
+			return all, nil
+		}
+		params.Cursor = next
+	}
+}
+
+// ---- JSON-RPC ----
+
+// Call sends a request and decodes the result into result (if non-nil).
+// A JSON-RPC error from the server is returned as a *jsonrpc.Error. If ctx
+// is cancelled first, the server is told to cancel the request.
+func (c *Client) Call(ctx context.Context, method string, params, result interface{}) error {
+	id := c.nextID.Add(1)
+	rawID := json.RawMessage(strconv.FormatInt(id, 10))
+
+	rawParams, err := json.Marshal(params)
+	if err != nil {
+		return fmt.Errorf("marshal %s params: %w", method, err)
+	}
+	data, err := json.Marshal(&message{JSONRPC: "2.0", ID: rawID, Method: method, Params: rawParams})
+	if err != nil {
+		return fmt.Errorf("marshal %s request: %w", method, err)
+	}
+
+	ch := make(chan *message, 1)
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return ErrClosed
+	}
+	c.pending[string(rawID)] = ch
+	c.mu.Unlock()
+
+	defer func() {
+		c.mu.Lock()
+		delete(c.pending, string(rawID))
+		c.mu.Unlock()
+	}()
+
+	c.logger.WithFields(logrus.Fields{"method": method, "id": id}).Debug("Sending request")
+
+	if err := c.transport.send(ctx, data); err != nil {
+		return fmt.Errorf("send %s: %w", method, err)
+	}
+
+	select {
+	case resp, ok := <-ch:
+		if !ok {
+			return ErrClosed
+		}
+		if resp.Error != nil {
+			return resp.Error
+		}
+		if result != nil && len(resp.Result) > 0 {
+			if err := json.Unmarshal(resp.Result, result); err != nil {
+				return fmt.Errorf("decode %s result: %w", method, err)
+			}
+		}
+		return nil
+	case <-ctx.Done():
+		// Best effort: the server may already have finished.
+		_ = c.Notify(context.Background(), mcp.NotificationCancelled, &mcp.CancelledNotification{
+			RequestID: id,
+			Reason:    ctx.Err().Error(),
+		})
+		return ctx.Err()
+	}
+}
+
+// Notify sends a notification to the server.
+func (c *Client) Notify(ctx context.Context, method string, params interface{}) error {
+	msg := &message{JSONRPC: "2.0", Method: method}
+	if params != nil {
+		raw, err := json.Marshal(params)
+		if err != nil {
+			return fmt.Errorf("marshal %s params: %w", method, err)
+		}
+		msg.Params = raw
+	}
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	return c.transport.send(ctx, data)
+}
+
+// Close ends the connection and fails any calls still waiting for a response.
+func (c *Client) Close() error {
+	c.fail(nil)
+	return c.transport.close()
+}
+
+// deliver routes a message received from the server.
+func (c *Client) deliver(msg *message) {
+	switch {
+	case msg.Method == "" && len(msg.ID) > 0:
+		// Response to one of our requests.
+		c.mu.Lock()
+		ch, ok := c.pending[string(msg.ID)]
+		if ok {
+			// Buffered; a duplicate response is dropped rather than blocking.
+			select {
+			case ch <- msg:
+			default:
+			}
+		}
+		c.mu.Unlock()
+		if !ok {
+			c.logger.WithField("id", string(msg.ID)).Warn("Dropping response for unknown request")
+		}
+
+	case msg.Method != "" && len(msg.ID) > 0:
+		// Request from the server. The client offers no features beyond ping.
+		resp := &message{JSONRPC: "2.0", ID: msg.ID}
+		if msg.Method == mcp.MethodPing {
+			resp.Result = json.RawMessage("{}")
+		} else {
+			resp.Error = jsonrpc.NewMethodNotFoundError(fmt.Sprintf("Method '%s' not supported by client", msg.Method), nil)
+		}
+		data, _ := json.Marshal(resp)
+		if err := c.transport.send(context.Background(), data); err != nil {
+			c.logger.WithError(err).Warn("Failed to answer server request")
+		}
+
+	case msg.Method != "":
+		c.handlersMu.RLock()
+		handlers := append(append([]NotificationHandler(nil), c.handlers[msg.Method]...), c.anyHandlers...)
+		c.handlersMu.RUnlock()
+		for _, h := range handlers {
+			h(msg.Method, msg.Params)
+		}
+	}
+}
+
+// fail marks the connection closed and releases every pending call.
+func (c *Client) fail(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return
+	}
+	c.closed = true
+	for id, ch := range c.pending {
+		close(ch)
+		delete(c.pending, id)
+	}
+	if err != nil && !errors.Is(err, io.EOF) {
+		c.logger.WithError(err).Warn("Connection lost")
+	}
+}
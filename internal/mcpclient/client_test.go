@@ -0,0 +1,203 @@
+package mcpclient_test
+
+import (
+	"context"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/trenchesdeveloper/mcp-server-store/internal/auth"
+	"github.com/trenchesdeveloper/mcp-server-store/internal/client"
+	"github.com/trenchesdeveloper/mcp-server-store/internal/mcp"
+	"github.com/trenchesdeveloper/mcp-server-store/internal/mcpclient"
+	"github.com/trenchesdeveloper/mcp-server-store/internal/mcphttp"
+	"github.com/trenchesdeveloper/mcp-server-store/internal/mockstore"
+	"github.com/trenchesdeveloper/mcp-server-store/internal/store/rest"
+	"github.com/trenchesdeveloper/mcp-server-store/internal/tools/account"
+	"github.com/trenchesdeveloper/mcp-server-store/internal/tools/cart"
+	"github.com/trenchesdeveloper/mcp-server-store/internal/tools/orders"
+)
+
+func quietLogger() *logrus.Logger {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	return logger
+}
+
+// newStoreServer serves the account, cart and order tools against a fresh
+// mockstore, as the server binary wires them.
+func newStoreServer(t *testing.T) *mcp.Server {
+	t.Helper()
+	ts := httptest.NewServer(mockstore.NewServer(nil))
+	t.Cleanup(ts.Close)
+
+	logger := quietLogger()
+	httpClient := client.NewRestClient(ts.URL+mockstore.DefaultBasePath, "", logger)
+	backend := rest.New(httpClient)
+	sessions := auth.NewManager(httpClient, logger)
+	accountTools := account.NewAccountToolSet(sessions, logger)
+	cartTools := cart.NewCartToolSet(auth.Cart(backend, sessions), logger)
+	orderTools := orders.NewOrderToolSet(auth.Orders(backend, sessions), logger)
+
+	server := mcp.NewServer("store", "1.0.0", logger)
+	for _, tool := range []struct {
+		tool    mcp.Tool
+		handler mcp.ToolHandler
+	}{
+		{accountTools.LoginTool(), accountTools.LoginHandler()},
+		{cartTools.AddToCartTool(), cartTools.AddToCartHandler()},
+		{orderTools.CreateOrderTool(), orderTools.CreateOrderHandler()},
+		{orderTools.ListOrdersTool(), orderTools.ListOrdersHandler()},
+	} {
+		if err := server.RegisterTool(tool.tool, tool.handler); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := server.RegisterResourceTemplate(orderTools.OrderTemplate(), orderTools.OrderResourceHandler()); err != nil {
+		t.Fatal(err)
+	}
+	return server
+}
+
+// forEachTransport runs test against a client connected to a fresh store
+// server over each transport.
+func forEachTransport(t *testing.T, test func(t *testing.T, c *mcpclient.Client)) {
+	for _, transport := range []struct {
+		name    string
+		connect func(*testing.T, *mcp.Server) *mcpclient.Client
+	}{
+		{"pipe", func(_ *testing.T, server *mcp.Server) *mcpclient.Client {
+			return mcpclient.NewInProcessClient(server)
+		}},
+		{"http", func(t *testing.T, server *mcp.Server) *mcpclient.Client {
+			ts := httptest.NewServer(mcphttp.NewHandler(server, quietLogger()))
+			t.Cleanup(ts.Close)
+			return mcpclient.NewHTTPClient(ts.URL)
+		}},
+	} {
+		t.Run(transport.name, func(t *testing.T) {
+			c := transport.connect(t, newStoreServer(t))
+			t.Cleanup(func() { c.Close() })
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			if _, err := c.Initialize(ctx); err != nil {
+				t.Fatalf("Initialize: %v", err)
+			}
+			test(t, c)
+		})
+	}
+}
+
+// callTool calls name and returns the text of its result, failing the
+// test on protocol errors and tool errors alike.
+func callTool(t *testing.T, c *mcpclient.Client, name string, arguments map[string]interface{}) (string, *mcp.ToolCallResult) {
+	t.Helper()
+	result, err := c.CallTool(context.Background(), name, arguments)
+	if err != nil {
+		t.Fatalf("%s: %v", name, err)
+	}
+	var parts []string
+	for _, content := range result.Content {
+		if content.Type == mcp.ContentTypeText {
+			parts = append(parts, content.Text)
+		}
+	}
+	text := strings.Join(parts, "\n")
+	if result.IsError {
+		t.Fatalf("%s failed: %s", name, text)
+	}
+	return text, result
+}
+
+func TestListTools(t *testing.T) {
+	forEachTransport(t, func(t *testing.T, c *mcpclient.Client) {
+		tools, err := c.ListTools(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		var names []string
+		for _, tool := range tools {
+			names = append(names, tool.Name)
+		}
+		if got := strings.Join(names, " "); got != "add_to_cart list_orders login place_order" {
+			t.Errorf("tools = %s", got)
+		}
+	})
+}
+
+func TestPlaceAndListOrders(t *testing.T) {
+	forEachTransport(t, func(t *testing.T, c *mcpclient.Client) {
+		callTool(t, c, "login", map[string]interface{}{
+			"email":    "alice@example.com",
+			"password": "password123",
+		})
+
+		result, err := c.CallTool(context.Background(), "place_order", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !result.IsError {
+			t.Error("place_order with an empty cart succeeded")
+		}
+
+		callTool(t, c, "add_to_cart", map[string]interface{}{"product_id": "1", "quantity": "2"})
+		if placed, _ := callTool(t, c, "place_order", nil); !strings.Contains(placed, "Order #1 created") {
+			t.Fatalf("place_order = %q, want order #1", placed)
+		}
+
+		_, listed := callTool(t, c, "list_orders", nil)
+		var links []string
+		for _, content := range listed.Content {
+			if content.Type == mcp.ContentTypeResourceLink {
+				links = append(links, content.URI)
+			}
+		}
+		if len(links) != 1 || links[0] != orders.OrderURI(1) {
+			t.Fatalf("list_orders links = %v, want [%s]", links, orders.OrderURI(1))
+		}
+
+		read, err := c.ReadResource(context.Background(), links[0])
+		if err != nil {
+			t.Fatalf("ReadResource: %v", err)
+		}
+		if len(read.Contents) != 1 || !strings.Contains(read.Contents[0].Text, `"status": "pending"`) {
+			t.Errorf("read %s = %+v, want a pending order", links[0], read.Contents)
+		}
+	})
+}
+
+// Each connection is its own session: logging in on one does not log in
+// the other.
+func TestSessionsAreSeparate(t *testing.T) {
+	server := newStoreServer(t)
+	ts := httptest.NewServer(mcphttp.NewHandler(server, quietLogger()))
+	t.Cleanup(ts.Close)
+
+	connect := func() *mcpclient.Client {
+		c := mcpclient.NewHTTPClient(ts.URL)
+		t.Cleanup(func() { c.Close() })
+		if _, err := c.Initialize(context.Background()); err != nil {
+			t.Fatalf("Initialize: %v", err)
+		}
+		return c
+	}
+	alice, other := connect(), connect()
+
+	callTool(t, alice, "login", map[string]interface{}{
+		"email":    "alice@example.com",
+		"password": "password123",
+	})
+	callTool(t, alice, "list_orders", nil)
+
+	result, err := other.CallTool(context.Background(), "list_orders", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.IsError {
+		t.Error("list_orders succeeded on a session that never logged in")
+	}
+}
@@ -0,0 +1,330 @@
+package mcpclient
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// transport carries encoded messages to the server. Messages coming back
+// are handed to the client's deliver method.
+type transport interface {
+	send(ctx context.Context, data []byte) error
+	close() error
+}
+
+// listener is a transport that needs a separate channel for messages the
+// server sends outside of a response. Initialize starts it once the
+// session is established.
+type listener interface {
+	listen()
+}
+
+// ---- Stream transport ----
+
+// streamTransport exchanges newline-delimited messages over a reader and
+// writer, such as a subprocess's stdout and stdin.
+type streamTransport struct {
+	w       io.Writer
+	mu      sync.Mutex
+	onClose func() error
+}
+
+// newStreamTransport starts reading from r. onClose, if set, runs after w
+// is closed.
+func newStreamTransport(r io.Reader, w io.Writer, c *Client, onClose func() error) *streamTransport {
+	go func() {
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+		for scanner.Scan() {
+			var msg message
+			if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil {
+				c.logger.WithError(err).Warn("Ignoring malformed message")
+				continue
+			}
+			c.deliver(&msg)
+		}
+		err := scanner.Err()
+		if err == nil {
+			err = io.EOF
+		}
+		c.fail(err)
+	}()
+	return &streamTransport{w: w, onClose: onClose}
+}
+
+func (t *streamTransport) send(_ context.Context, data []byte) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	_, err := t.w.Write(append(data, '\n'))
+	return err
+}
+
+func (t *streamTransport) close() error {
+	var err error
+	if closer, ok := t.w.(io.Closer); ok {
+		err = closer.Close()
+	}
+	if t.onClose != nil {
+		if cerr := t.onClose(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+// startCommand starts cmd and returns a stream transport over its pipes.
+// Closing the transport closes stdin, which asks the server to exit, and
+// kills it if it has not done so shortly after.
+func startCommand(cmd *exec.Cmd, c *Client) (*streamTransport, error) {
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("start %s: %w", cmd.Path, err)
+	}
+
+	wait := func() error {
+		done := make(chan error, 1)
+		go func() { done <- cmd.Wait() }()
+		select {
+		case err := <-done:
+			return err
+		case <-time.After(5 * time.Second):
+			cmd.Process.Kill()
+			return <-done
+		}
+	}
+	return newStreamTransport(stdout, stdin, c, wait), nil
+}
+
+// ---- HTTP transport ----
+
+// listenRetry is how long the HTTP transport waits before reopening an
+// event stream the server ended.
+const listenRetry = time.Second
+
+// httpTransport posts each message to an MCP endpoint. Responses arrive
+// either as a JSON body or as a server-sent event stream. Notifications
+// the server sends on its own, such as list_changed, arrive on a GET
+// event stream opened by listen.
+type httpTransport struct {
+	url    string
+	client *Client
+
+	mu         sync.Mutex
+	sessionID  string
+	stopListen context.CancelFunc
+}
+
+func newHTTPTransport(url string, c *Client) *httpTransport {
+	return &httpTransport{url: url, client: c}
+}
+
+func (t *httpTransport) newRequest(ctx context.Context, method string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, t.url, body)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range t.client.headers {
+		req.Header.Set(k, v)
+	}
+	t.mu.Lock()
+	if t.sessionID != "" {
+		req.Header.Set("Mcp-Session-Id", t.sessionID)
+	}
+	t.mu.Unlock()
+	return req, nil
+}
+
+func (t *httpTransport) send(ctx context.Context, data []byte) error {
+	req, err := t.newRequest(ctx, http.MethodPost, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json, text/event-stream")
+
+	resp, err := t.client.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if id := resp.Header.Get("Mcp-Session-Id"); id != "" {
+		t.mu.Lock()
+		t.sessionID = id
+		t.mu.Unlock()
+	}
+
+	if resp.StatusCode == http.StatusAccepted {
+		return nil
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("server returned %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	if strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+		return t.readEvents(resp.Body)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	return t.deliverBody(body)
+}
+
+// listen opens the GET event stream in the background. A server that
+// does not offer one answers 405, which ends listening quietly.
+func (t *httpTransport) listen() {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.mu.Lock()
+	if t.stopListen != nil {
+		t.mu.Unlock()
+		cancel()
+		return
+	}
+	t.stopListen = cancel
+	t.mu.Unlock()
+
+	go func() {
+		for {
+			err := t.openStream(ctx)
+			if ctx.Err() != nil {
+				return
+			}
+			if err != nil {
+				t.client.logger.WithError(err).Debug("Event stream unavailable; not listening for server notifications")
+				return
+			}
+			select {
+			case <-time.After(listenRetry):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// openStream reads the GET event stream until the server ends it. It
+// returns an error if the server refuses the stream.
+func (t *httpTransport) openStream(ctx context.Context) error {
+	req, err := t.newRequest(ctx, http.MethodGet, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+
+	resp, err := t.client.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("server returned %s", resp.Status)
+	}
+	if !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+		return fmt.Errorf("server returned %q instead of an event stream", resp.Header.Get("Content-Type"))
+	}
+	if err := t.readEvents(resp.Body); err != nil && ctx.Err() == nil {
+		t.client.logger.WithError(err).Warn("Event stream failed")
+	}
+	return nil
+}
+
+// readEvents delivers the data of every event in a server-sent event stream.
+func (t *httpTransport) readEvents(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	var data bytes.Buffer
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			if data.Len() > 0 {
+				if err := t.deliverBody(data.Bytes()); err != nil {
+					return err
+				}
+				data.Reset()
+			}
+		case strings.HasPrefix(line, "data:"):
+			if data.Len() > 0 {
+				data.WriteByte('\n')
+			}
+			data.WriteString(strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+		}
+	}
+	if data.Len() > 0 {
+		if err := t.deliverBody(data.Bytes()); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// deliverBody decodes a single message or a batch and delivers it.
+func (t *httpTransport) deliverBody(body []byte) error {
+	body = bytes.TrimSpace(body)
+	if len(body) == 0 {
+		return nil
+	}
+	if body[0] == '[' {
+		var batch []*message
+		if err := json.Unmarshal(body, &batch); err != nil {
+			return fmt.Errorf("decode batch: %w", err)
+		}
+		for _, msg := range batch {
+			t.client.deliver(msg)
+		}
+		return nil
+	}
+	var msg message
+	if err := json.Unmarshal(body, &msg); err != nil {
+		return fmt.Errorf("decode message: %w", err)
+	}
+	t.client.deliver(&msg)
+	return nil
+}
+
+// close stops listening and ends the server session if the server
+// assigned one.
+func (t *httpTransport) close() error {
+	t.mu.Lock()
+	sessionID := t.sessionID
+	if t.stopListen != nil {
+		t.stopListen()
+	}
+	t.mu.Unlock()
+	if sessionID == "" {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, err := t.newRequest(ctx, http.MethodDelete, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := t.client.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
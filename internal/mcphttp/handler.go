@@ -0,0 +1,470 @@
+// Package mcphttp serves an mcp.Server over the MCP streamable HTTP
+// transport. Clients POST one JSON-RPC message per request and get the
+// response back as a JSON body. Each client gets a session on initialize,
+// identified by the Mcp-Session-Id header. The session ID is not a
+// credential: tools call the API with the bearer token from the current
+// request's Authorization header, and a session only serves requests that
+// carry the token it was opened with. With WithOAuth the endpoint is an
+// OAuth protected resource and every request must carry a valid access
+// token for the subject that opened the session.
+//
+// Requests from browsers are only served for allowed origins, so a page
+// cannot reach a local server through DNS rebinding.
+//
+// Sessions idle for longer than the session TTL are closed, and initialize
+// is refused while the maximum number of sessions is open.
+//
+// Server-initiated messages are not supported: GET, which would open an
+// event stream, is refused, so clients do not receive list_changed
+// notifications and re-list on their own.
+package mcphttp
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/trenchesdeveloper/mcp-server-store/internal/auth"
+	"github.com/trenchesdeveloper/mcp-server-store/internal/client"
+	"github.com/trenchesdeveloper/mcp-server-store/internal/mcp"
+	"github.com/trenchesdeveloper/mcp-server-store/internal/oauth"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// SessionHeader carries the session ID issued on initialize.
+const SessionHeader = "Mcp-Session-Id"
+
+// maxBodySize bounds a single JSON-RPC message.
+const maxBodySize = 4 << 20
+
+// Session limits used unless options say otherwise.
+const (
+	DefaultSessionTTL  = 30 * time.Minute
+	DefaultMaxSessions = 1000
+)
+
+// errTooManySessions refuses a session while the handler is full.
+var errTooManySessions = errors.New("too many open sessions")
+
+// Session is one client's connection to the server.
+type Session struct {
+	ID        string
+	CreatedAt time.Time
+
+	// login holds the API tokens of the login tool, if used.
+	login *auth.Session
+
+	mu       sync.Mutex
+	token    string
+	claims   *oauth.Claims
+	lastUsed time.Time
+}
+
+// Token returns the bearer token the session was opened with, or the one
+// it was last refreshed to with OAuth.
+func (s *Session) Token() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.token
+}
+
+// Claims returns the validated claims of the session's access token, or
+// nil if the handler does not use OAuth.
+func (s *Session) Claims() *oauth.Claims {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.claims
+}
+
+// owns reports whether a request carrying token, and claims if the handler
+// uses OAuth, comes from the client that opened the session. With OAuth the
+// token may have been refreshed, so the subjects are compared and the
+// session takes the new token; otherwise the token must be the one the
+// session was opened with, or none if it was opened without one.
+func (s *Session) owns(token string, claims *oauth.Claims) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if claims != nil && s.claims != nil {
+		if claims.Subject != s.claims.Subject {
+			return false
+		}
+		s.token, s.claims = token, claims
+		return true
+	}
+	return subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) == 1
+}
+
+type sessionKey struct{}
+
+// SessionFromContext returns the session a request belongs to, if it came
+// in over HTTP.
+func SessionFromContext(ctx context.Context) (*Session, bool) {
+	session, ok := ctx.Value(sessionKey{}).(*Session)
+	return session, ok
+}
+
+// Handler is an http.Handler serving one MCP endpoint.
+type Handler struct {
+	server *mcp.Server
+	logger *logrus.Logger
+
+	// validator checks access tokens when OAuth is enabled; metadataURL is
+	// advertised in 401 challenges so clients can find the authorization server.
+	validator   *oauth.Validator
+	metadataURL string
+
+	sessionTTL  time.Duration
+	maxSessions int
+	now         func() time.Time
+
+	// origins are the allowed values of the Origin header; empty allows
+	// loopback origins only.
+	origins map[string]bool
+
+	mu       sync.Mutex
+	sessions map[string]*Session
+}
+
+// Option is a functional option for configuring a Handler.
+type Option func(*Handler)
+
+// WithOAuth requires every request to carry an access token accepted by
+// validator. Requests without one are answered with 401 and a challenge
+// pointing at the protected resource metadata at metadataURL.
+func WithOAuth(validator *oauth.Validator, metadataURL string) Option {
+	return func(h *Handler) {
+		h.validator = validator
+		h.metadataURL = metadataURL
+	}
+}
+
+// WithSessionTTL closes sessions that have been idle for longer than ttl.
+// The default is DefaultSessionTTL.
+func WithSessionTTL(ttl time.Duration) Option {
+	return func(h *Handler) {
+		h.sessionTTL = ttl
+	}
+}
+
+// WithMaxSessions refuses to open more than n sessions at a time. The
+// default is DefaultMaxSessions.
+func WithMaxSessions(n int) Option {
+	return func(h *Handler) {
+		h.maxSessions = n
+	}
+}
+
+// WithAllowedOrigins serves browser requests only from the given origins,
+// such as "https://app.example.com". Without it, or with none, only
+// loopback origins like "http://localhost:5173" are allowed. Requests
+// without an Origin header do not come from a browser and are allowed.
+func WithAllowedOrigins(origins []string) Option {
+	return func(h *Handler) {
+		h.origins = make(map[string]bool, len(origins))
+		for _, origin := range origins {
+			h.origins[strings.ToLower(strings.TrimSuffix(origin, "/"))] = true
+		}
+	}
+}
+
+// NewHandler creates a Handler serving server.
+func NewHandler(server *mcp.Server, logger *logrus.Logger, opts ...Option) *Handler {
+	h := &Handler{
+		server:      server,
+		logger:      logger,
+		sessionTTL:  DefaultSessionTTL,
+		maxSessions: DefaultMaxSessions,
+		now:         time.Now,
+		sessions:    make(map[string]*Session),
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+// Sessions returns the number of open sessions.
+func (h *Handler) Sessions() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.sessions)
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if origin := r.Header.Get("Origin"); origin != "" && !h.allowedOrigin(origin) {
+		h.logger.WithField("origin", origin).Warn("Rejected request from a disallowed origin")
+		http.Error(w, "origin not allowed", http.StatusForbidden)
+		return
+	}
+	switch r.Method {
+	case http.MethodPost:
+		h.handlePost(w, r)
+	case http.MethodDelete:
+		h.handleDelete(w, r)
+	default:
+		w.Header().Set("Allow", "POST, DELETE")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handlePost handles one JSON-RPC message. initialize opens a session;
+// every other message must name an open one.
+func (h *Handler) handlePost(w http.ResponseWriter, r *http.Request) {
+	token, _ := bearerToken(r)
+	var claims *oauth.Claims
+	if h.validator != nil {
+		var ok bool
+		if claims, ok = h.authenticate(w, r, token); !ok {
+			return
+		}
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodySize))
+	if err != nil {
+		http.Error(w, "failed to read request body", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	var peek struct {
+		Method string `json:"method"`
+	}
+	_ = json.Unmarshal(body, &peek) // malformed bodies get a JSON-RPC parse error below
+
+	var session *Session
+	if peek.Method == mcp.MethodInitialize {
+		session, err = h.newSession(token, claims)
+		if errors.Is(err, errTooManySessions) {
+			h.logger.WithField("maxSessions", h.maxSessions).Warn("Refused session")
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		if err != nil {
+			h.logger.WithError(err).Error("Failed to create session")
+			http.Error(w, "failed to create session", http.StatusInternalServerError)
+			return
+		}
+	} else {
+		id := r.Header.Get(SessionHeader)
+		if id == "" {
+			http.Error(w, "missing "+SessionHeader+" header", http.StatusBadRequest)
+			return
+		}
+		var ok bool
+		if session, ok = h.session(id); !ok {
+			// Tells the client to initialize again.
+			http.Error(w, "unknown session", http.StatusNotFound)
+			return
+		}
+		// A session belongs to the client that opened it.
+		if !session.owns(token, claims) {
+			h.logger.WithField("session", id).Warn("Rejected a request with other credentials than the session's")
+			http.Error(w, "credentials do not match the session", http.StatusForbidden)
+			return
+		}
+	}
+
+	ctx := context.WithValue(r.Context(), sessionKey{}, session)
+	if token != "" {
+		ctx = client.ContextWithToken(ctx, token)
+	}
+	ctx = auth.ContextWithSession(ctx, session.login)
+	ctx = mcp.ContextWithSessionID(ctx, session.ID)
+	// Trace context in the message's params._meta takes precedence.
+	ctx = otel.GetTextMapPropagator().Extract(ctx, propagation.HeaderCarrier(r.Header))
+
+	resp := h.server.ServeMessage(ctx, body)
+	w.Header().Set(SessionHeader, session.ID)
+	if resp == nil {
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(resp)
+}
+
+// authenticate validates the request's access token. On failure it writes
+// the 401 or 403 response and returns false.
+func (h *Handler) authenticate(w http.ResponseWriter, r *http.Request, token string) (*oauth.Claims, bool) {
+	if token == "" {
+		w.Header().Set("WWW-Authenticate", oauth.Challenge(h.metadataURL, "", "", h.validator.RequiredScopes()))
+		http.Error(w, "authorization required", http.StatusUnauthorized)
+		return nil, false
+	}
+
+	claims, err := h.validator.Validate(r.Context(), token)
+	if err == nil {
+		return claims, true
+	}
+
+	h.logger.WithError(err).Warn("Rejected access token")
+	if errors.Is(err, oauth.ErrInsufficientScope) {
+		w.Header().Set("WWW-Authenticate", oauth.Challenge(h.metadataURL, "insufficient_scope", err.Error(), h.validator.RequiredScopes()))
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return nil, false
+	}
+	w.Header().Set("WWW-Authenticate", oauth.Challenge(h.metadataURL, "invalid_token", err.Error(), nil))
+	http.Error(w, err.Error(), http.StatusUnauthorized)
+	return nil, false
+}
+
+// handleDelete closes the session named in the request.
+func (h *Handler) handleDelete(w http.ResponseWriter, r *http.Request) {
+	token, _ := bearerToken(r)
+	var claims *oauth.Claims
+	if h.validator != nil {
+		var ok bool
+		if claims, ok = h.authenticate(w, r, token); !ok {
+			return
+		}
+	}
+
+	id := r.Header.Get(SessionHeader)
+	session, ok := h.session(id)
+	if !ok {
+		http.Error(w, "unknown session", http.StatusNotFound)
+		return
+	}
+	if !session.owns(token, claims) {
+		http.Error(w, "credentials do not match the session", http.StatusForbidden)
+		return
+	}
+
+	h.mu.Lock()
+	delete(h.sessions, id)
+	h.mu.Unlock()
+	h.server.EndSession(id)
+
+	h.logger.WithField("session", id).Info("Closed HTTP session")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// newSession opens a session for the client authenticated by token and,
+// with OAuth, claims.
+func (h *Handler) newSession(token string, claims *oauth.Claims) (*Session, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return nil, err
+	}
+	now := h.now()
+	session := &Session{
+		ID:        hex.EncodeToString(b[:]),
+		CreatedAt: now,
+		login:     auth.NewSession(),
+		token:     token,
+		claims:    claims,
+		lastUsed:  now,
+	}
+
+	h.mu.Lock()
+	if len(h.sessions) >= h.maxSessions {
+		h.sweep(now)
+	}
+	if len(h.sessions) >= h.maxSessions {
+		h.mu.Unlock()
+		return nil, errTooManySessions
+	}
+	h.sessions[session.ID] = session
+	h.mu.Unlock()
+
+	h.logger.WithField("session", session.ID).Info("Opened HTTP session")
+	return session, nil
+}
+
+// session returns the open session id and marks it used. A session idle
+// for longer than the TTL is closed instead.
+func (h *Handler) session(id string) (*Session, bool) {
+	now := h.now()
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	session, ok := h.sessions[id]
+	if !ok {
+		return nil, false
+	}
+	if h.expired(session, now) {
+		h.closeIdle(session)
+		return nil, false
+	}
+	session.mu.Lock()
+	session.lastUsed = now
+	session.mu.Unlock()
+	return session, true
+}
+
+// ExpireSessions closes idle sessions every interval until ctx is done.
+// Idle sessions are never used again either way, but would otherwise be
+// kept until a client names them.
+func (h *Handler) ExpireSessions(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			h.mu.Lock()
+			h.sweep(h.now())
+			h.mu.Unlock()
+		}
+	}
+}
+
+// sweep closes the sessions idle for longer than the TTL. h.mu must be held.
+func (h *Handler) sweep(now time.Time) {
+	for _, session := range h.sessions {
+		if h.expired(session, now) {
+			h.closeIdle(session)
+		}
+	}
+}
+
+func (h *Handler) expired(session *Session, now time.Time) bool {
+	session.mu.Lock()
+	defer session.mu.Unlock()
+	return now.Sub(session.lastUsed) > h.sessionTTL
+}
+
+// closeIdle closes an expired session. h.mu must be held.
+func (h *Handler) closeIdle(session *Session) {
+	delete(h.sessions, session.ID)
+	h.server.EndSession(session.ID)
+	h.logger.WithField("session", session.ID).Info("Closed idle HTTP session")
+}
+
+// allowedOrigin reports whether requests from origin are served.
+func (h *Handler) allowedOrigin(origin string) bool {
+	if len(h.origins) > 0 {
+		return h.origins[strings.ToLower(origin)]
+	}
+	u, err := url.Parse(origin)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return false
+	}
+	host := u.Hostname()
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// bearerToken returns the token of an "Authorization: Bearer" header.
+func bearerToken(r *http.Request) (string, bool) {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
@@ -0,0 +1,182 @@
+package mcphttp
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/trenchesdeveloper/mcp-server-store/internal/client"
+	"github.com/trenchesdeveloper/mcp-server-store/internal/mcp"
+)
+
+const initializeBody = `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-06-18","capabilities":{},"clientInfo":{"name":"test","version":"1"}}}`
+
+const whoamiBody = `{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"whoami","arguments":{}}}`
+
+// newTestHandler serves a server with a whoami tool that reports the API
+// token its call would use.
+func newTestHandler(t *testing.T, opts ...Option) *Handler {
+	t.Helper()
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	server := mcp.NewServer("test", "1.0.0", logger)
+	err := server.RegisterTool(mcp.Tool{
+		Name:        "whoami",
+		InputSchema: mcp.InputSchema{Type: "object"},
+	}, func(ctx context.Context, _ map[string]interface{}) (*mcp.ToolCallResult, error) {
+		token, _ := client.TokenFromContext(ctx)
+		return &mcp.ToolCallResult{Content: []mcp.Content{mcp.NewTextContent("token=" + token)}}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewHandler(server, logger, opts...)
+}
+
+// request sends one message and returns the recorded response.
+func request(h *Handler, method, session, token, body string, header ...string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, "/mcp", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if session != "" {
+		req.Header.Set(SessionHeader, session)
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func open(t *testing.T, h *Handler, token string) string {
+	t.Helper()
+	rec := request(h, http.MethodPost, "", token, initializeBody)
+	if rec.Code != http.StatusOK || rec.Header().Get(SessionHeader) == "" {
+		t.Fatalf("initialize = %d %q, want a session", rec.Code, rec.Body.String())
+	}
+	return rec.Header().Get(SessionHeader)
+}
+
+// whoami calls the whoami tool and returns the token it saw.
+func whoami(t *testing.T, rec *httptest.ResponseRecorder) string {
+	t.Helper()
+	var resp struct {
+		Result mcp.ToolCallResult `json:"result"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || len(resp.Result.Content) == 0 {
+		t.Fatalf("tools/call response %q: %v", rec.Body.String(), err)
+	}
+	return strings.TrimPrefix(resp.Result.Content[0].Text, "token=")
+}
+
+func TestSessionOnlyServesItsOpeningToken(t *testing.T) {
+	h := newTestHandler(t)
+	session := open(t, h, "alice-token")
+
+	rec := request(h, http.MethodPost, session, "alice-token", whoamiBody)
+	if rec.Code != http.StatusOK || whoami(t, rec) != "alice-token" {
+		t.Fatalf("call with the opening token = %d %q", rec.Code, rec.Body.String())
+	}
+
+	for name, token := range map[string]string{"no token": "", "another token": "mallory-token"} {
+		if rec := request(h, http.MethodPost, session, token, whoamiBody); rec.Code != http.StatusForbidden {
+			t.Errorf("call with %s = %d %q, want 403", name, rec.Code, rec.Body.String())
+		}
+	}
+	// The refused requests did not replace the session's token.
+	if rec := request(h, http.MethodPost, session, "alice-token", whoamiBody); whoami(t, rec) != "alice-token" {
+		t.Errorf("token after refused requests = %q", rec.Body.String())
+	}
+}
+
+func TestSessionWithoutTokenStaysAnonymous(t *testing.T) {
+	h := newTestHandler(t)
+	session := open(t, h, "")
+
+	if rec := request(h, http.MethodPost, session, "", whoamiBody); rec.Code != http.StatusOK || whoami(t, rec) != "" {
+		t.Errorf("anonymous call = %d %q, want no token", rec.Code, rec.Body.String())
+	}
+	if rec := request(h, http.MethodPost, session, "bob-token", whoamiBody); rec.Code != http.StatusForbidden {
+		t.Errorf("call adding a token = %d, want 403", rec.Code)
+	}
+}
+
+func TestDeleteRequiresTheSessionToken(t *testing.T) {
+	h := newTestHandler(t)
+	session := open(t, h, "alice-token")
+
+	if rec := request(h, http.MethodDelete, session, "", ""); rec.Code != http.StatusForbidden {
+		t.Errorf("DELETE without the token = %d, want 403", rec.Code)
+	}
+	if rec := request(h, http.MethodDelete, session, "alice-token", ""); rec.Code != http.StatusNoContent {
+		t.Errorf("DELETE with the token = %d, want 204", rec.Code)
+	}
+	if rec := request(h, http.MethodPost, session, "alice-token", whoamiBody); rec.Code != http.StatusNotFound {
+		t.Errorf("call on a deleted session = %d, want 404", rec.Code)
+	}
+}
+
+func TestSessionHeaderRequired(t *testing.T) {
+	h := newTestHandler(t)
+
+	if rec := request(h, http.MethodPost, "", "", whoamiBody); rec.Code != http.StatusBadRequest {
+		t.Errorf("call without a session = %d, want 400", rec.Code)
+	}
+	if rec := request(h, http.MethodPost, "unknown", "", whoamiBody); rec.Code != http.StatusNotFound {
+		t.Errorf("call on an unknown session = %d, want 404", rec.Code)
+	}
+}
+
+func TestSessionLimits(t *testing.T) {
+	h := newTestHandler(t, WithMaxSessions(1), WithSessionTTL(time.Minute))
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	h.now = func() time.Time { return now }
+
+	session := open(t, h, "")
+	if rec := request(h, http.MethodPost, "", "", initializeBody); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("initialize beyond max sessions = %d, want 503", rec.Code)
+	}
+
+	now = now.Add(2 * time.Minute)
+	if rec := request(h, http.MethodPost, session, "", whoamiBody); rec.Code != http.StatusNotFound {
+		t.Errorf("call on an idle session = %d, want 404", rec.Code)
+	}
+	open(t, h, "")
+}
+
+func TestOrigins(t *testing.T) {
+	tests := []struct {
+		name    string
+		allowed []string
+		origin  string
+		want    int
+	}{
+		{"no origin", nil, "", http.StatusOK},
+		{"localhost by default", nil, "http://localhost:5173", http.StatusOK},
+		{"loopback IP by default", nil, "http://127.0.0.1:8080", http.StatusOK},
+		{"remote by default", nil, "https://evil.example.com", http.StatusForbidden},
+		{"listed", []string{"https://app.example.com/"}, "https://APP.example.com", http.StatusOK},
+		{"localhost when a list is set", []string{"https://app.example.com"}, "http://localhost:5173", http.StatusForbidden},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			h := newTestHandler(t, WithAllowedOrigins(tc.allowed))
+			var header []string
+			if tc.origin != "" {
+				header = []string{"Origin", tc.origin}
+			}
+			if rec := request(h, http.MethodPost, "", "", initializeBody, header...); rec.Code != tc.want {
+				t.Errorf("initialize from %q = %d, want %d", tc.origin, rec.Code, tc.want)
+			}
+		})
+	}
+}
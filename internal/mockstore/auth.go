@@ -0,0 +1,212 @@
+package mockstore
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Tokens are HS256 JWTs carrying the user id in "sub", like the real API's.
+
+var (
+	errMissingToken = errors.New("missing bearer token")
+	errInvalidToken = errors.New("invalid token")
+	errExpiredToken = errors.New("token has expired")
+)
+
+type claims struct {
+	Subject   string `json:"sub"`
+	Email     string `json:"email,omitempty"`
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+var jwtHeader = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+
+func (s *Server) signToken(c claims) string {
+	payload, _ := json.Marshal(c)
+	unsigned := jwtHeader + "." + base64.RawURLEncoding.EncodeToString(payload)
+	return unsigned + "." + s.signature(unsigned)
+}
+
+func (s *Server) signature(unsigned string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(unsigned))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// IssueToken returns a token for the user that is valid for ttl.
+func (s *Server) IssueToken(userID uint, ttl time.Duration) (string, error) {
+	s.mu.Lock()
+	user, ok := s.users[userID]
+	s.mu.Unlock()
+	if !ok {
+		return "", fmt.Errorf("mockstore: unknown user %d", userID)
+	}
+
+	now := s.now()
+	return s.signToken(claims{
+		Subject:   strconv.FormatUint(uint64(user.ID), 10),
+		Email:     user.Email,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(ttl).Unix(),
+	}), nil
+}
+
+// authenticate returns the user id from the request's bearer token.
+func (s *Server) authenticate(header string) (uint, error) {
+	token, ok := strings.CutPrefix(header, "Bearer ")
+	if !ok || token == "" {
+		return 0, errMissingToken
+	}
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 || parts[0] != jwtHeader {
+		return 0, errInvalidToken
+	}
+	if !hmac.Equal([]byte(parts[2]), []byte(s.signature(parts[0]+"."+parts[1]))) {
+		return 0, errInvalidToken
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return 0, errInvalidToken
+	}
+	var c claims
+	if err := json.Unmarshal(payload, &c); err != nil {
+		return 0, errInvalidToken
+	}
+	if s.now().Unix() >= c.ExpiresAt {
+		return 0, errExpiredToken
+	}
+
+	id, err := strconv.ParseUint(c.Subject, 10, 64)
+	if err != nil {
+		return 0, errInvalidToken
+	}
+	s.mu.Lock()
+	_, known := s.users[uint(id)]
+	s.mu.Unlock()
+	if !known {
+		return 0, errInvalidToken
+	}
+	return uint(id), nil
+}
+
+// ---- Login and refresh ----
+
+// refreshToken is an opaque, single-use token exchanged for a new access
+// token at /auth/refresh.
+type refreshToken struct {
+	userID    uint
+	expiresAt time.Time
+}
+
+// tokenResponse is the data of a successful login or refresh.
+type tokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"` // seconds
+	User         User   `json:"user"`
+}
+
+func (s *Server) handleLogin(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Email    string `json:"email"`
+		Password string `json:"password"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	s.mu.Lock()
+	var user *User
+	for _, u := range s.users {
+		if strings.EqualFold(u.Email, req.Email) && hmac.Equal([]byte(u.Password), []byte(req.Password)) {
+			u := u
+			user = &u
+			break
+		}
+	}
+	s.mu.Unlock()
+	if user == nil {
+		writeError(w, http.StatusUnauthorized, "Invalid email or password", nil)
+		return
+	}
+
+	s.writeTokens(w, *user, "Logged in")
+}
+
+func (s *Server) handleRefresh(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	s.mu.Lock()
+	rt, ok := s.refresh[req.RefreshToken]
+	delete(s.refresh, req.RefreshToken) // single use
+	user, known := s.users[rt.userID]
+	s.mu.Unlock()
+	if !ok || !known || !s.now().Before(rt.expiresAt) {
+		writeError(w, http.StatusUnauthorized, "Invalid or expired refresh token", nil)
+		return
+	}
+
+	s.writeTokens(w, user, "Token refreshed")
+}
+
+func (s *Server) handleLogout(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+	// An empty body just ends the client's session.
+	_ = json.NewDecoder(r.Body).Decode(&req)
+
+	s.mu.Lock()
+	delete(s.refresh, req.RefreshToken)
+	s.mu.Unlock()
+
+	writeData(w, http.StatusOK, "Logged out", nil, nil)
+}
+
+// writeTokens issues an access and refresh token pair for user.
+func (s *Server) writeTokens(w http.ResponseWriter, user User, message string) {
+	access, err := s.IssueToken(user.ID, s.accessTTL)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to issue token", err)
+		return
+	}
+	var b [32]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to issue token", err)
+		return
+	}
+	refresh := base64.RawURLEncoding.EncodeToString(b[:])
+
+	s.mu.Lock()
+	s.refresh[refresh] = refreshToken{userID: user.ID, expiresAt: s.now().Add(s.refreshTTL)}
+	s.mu.Unlock()
+
+	user.Password = ""
+	writeData(w, http.StatusOK, message, tokenResponse{
+		AccessToken:  access,
+		RefreshToken: refresh,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(s.accessTTL / time.Second),
+		User:         user,
+	}, nil)
+}
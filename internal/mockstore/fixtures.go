@@ -0,0 +1,116 @@
+package mockstore
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+//go:embed fixtures.json
+var defaultFixtures []byte
+
+// User is a customer account that can authenticate against the mock store.
+type User struct {
+	ID       uint   `json:"id"`
+	Email    string `json:"email"`
+	Name     string `json:"name"`
+	Password string `json:"password,omitempty"`
+}
+
+type Category struct {
+	ID          uint      `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	IsActive    bool      `json:"is_active"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type ProductImage struct {
+	ID        uint      `json:"id"`
+	URL       string    `json:"url"`
+	AltText   string    `json:"alt_text"`
+	IsPrimary bool      `json:"is_primary"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type Product struct {
+	ID          uint           `json:"id"`
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	Price       float64        `json:"price"`
+	Stock       int            `json:"stock"`
+	CategoryID  uint           `json:"category_id"`
+	SKU         string         `json:"sku"`
+	IsActive    bool           `json:"is_active"`
+	Category    Category       `json:"category"`
+	Images      []ProductImage `json:"images"`
+}
+
+// Fixtures is the initial state of the mock store.
+type Fixtures struct {
+	Users      []User     `json:"users"`
+	Categories []Category `json:"categories"`
+	Products   []Product  `json:"products"`
+}
+
+// DefaultFixtures returns the bundled sample catalog and users.
+func DefaultFixtures() *Fixtures {
+	fx, err := parseFixtures(defaultFixtures)
+	if err != nil {
+		panic(fmt.Sprintf("mockstore: bundled fixtures: %v", err))
+	}
+	return fx
+}
+
+// LoadFixtures reads a JSON fixtures file.
+func LoadFixtures(path string) (*Fixtures, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read fixtures: %w", err)
+	}
+	fx, err := parseFixtures(data)
+	if err != nil {
+		return nil, fmt.Errorf("fixtures %s: %w", path, err)
+	}
+	return fx, nil
+}
+
+func parseFixtures(data []byte) (*Fixtures, error) {
+	var fx Fixtures
+	if err := json.Unmarshal(data, &fx); err != nil {
+		return nil, err
+	}
+	return &fx, fx.validate()
+}
+
+// validate checks ids are unique and every product's category exists.
+func (fx *Fixtures) validate() error {
+	users := make(map[uint]bool)
+	for _, u := range fx.Users {
+		if u.ID == 0 || users[u.ID] {
+			return fmt.Errorf("user %q: missing or duplicate id %d", u.Email, u.ID)
+		}
+		users[u.ID] = true
+	}
+	categories := make(map[uint]bool)
+	for _, c := range fx.Categories {
+		if c.ID == 0 || categories[c.ID] {
+			return fmt.Errorf("category %q: missing or duplicate id %d", c.Name, c.ID)
+		}
+		categories[c.ID] = true
+	}
+	products := make(map[uint]bool)
+	for _, p := range fx.Products {
+		if p.ID == 0 || products[p.ID] {
+			return fmt.Errorf("product %q: missing or duplicate id %d", p.Name, p.ID)
+		}
+		if !categories[p.CategoryID] {
+			return fmt.Errorf("product %d: unknown category %d", p.ID, p.CategoryID)
+		}
+		products[p.ID] = true
+	}
+	return nil
+}
@@ -0,0 +1,439 @@
+package mockstore
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Order statuses. Only pending orders can be cancelled.
+const (
+	StatusPending   = "pending"
+	StatusCancelled = "cancelled"
+)
+
+type CartItem struct {
+	ID       uint    `json:"id"`
+	Product  Product `json:"product"`
+	Quantity int     `json:"quantity"`
+}
+
+type cart struct {
+	ID        uint        `json:"id"`
+	UserID    uint        `json:"user_id"`
+	CartItems []*CartItem `json:"cart_items"`
+	Total     float64     `json:"total"`
+	CreatedAt time.Time   `json:"created_at"`
+	UpdatedAt time.Time   `json:"updated_at"`
+}
+
+type OrderItem struct {
+	ProductID uint    `json:"product_id"`
+	Name      string  `json:"name"`
+	Price     float64 `json:"price"`
+	Quantity  int     `json:"quantity"`
+}
+
+type Order struct {
+	ID        uint        `json:"id"`
+	UserID    uint        `json:"user_id"`
+	Status    string      `json:"status"`
+	Total     float64     `json:"total"`
+	Items     []OrderItem `json:"items"`
+	CreatedAt time.Time   `json:"created_at"`
+	UpdatedAt time.Time   `json:"updated_at"`
+}
+
+// ---- Products ----
+
+func (s *Server) handleListProducts(w http.ResponseWriter, r *http.Request) {
+	page, limit, err := pagination(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid pagination", err)
+		return
+	}
+
+	s.mu.Lock()
+	products := s.activeProducts(func(*Product) bool { return true })
+	s.mu.Unlock()
+
+	items, meta := paginate(products, page, limit)
+	writeData(w, http.StatusOK, "Products retrieved successfully", items, meta)
+}
+
+func (s *Server) handleSearchProducts(w http.ResponseWriter, r *http.Request) {
+	page, limit, err := pagination(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid pagination", err)
+		return
+	}
+
+	q := r.URL.Query()
+	query := strings.ToLower(strings.TrimSpace(q.Get("q")))
+	if query == "" {
+		writeError(w, http.StatusBadRequest, "Search query is required", errors.New("q must not be empty"))
+		return
+	}
+
+	var categoryID uint64
+	var minPrice, maxPrice float64
+	if v := q.Get("category_id"); v != "" {
+		if categoryID, err = strconv.ParseUint(v, 10, 64); err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid category_id", err)
+			return
+		}
+	}
+	if v := q.Get("min_price"); v != "" {
+		if minPrice, err = strconv.ParseFloat(v, 64); err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid min_price", err)
+			return
+		}
+	}
+	if v := q.Get("max_price"); v != "" {
+		if maxPrice, err = strconv.ParseFloat(v, 64); err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid max_price", err)
+			return
+		}
+	}
+
+	s.mu.Lock()
+	products := s.activeProducts(func(p *Product) bool {
+		if !strings.Contains(strings.ToLower(p.Name), query) &&
+			!strings.Contains(strings.ToLower(p.SKU), query) &&
+			!strings.Contains(strings.ToLower(p.Description), query) {
+			return false
+		}
+		if categoryID != 0 && uint64(p.CategoryID) != categoryID {
+			return false
+		}
+		if minPrice > 0 && p.Price < minPrice {
+			return false
+		}
+		if maxPrice > 0 && p.Price > maxPrice {
+			return false
+		}
+		return true
+	})
+	s.mu.Unlock()
+
+	items, meta := paginate(products, page, limit)
+	writeData(w, http.StatusOK, "Products retrieved successfully", items, meta)
+}
+
+func (s *Server) handleGetProduct(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid product ID", err)
+		return
+	}
+
+	s.mu.Lock()
+	p, ok := s.products[id]
+	var product Product
+	if ok {
+		product = *p
+	}
+	s.mu.Unlock()
+
+	if !ok {
+		writeError(w, http.StatusNotFound, "Product not found", fmt.Errorf("product %d does not exist", id))
+		return
+	}
+	writeData(w, http.StatusOK, "Product retrieved successfully", product, nil)
+}
+
+// activeProducts returns copies of the active products matching keep,
+// ordered by id. The caller must hold s.mu.
+func (s *Server) activeProducts(keep func(*Product) bool) []Product {
+	products := make([]Product, 0, len(s.products))
+	for _, p := range s.products {
+		if p.IsActive && keep(p) {
+			products = append(products, *p)
+		}
+	}
+	sort.Slice(products, func(i, j int) bool { return products[i].ID < products[j].ID })
+	return products
+}
+
+// ---- Cart ----
+
+type addToCartRequest struct {
+	ProductID uint `json:"product_id"`
+	Quantity  int  `json:"quantity"`
+}
+
+func (s *Server) handleViewCart(w http.ResponseWriter, r *http.Request, userID uint) {
+	s.mu.Lock()
+	c := s.cartFor(userID)
+	view := *c
+	view.CartItems = make([]*CartItem, len(c.CartItems))
+	for i, it := range c.CartItems {
+		item := *it
+		view.CartItems[i] = &item
+	}
+	s.mu.Unlock()
+
+	writeData(w, http.StatusOK, "Cart retrieved successfully", view, nil)
+}
+
+func (s *Server) handleAddToCart(w http.ResponseWriter, r *http.Request, userID uint) {
+	var req addToCartRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+	if req.ProductID == 0 {
+		writeError(w, http.StatusBadRequest, "product_id is required", nil)
+		return
+	}
+	if req.Quantity <= 0 {
+		writeError(w, http.StatusBadRequest, "Quantity must be at least 1", fmt.Errorf("quantity %d", req.Quantity))
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	product, ok := s.products[req.ProductID]
+	if !ok || !product.IsActive {
+		writeError(w, http.StatusNotFound, "Product not found", fmt.Errorf("product %d does not exist", req.ProductID))
+		return
+	}
+
+	c := s.cartFor(userID)
+	var item *CartItem
+	for _, it := range c.CartItems {
+		if it.Product.ID == req.ProductID {
+			item = it
+			break
+		}
+	}
+	inCart := 0
+	if item != nil {
+		inCart = item.Quantity
+	}
+	if inCart+req.Quantity > product.Stock {
+		writeError(w, http.StatusConflict, "Insufficient stock",
+			fmt.Errorf("only %d of product %d available, %d already in cart", product.Stock, product.ID, inCart))
+		return
+	}
+
+	if item == nil {
+		s.nextID.cartItem++
+		item = &CartItem{ID: s.nextID.cartItem, Product: *product}
+		c.CartItems = append(c.CartItems, item)
+	}
+	item.Quantity += req.Quantity
+	c.recalculate(s.now())
+
+	writeData(w, http.StatusCreated, "Product added to cart", struct {
+		ID        uint      `json:"id"`
+		UserID    uint      `json:"user_id"`
+		Total     float64   `json:"total"`
+		CreatedAt time.Time `json:"created_at"`
+		UpdatedAt time.Time `json:"updated_at"`
+	}{c.ID, c.UserID, c.Total, c.CreatedAt, c.UpdatedAt}, nil)
+}
+
+// cartFor returns the user's cart, creating an empty one on first use.
+// The caller must hold s.mu.
+func (s *Server) cartFor(userID uint) *cart {
+	c, ok := s.carts[userID]
+	if !ok {
+		now := s.now().UTC()
+		s.nextID.cart++
+		c = &cart{ID: s.nextID.cart, UserID: userID, CartItems: []*CartItem{}, CreatedAt: now, UpdatedAt: now}
+		s.carts[userID] = c
+	}
+	return c
+}
+
+func (c *cart) recalculate(now time.Time) {
+	total := 0.0
+	for _, it := range c.CartItems {
+		total += it.Product.Price * float64(it.Quantity)
+	}
+	c.Total = roundCents(total)
+	c.UpdatedAt = now.UTC()
+}
+
+// ---- Orders ----
+
+func (s *Server) handleListOrders(w http.ResponseWriter, r *http.Request, userID uint) {
+	page, limit, err := pagination(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid pagination", err)
+		return
+	}
+
+	s.mu.Lock()
+	orders := make([]Order, 0)
+	for _, o := range s.orders {
+		if o.UserID == userID {
+			orders = append(orders, *o)
+		}
+	}
+	s.mu.Unlock()
+
+	// Newest first, as the real API returns them.
+	sort.Slice(orders, func(i, j int) bool { return orders[i].ID > orders[j].ID })
+	items, meta := paginate(orders, page, limit)
+	writeData(w, http.StatusOK, "Orders retrieved successfully", items, meta)
+}
+
+// handleCreateOrder turns the user's cart into a pending order, reserving
+// stock and emptying the cart.
+func (s *Server) handleCreateOrder(w http.ResponseWriter, r *http.Request, userID uint) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c := s.cartFor(userID)
+	if len(c.CartItems) == 0 {
+		writeError(w, http.StatusBadRequest, "Cart is empty", errors.New("add products to the cart before placing an order"))
+		return
+	}
+
+	// Check every line before touching stock so a failure changes nothing.
+	for _, it := range c.CartItems {
+		product, ok := s.products[it.Product.ID]
+		if !ok || !product.IsActive {
+			writeError(w, http.StatusConflict, "Product no longer available", fmt.Errorf("product %d", it.Product.ID))
+			return
+		}
+		if product.Stock < it.Quantity {
+			writeError(w, http.StatusConflict, "Insufficient stock",
+				fmt.Errorf("only %d of product %d available", product.Stock, product.ID))
+			return
+		}
+	}
+
+	now := s.now().UTC()
+	s.nextID.order++
+	order := &Order{ID: s.nextID.order, UserID: userID, Status: StatusPending, Items: []OrderItem{}, CreatedAt: now, UpdatedAt: now}
+	total := 0.0
+	for _, it := range c.CartItems {
+		product := s.products[it.Product.ID]
+		product.Stock -= it.Quantity
+		order.Items = append(order.Items, OrderItem{
+			ProductID: product.ID,
+			Name:      product.Name,
+			Price:     product.Price,
+			Quantity:  it.Quantity,
+		})
+		total += product.Price * float64(it.Quantity)
+	}
+	order.Total = roundCents(total)
+	s.orders[order.ID] = order
+
+	c.CartItems = []*CartItem{}
+	c.recalculate(now)
+
+	writeData(w, http.StatusCreated, "Order created successfully", *order, nil)
+}
+
+func (s *Server) handleGetOrder(w http.ResponseWriter, r *http.Request, userID uint) {
+	id, err := pathID(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid order ID", err)
+		return
+	}
+
+	s.mu.Lock()
+	o, ok := s.orders[id]
+	var order Order
+	if ok {
+		order = *o
+	}
+	s.mu.Unlock()
+
+	// Other users' orders are indistinguishable from missing ones.
+	if !ok || order.UserID != userID {
+		writeError(w, http.StatusNotFound, "Order not found", fmt.Errorf("order %d does not exist", id))
+		return
+	}
+	writeData(w, http.StatusOK, "Order retrieved successfully", order, nil)
+}
+
+// handleCancelOrder cancels a pending order and returns its stock.
+func (s *Server) handleCancelOrder(w http.ResponseWriter, r *http.Request, userID uint) {
+	id, err := pathID(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid order ID", err)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	order, ok := s.orders[id]
+	if !ok || order.UserID != userID {
+		writeError(w, http.StatusNotFound, "Order not found", fmt.Errorf("order %d does not exist", id))
+		return
+	}
+	if order.Status != StatusPending {
+		writeError(w, http.StatusConflict, "Only pending orders can be cancelled",
+			fmt.Errorf("order %d is %s", id, order.Status))
+		return
+	}
+
+	for _, item := range order.Items {
+		if product, ok := s.products[item.ProductID]; ok {
+			product.Stock += item.Quantity
+		}
+	}
+	order.Status = StatusCancelled
+	order.UpdatedAt = s.now().UTC()
+
+	writeData(w, http.StatusOK, "Order cancelled successfully", *order, nil)
+}
+
+// ---- Helpers ----
+
+func pathID(r *http.Request) (uint, error) {
+	id, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
+	if err != nil || id == 0 {
+		return 0, fmt.Errorf("%q is not a valid id", r.PathValue("id"))
+	}
+	return uint(id), nil
+}
+
+// pagination reads page and limit, defaulting to 1 and 10.
+func pagination(r *http.Request) (page, limit int, err error) {
+	page, limit = 1, 10
+	q := r.URL.Query()
+	if v := q.Get("page"); v != "" {
+		if page, err = strconv.Atoi(v); err != nil || page < 1 {
+			return 0, 0, fmt.Errorf("page must be a positive integer, got %q", v)
+		}
+	}
+	if v := q.Get("limit"); v != "" {
+		if limit, err = strconv.Atoi(v); err != nil || limit < 1 || limit > 100 {
+			return 0, 0, fmt.Errorf("limit must be between 1 and 100, got %q", v)
+		}
+	}
+	return page, limit, nil
+}
+
+func paginate[T any](items []T, page, limit int) ([]T, *Meta) {
+	meta := &Meta{
+		Total:      len(items),
+		Page:       page,
+		Limit:      limit,
+		TotalPages: (len(items) + limit - 1) / limit,
+	}
+	start := (page - 1) * limit
+	if start >= len(items) {
+		return []T{}, meta
+	}
+	end := min(start+limit, len(items))
+	return items[start:end], meta
+}
+
+func roundCents(v float64) float64 {
+	return float64(int64(v*100+0.5)) / 100
+}
@@ -0,0 +1,411 @@
+// Package mockstore is an in-memory stand-in for the ecommerce API the store
+// tools talk to. It serves the product, cart, and order endpoints with the
+// same response envelope as the real API, checks JWT-style bearer tokens,
+// and keeps cart and order state per user. A POST carrying an
+// Idempotency-Key header is carried out once: repeats get the stored
+// response.
+//
+// Server is an http.Handler, so tests can run it with httptest:
+//
+//	store := mockstore.NewServer(mockstore.DefaultFixtures())
+//	ts := httptest.NewServer(store)
+//	defer ts.Close()
+//	apiURL := ts.URL + mockstore.DefaultBasePath
+//	token, _ := store.IssueToken(1, time.Hour)
+package mockstore
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// DefaultBasePath is the path prefix the endpoints are mounted under,
+// matching the default API_URL of the MCP server.
+const DefaultBasePath = "/api/v1"
+
+// Server is the mock ecommerce API.
+type Server struct {
+	mux      *http.ServeMux
+	logger   *logrus.Logger
+	secret   []byte
+	basePath string
+	now      func() time.Time
+
+	// accessTTL is the lifetime of tokens issued by /auth/login and
+	// /auth/refresh; refresh tokens last refreshTTL.
+	accessTTL  time.Duration
+	refreshTTL time.Duration
+
+	mu         sync.Mutex
+	users      map[uint]User
+	categories map[uint]Category
+	products   map[uint]*Product
+	carts      map[uint]*cart // by user id
+	orders     map[uint]*Order
+	refresh    map[string]refreshToken
+	replies    map[idempotencyKey]*storedReply
+	nextID     struct{ cart, cartItem, order uint }
+}
+
+// Option configures a Server.
+type Option func(*Server)
+
+// WithSecret sets the HMAC key used to sign and verify tokens.
+func WithSecret(secret []byte) Option {
+	return func(s *Server) {
+		s.secret = secret
+	}
+}
+
+// WithBasePath mounts the endpoints under prefix instead of DefaultBasePath.
+func WithBasePath(prefix string) Option {
+	return func(s *Server) {
+		s.basePath = prefix
+	}
+}
+
+// WithAccessTokenTTL sets the lifetime of access tokens issued by the
+// login and refresh endpoints. The default is 15 minutes.
+func WithAccessTokenTTL(ttl time.Duration) Option {
+	return func(s *Server) {
+		s.accessTTL = ttl
+	}
+}
+
+// WithLogger logs every request to logger.
+func WithLogger(logger *logrus.Logger) Option {
+	return func(s *Server) {
+		s.logger = logger
+	}
+}
+
+// NewServer creates a mock store seeded from fx. A nil fx uses the bundled
+// fixtures.
+func NewServer(fx *Fixtures, opts ...Option) *Server {
+	if fx == nil {
+		fx = DefaultFixtures()
+	}
+
+	discard := logrus.New()
+	discard.SetOutput(io.Discard)
+
+	s := &Server{
+		logger:     discard,
+		secret:     []byte("mockstore-dev-secret"),
+		basePath:   DefaultBasePath,
+		now:        time.Now,
+		accessTTL:  15 * time.Minute,
+		refreshTTL: 30 * 24 * time.Hour,
+		users:      make(map[uint]User),
+		categories: make(map[uint]Category),
+		products:   make(map[uint]*Product),
+		carts:      make(map[uint]*cart),
+		orders:     make(map[uint]*Order),
+		refresh:    make(map[string]refreshToken),
+		replies:    make(map[idempotencyKey]*storedReply),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	for _, u := range fx.Users {
+		s.users[u.ID] = u
+	}
+	for _, c := range fx.Categories {
+		s.categories[c.ID] = c
+	}
+	for _, p := range fx.Products {
+		p := p
+		p.Category = s.categories[p.CategoryID]
+		if p.Images == nil {
+			p.Images = []ProductImage{}
+		}
+		s.products[p.ID] = &p
+	}
+
+	s.routes()
+	return s
+}
+
+// Users returns the accounts the store was seeded with, ordered by id.
+func (s *Server) Users() []User {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	users := make([]User, 0, len(s.users))
+	for _, u := range s.users {
+		users = append(users, u)
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].ID < users[j].ID })
+	return users
+}
+
+func (s *Server) routes() {
+	s.mux = http.NewServeMux()
+	handle := func(pattern string, h http.HandlerFunc) {
+		method, path, _ := strings.Cut(pattern, " ")
+		s.mux.HandleFunc(method+" "+s.basePath+path, h)
+	}
+
+	// Auth
+	handle("POST /auth/login", s.handleLogin)
+	handle("POST /auth/refresh", s.handleRefresh)
+	handle("POST /auth/logout", s.handleLogout)
+
+	// Products
+	handle("GET /products", s.handleListProducts)
+	handle("GET /products/search", s.handleSearchProducts)
+	handle("GET /products/{id}", s.handleGetProduct)
+
+	// Cart
+	handle("GET /cart", s.authed(s.handleViewCart))
+	handle("POST /cart/items", s.authed(s.idempotent(s.handleAddToCart)))
+
+	// Orders
+	handle("GET /orders", s.authed(s.handleListOrders))
+	handle("POST /orders", s.authed(s.idempotent(s.handleCreateOrder)))
+	handle("GET /orders/{id}", s.authed(s.handleGetOrder))
+	handle("POST /orders/{id}/cancel", s.authed(s.idempotent(s.handleCancelOrder)))
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Header.Get("X-Request-Id")
+	if requestID == "" {
+		requestID = newRequestID()
+	}
+	w.Header().Set("X-Request-Id", requestID)
+
+	rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+	start := time.Now()
+	if r.Method == http.MethodGet {
+		serveWithETag(s.mux, rec, r)
+	} else {
+		s.mux.ServeHTTP(rec, r)
+	}
+	s.logger.WithFields(logrus.Fields{
+		"method":    r.Method,
+		"path":      r.URL.RequestURI(),
+		"status":    rec.status,
+		"duration":  time.Since(start).String(),
+		"requestId": requestID,
+	}).Info("Handled request")
+}
+
+// newRequestID returns a random ID for a request that did not bring one.
+func newRequestID() string {
+	var b [8]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// serveWithETag serves a GET with an ETag derived from the response body,
+// answering 304 Not Modified when it matches the request's If-None-Match.
+func serveWithETag(h http.Handler, w http.ResponseWriter, r *http.Request) {
+	buf := &bufferedResponse{header: make(http.Header), status: http.StatusOK}
+	h.ServeHTTP(buf, r)
+
+	for k, v := range buf.header {
+		w.Header()[k] = v
+	}
+	if buf.status == http.StatusOK {
+		sum := sha256.Sum256(buf.body.Bytes())
+		etag := `"` + hex.EncodeToString(sum[:8]) + `"`
+		w.Header().Set("ETag", etag)
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+	}
+	w.WriteHeader(buf.status)
+	w.Write(buf.body.Bytes())
+}
+
+type bufferedResponse struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (b *bufferedResponse) Header() http.Header         { return b.header }
+func (b *bufferedResponse) Write(p []byte) (int, error) { return b.body.Write(p) }
+func (b *bufferedResponse) WriteHeader(status int)      { b.status = status }
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// ---- Idempotency ----
+
+// idempotencyTTL is how long a response answers repeats of its request.
+const idempotencyTTL = 24 * time.Hour
+
+// idempotencyKey identifies a request by its Idempotency-Key header, scoped
+// to the user and endpoint.
+type idempotencyKey struct {
+	userID uint
+	route  string
+	key    string
+}
+
+// storedReply is the response to a request with an Idempotency-Key, or a
+// placeholder while the request is being handled.
+type storedReply struct {
+	body    string // digest of the request body, to catch reused keys
+	done    bool
+	status  int
+	header  http.Header
+	data    []byte
+	expires time.Time
+}
+
+// idempotent carries out a request with an Idempotency-Key header once per
+// user and endpoint. A repeat gets the stored response, marked with an
+// Idempotent-Replayed header; one arriving while the first is still being
+// handled gets 409, and one with a different body 422. Server errors are
+// not stored, so the request can be retried.
+func (s *Server) idempotent(h func(w http.ResponseWriter, r *http.Request, userID uint)) func(w http.ResponseWriter, r *http.Request, userID uint) {
+	return func(w http.ResponseWriter, r *http.Request, userID uint) {
+		key := r.Header.Get("Idempotency-Key")
+		if key == "" {
+			h(w, r, userID)
+			return
+		}
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid request body", err)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		sum := sha256.Sum256(body)
+		digest := hex.EncodeToString(sum[:])
+		id := idempotencyKey{userID: userID, route: r.Method + " " + r.URL.Path, key: key}
+
+		s.mu.Lock()
+		now := s.now()
+		for k, reply := range s.replies {
+			if reply.done && now.After(reply.expires) {
+				delete(s.replies, k)
+			}
+		}
+		reply, repeated := s.replies[id]
+		var stored storedReply
+		if repeated {
+			stored = *reply
+		} else {
+			s.replies[id] = &storedReply{body: digest}
+		}
+		s.mu.Unlock()
+
+		if repeated {
+			switch {
+			case stored.body != digest:
+				writeError(w, http.StatusUnprocessableEntity, "Idempotency key reused",
+					fmt.Errorf("key %q was used with a different request", key))
+			case !stored.done:
+				writeError(w, http.StatusConflict, "Request in progress",
+					fmt.Errorf("a request with key %q is still being handled", key))
+			default:
+				for k, v := range stored.header {
+					w.Header()[k] = v
+				}
+				w.Header().Set("Idempotent-Replayed", "true")
+				w.WriteHeader(stored.status)
+				w.Write(stored.data)
+			}
+			return
+		}
+
+		buf := &bufferedResponse{header: make(http.Header), status: http.StatusOK}
+		h(buf, r, userID)
+
+		s.mu.Lock()
+		if buf.status >= http.StatusInternalServerError {
+			delete(s.replies, id)
+		} else {
+			s.replies[id] = &storedReply{
+				body:    digest,
+				done:    true,
+				status:  buf.status,
+				header:  buf.header.Clone(),
+				data:    buf.body.Bytes(),
+				expires: s.now().Add(idempotencyTTL),
+			}
+		}
+		s.mu.Unlock()
+
+		for k, v := range buf.header {
+			w.Header()[k] = v
+		}
+		w.WriteHeader(buf.status)
+		w.Write(buf.body.Bytes())
+	}
+}
+
+// ---- Envelope ----
+
+// Meta describes a page of a paginated list.
+type Meta struct {
+	Total      int `json:"total"`
+	Page       int `json:"page"`
+	Limit      int `json:"limit"`
+	TotalPages int `json:"total_pages"`
+}
+
+// envelope is the response body shape shared by every endpoint.
+type envelope struct {
+	Success bool        `json:"success"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
+	Meta    *Meta       `json:"meta,omitempty"`
+	Error   string      `json:"error,omitempty"`
+}
+
+func writeJSON(w http.ResponseWriter, status int, body envelope) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+func writeData(w http.ResponseWriter, status int, message string, data interface{}, meta *Meta) {
+	writeJSON(w, status, envelope{Success: true, Message: message, Data: data, Meta: meta})
+}
+
+func writeError(w http.ResponseWriter, status int, message string, err error) {
+	body := envelope{Message: message}
+	if err != nil {
+		body.Error = err.Error()
+	}
+	writeJSON(w, status, body)
+}
+
+// authed rejects requests without a valid bearer token and passes the
+// authenticated user's id to h.
+func (s *Server) authed(h func(w http.ResponseWriter, r *http.Request, userID uint)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := s.authenticate(r.Header.Get("Authorization"))
+		if err != nil {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="mockstore"`)
+			writeError(w, http.StatusUnauthorized, "Unauthorized", err)
+			return
+		}
+		h(w, r, userID)
+	}
+}
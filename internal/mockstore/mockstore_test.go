@@ -0,0 +1,101 @@
+package mockstore
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// post sends an authenticated POST to the store and decodes the envelope.
+func post(t *testing.T, ts *httptest.Server, token, path, body, key string) (*http.Response, envelope) {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodPost, ts.URL+DefaultBasePath+path, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+	if key != "" {
+		req.Header.Set("Idempotency-Key", key)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var env envelope
+	if err := json.NewDecoder(resp.Body).Decode(&env); err != nil {
+		t.Fatalf("decode %s response: %v", path, err)
+	}
+	return resp, env
+}
+
+func orderID(t *testing.T, env envelope) float64 {
+	t.Helper()
+	data, ok := env.Data.(map[string]interface{})
+	if !ok {
+		t.Fatalf("response data = %v, want an order", env.Data)
+	}
+	id, _ := data["id"].(float64)
+	return id
+}
+
+func TestIdempotencyKeyReplaysResponse(t *testing.T) {
+	store := NewServer(nil)
+	ts := httptest.NewServer(store)
+	defer ts.Close()
+	token, err := store.IssueToken(1, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	post(t, ts, token, "/cart/items", `{"product_id":1,"quantity":1}`, "")
+	first, env := post(t, ts, token, "/orders", "", "order-key")
+	if first.StatusCode != http.StatusCreated {
+		t.Fatalf("POST /orders = %d, want 201", first.StatusCode)
+	}
+	id := orderID(t, env)
+
+	again, env := post(t, ts, token, "/orders", "", "order-key")
+	if again.StatusCode != http.StatusCreated || again.Header.Get("Idempotent-Replayed") != "true" {
+		t.Fatalf("repeated POST /orders = %d replayed=%q, want the stored 201", again.StatusCode, again.Header.Get("Idempotent-Replayed"))
+	}
+	if got := orderID(t, env); got != id {
+		t.Errorf("repeated POST /orders created order %v, want the original %v", got, id)
+	}
+
+	// The cart was emptied by the first order, so a new key fails.
+	if resp, _ := post(t, ts, token, "/orders", "", "other-key"); resp.StatusCode == http.StatusCreated {
+		t.Error("POST /orders with a new key placed a second order from an empty cart")
+	}
+}
+
+func TestIdempotencyKeyRejectsOtherBody(t *testing.T) {
+	store := NewServer(nil)
+	ts := httptest.NewServer(store)
+	defer ts.Close()
+	token, _ := store.IssueToken(1, time.Hour)
+
+	post(t, ts, token, "/cart/items", `{"product_id":1,"quantity":1}`, "add-key")
+	resp, _ := post(t, ts, token, "/cart/items", `{"product_id":2,"quantity":1}`, "add-key")
+	if resp.StatusCode != http.StatusUnprocessableEntity {
+		t.Errorf("reused key with another body = %d, want 422", resp.StatusCode)
+	}
+}
+
+func TestIdempotencyKeyIsPerUser(t *testing.T) {
+	store := NewServer(nil)
+	ts := httptest.NewServer(store)
+	defer ts.Close()
+	alice, _ := store.IssueToken(1, time.Hour)
+	bob, _ := store.IssueToken(2, time.Hour)
+
+	post(t, ts, alice, "/cart/items", `{"product_id":1,"quantity":1}`, "same-key")
+	resp, _ := post(t, ts, bob, "/cart/items", `{"product_id":1,"quantity":1}`, "same-key")
+	if resp.Header.Get("Idempotent-Replayed") != "" {
+		t.Error("another user's request with the same key was answered from the first user's")
+	}
+}
@@ -0,0 +1,261 @@
+// Package oauth lets the HTTP transport act as an OAuth 2.1 protected
+// resource: it validates JWT access tokens against an authorization
+// server's JSON Web Key Set and describes itself with RFC 9728 metadata.
+package oauth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ---- JSON Web Key Set ----
+
+// jwk is one key of a JWKS document. Only the members needed to build RSA
+// and EC public keys are decoded.
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+
+	// RSA
+	N string `json:"n"`
+	E string `json:"e"`
+
+	// EC
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// publicKey is a verification key and the algorithm it is pinned to, if any.
+type publicKey struct {
+	key crypto.PublicKey
+	alg string
+}
+
+// parseJWKS decodes a JWKS document. Keys that are not for signatures or
+// of an unsupported type are skipped.
+func parseJWKS(data []byte) (map[string]publicKey, error) {
+	var doc struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("parse JWKS: %w", err)
+	}
+
+	keys := make(map[string]publicKey, len(doc.Keys))
+	for _, k := range doc.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		key, err := k.publicKey()
+		if err != nil {
+			return nil, fmt.Errorf("JWKS key %q: %w", k.Kid, err)
+		}
+		if key == nil {
+			continue
+		}
+		keys[k.Kid] = publicKey{key: key, alg: k.Alg}
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("JWKS has no usable signing keys")
+	}
+	return keys, nil
+}
+
+func (k jwk) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("modulus: %w", err)
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, fmt.Errorf("exponent: %w", err)
+		}
+		if !e.IsInt64() || e.Int64() < 3 {
+			return nil, errors.New("invalid exponent")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, fmt.Errorf("x: %w", err)
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, fmt.Errorf("y: %w", err)
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, errors.New("point is not on the curve")
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	}
+	return nil, nil
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) == 0 {
+		return nil, errors.New("empty value")
+	}
+	return new(big.Int).SetBytes(b), nil
+}
+
+// ---- Key sources ----
+
+// KeySet supplies the keys access tokens are verified with, read from a
+// JWKS file or fetched from a JWKS URL.
+type KeySet struct {
+	load   func(ctx context.Context) ([]byte, error)
+	source string
+	logger *logrus.Logger
+
+	// refreshInterval is how long fetched keys are used before re-fetching;
+	// minRefresh rate-limits re-fetches for unknown key IDs.
+	refreshInterval time.Duration
+	minRefresh      time.Duration
+
+	mu      sync.Mutex
+	keys    map[string]publicKey
+	fetched time.Time
+}
+
+// NewFileKeySet reads the keys from a JWKS file. The file is read once.
+func NewFileKeySet(path string, logger *logrus.Logger) (*KeySet, error) {
+	ks := &KeySet{
+		source: path,
+		logger: logger,
+		load: func(context.Context) ([]byte, error) {
+			return os.ReadFile(path)
+		},
+	}
+	if err := ks.refresh(context.Background()); err != nil {
+		return nil, err
+	}
+	return ks, nil
+}
+
+// NewURLKeySet fetches the keys from a JWKS URL, re-fetching them hourly
+// and when a token names an unknown key, at most once a minute. A failed
+// first fetch is retried on the next token instead of failing startup.
+func NewURLKeySet(url string, httpClient *http.Client, logger *logrus.Logger) *KeySet {
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 10 * time.Second}
+	}
+	ks := &KeySet{
+		source:          url,
+		logger:          logger,
+		refreshInterval: time.Hour,
+		minRefresh:      time.Minute,
+		load: func(ctx context.Context) ([]byte, error) {
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+			if err != nil {
+				return nil, err
+			}
+			resp, err := httpClient.Do(req)
+			if err != nil {
+				return nil, err
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != http.StatusOK {
+				return nil, fmt.Errorf("fetch JWKS: %s", resp.Status)
+			}
+			return io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+		},
+	}
+	if err := ks.refresh(context.Background()); err != nil {
+		logger.WithError(err).WithField("url", url).Warn("Failed to fetch JWKS, will retry")
+	}
+	return ks
+}
+
+// key returns the key with the given ID, re-fetching the set if it is
+// stale or does not contain kid.
+func (ks *KeySet) key(ctx context.Context, kid string) (publicKey, error) {
+	ks.mu.Lock()
+	key, ok := ks.lookup(kid)
+	stale := ks.refreshInterval > 0 && time.Since(ks.fetched) > ks.refreshInterval
+	canRefresh := ks.minRefresh > 0 && time.Since(ks.fetched) > ks.minRefresh
+	ks.mu.Unlock()
+
+	if ok && !stale {
+		return key, nil
+	}
+	if stale || canRefresh {
+		if err := ks.refresh(ctx); err != nil {
+			ks.logger.WithError(err).WithField("source", ks.source).Warn("Failed to refresh JWKS")
+		}
+		ks.mu.Lock()
+		key, ok = ks.lookup(kid)
+		ks.mu.Unlock()
+	}
+	if !ok {
+		return publicKey{}, fmt.Errorf("unknown signing key %q", kid)
+	}
+	return key, nil
+}
+
+// lookup finds kid in the set. A token without a key ID matches the only
+// key of a single-key set. ks.mu must be held.
+func (ks *KeySet) lookup(kid string) (publicKey, bool) {
+	if kid == "" && len(ks.keys) == 1 {
+		for _, key := range ks.keys {
+			return key, true
+		}
+	}
+	key, ok := ks.keys[kid]
+	return key, ok
+}
+
+func (ks *KeySet) refresh(ctx context.Context) error {
+	data, err := ks.load(ctx)
+
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	ks.fetched = time.Now()
+	if err != nil {
+		return fmt.Errorf("load JWKS from %s: %w", ks.source, err)
+	}
+	keys, err := parseJWKS(data)
+	if err != nil {
+		return fmt.Errorf("load JWKS from %s: %w", ks.source, err)
+	}
+	ks.keys = keys
+	ks.logger.WithFields(logrus.Fields{
+		"source": ks.source,
+		"keys":   len(keys),
+	}).Info("Loaded JWKS")
+	return nil
+}
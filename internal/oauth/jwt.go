@@ -0,0 +1,234 @@
+package oauth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ---- Access token validation ----
+
+// Errors returned by Validate. ErrInsufficientScope means the token is
+// valid but lacks a required scope; everything else is ErrInvalidToken.
+var (
+	ErrInvalidToken      = errors.New("invalid access token")
+	ErrInsufficientScope = errors.New("insufficient scope")
+)
+
+// leeway absorbs clock skew between this server and the authorization server.
+const leeway = time.Minute
+
+// Claims are the validated contents of an access token.
+type Claims struct {
+	Subject   string
+	Issuer    string
+	Audience  []string
+	Scopes    []string
+	ExpiresAt time.Time
+	IssuedAt  time.Time
+	ClientID  string
+
+	// Raw holds every claim as decoded from the token.
+	Raw map[string]interface{}
+}
+
+// HasScope reports whether the token was granted scope.
+func (c *Claims) HasScope(scope string) bool {
+	return slices.Contains(c.Scopes, scope)
+}
+
+// Validator checks JWT access tokens issued for this resource.
+type Validator struct {
+	keys     *KeySet
+	issuer   string
+	audience string
+	scopes   []string
+	now      func() time.Time
+}
+
+// NewValidator creates a Validator accepting tokens signed by a key in
+// keys, issued by issuer for audience, and carrying every scope in scopes.
+func NewValidator(keys *KeySet, issuer, audience string, scopes []string) *Validator {
+	return &Validator{
+		keys:     keys,
+		issuer:   issuer,
+		audience: audience,
+		scopes:   scopes,
+		now:      time.Now,
+	}
+}
+
+// RequiredScopes returns the scopes every token must carry.
+func (v *Validator) RequiredScopes() []string {
+	return v.scopes
+}
+
+// Validate verifies the token's signature and its iss, aud, exp, and nbf
+// claims, then checks the required scopes. The returned error wraps
+// ErrInvalidToken or ErrInsufficientScope; with the latter the claims are
+// returned as well.
+func (v *Validator) Validate(ctx context.Context, token string) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w: malformed token", ErrInvalidToken)
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+		Typ string `json:"typ"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("%w: header: %v", ErrInvalidToken, err)
+	}
+	key, err := v.keys.key(ctx, header.Kid)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	if key.alg != "" && key.alg != header.Alg {
+		return nil, fmt.Errorf("%w: key %q is for %s, token uses %s", ErrInvalidToken, header.Kid, key.alg, header.Alg)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: signature: %v", ErrInvalidToken, err)
+	}
+	if err := verify(header.Alg, key.key, []byte(parts[0]+"."+parts[1]), signature); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	var raw map[string]interface{}
+	if err := decodeSegment(parts[1], &raw); err != nil {
+		return nil, fmt.Errorf("%w: payload: %v", ErrInvalidToken, err)
+	}
+	claims := claimsFrom(raw)
+
+	now := v.now()
+	if claims.ExpiresAt.IsZero() {
+		return nil, fmt.Errorf("%w: no exp claim", ErrInvalidToken)
+	}
+	if now.After(claims.ExpiresAt.Add(leeway)) {
+		return nil, fmt.Errorf("%w: token expired at %s", ErrInvalidToken, claims.ExpiresAt.Format(time.RFC3339))
+	}
+	if nbf, ok := numericDate(raw["nbf"]); ok && now.Add(leeway).Before(nbf) {
+		return nil, fmt.Errorf("%w: token not valid before %s", ErrInvalidToken, nbf.Format(time.RFC3339))
+	}
+	if claims.Issuer != v.issuer {
+		return nil, fmt.Errorf("%w: issuer %q is not trusted", ErrInvalidToken, claims.Issuer)
+	}
+	if !slices.Contains(claims.Audience, v.audience) {
+		return nil, fmt.Errorf("%w: token is not for audience %q", ErrInvalidToken, v.audience)
+	}
+
+	for _, scope := range v.scopes {
+		if !claims.HasScope(scope) {
+			return claims, fmt.Errorf("%w: missing scope %q", ErrInsufficientScope, scope)
+		}
+	}
+	return claims, nil
+}
+
+func decodeSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// verify checks signature over signed with key using alg.
+func verify(alg string, key crypto.PublicKey, signed, signature []byte) error {
+	var hash crypto.Hash
+	switch alg {
+	case "RS256", "PS256", "ES256":
+		hash = crypto.SHA256
+	case "RS384", "PS384", "ES384":
+		hash = crypto.SHA384
+	case "RS512", "PS512", "ES512":
+		hash = crypto.SHA512
+	default:
+		return fmt.Errorf("unsupported algorithm %q", alg)
+	}
+	h := hash.New()
+	h.Write(signed)
+	digest := h.Sum(nil)
+
+	switch alg[:2] {
+	case "RS", "PS":
+		rsaKey, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("algorithm %s needs an RSA key", alg)
+		}
+		if alg[0] == 'P' {
+			return rsa.VerifyPSS(rsaKey, hash, digest, signature, nil)
+		}
+		return rsa.VerifyPKCS1v15(rsaKey, hash, digest, signature)
+	default:
+		ecKey, ok := key.(*ecdsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("algorithm %s needs an EC key", alg)
+		}
+		size := (ecKey.Curve.Params().BitSize + 7) / 8
+		if len(signature) != 2*size {
+			return errors.New("malformed ECDSA signature")
+		}
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		if !ecdsa.Verify(ecKey, digest, r, s) {
+			return errors.New("signature verification failed")
+		}
+		return nil
+	}
+}
+
+// claimsFrom extracts the registered claims from a decoded payload. aud
+// may be a string or a list; scopes come from a space-separated "scope"
+// claim or a "scp" list.
+func claimsFrom(raw map[string]interface{}) *Claims {
+	claims := &Claims{Raw: raw}
+	claims.Subject, _ = raw["sub"].(string)
+	claims.Issuer, _ = raw["iss"].(string)
+	claims.ClientID, _ = raw["client_id"].(string)
+	claims.ExpiresAt, _ = numericDate(raw["exp"])
+	claims.IssuedAt, _ = numericDate(raw["iat"])
+
+	switch aud := raw["aud"].(type) {
+	case string:
+		claims.Audience = []string{aud}
+	case []interface{}:
+		claims.Audience = stringList(aud)
+	}
+
+	if scope, ok := raw["scope"].(string); ok {
+		claims.Scopes = strings.Fields(scope)
+	} else if scp, ok := raw["scp"].([]interface{}); ok {
+		claims.Scopes = stringList(scp)
+	}
+	return claims
+}
+
+func numericDate(v interface{}) (time.Time, bool) {
+	f, ok := v.(float64)
+	if !ok {
+		return time.Time{}, false
+	}
+	return time.Unix(int64(f), 0), true
+}
+
+func stringList(values []interface{}) []string {
+	var out []string
+	for _, v := range values {
+		if s, ok := v.(string); ok {
+			out = append(out, s)
+		}
+	}
+	return out
+}
@@ -0,0 +1,271 @@
+package oauth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	testIssuer   = "https://auth.example.com"
+	testAudience = "https://mcp.example.com/mcp"
+)
+
+var testNow = time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+// testKeys are the signing keys behind the test JWKS: an RSA key pinned
+// to RS256, and an EC key pinned to ES256 unless unpinned is set.
+type testKeys struct {
+	rsa      *rsa.PrivateKey
+	ec       *ecdsa.PrivateKey
+	unpinned bool
+}
+
+func newTestKeys(t *testing.T) *testKeys {
+	t.Helper()
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testKeys{rsa: rsaKey, ec: ecKey}
+}
+
+func b64(b []byte) string { return base64.RawURLEncoding.EncodeToString(b) }
+
+// validator writes the public keys to a JWKS file and returns a Validator
+// using it, whose clock reads testNow.
+func (k *testKeys) validator(t *testing.T, scopes ...string) *Validator {
+	t.Helper()
+	point := func(n *big.Int) string { return b64(n.FillBytes(make([]byte, 32))) }
+	doc := map[string]interface{}{
+		"keys": []map[string]string{
+			{
+				"kty": "RSA", "kid": "rsa", "use": "sig", "alg": "RS256",
+				"n": b64(k.rsa.N.Bytes()), "e": b64(big.NewInt(int64(k.rsa.E)).Bytes()),
+			},
+			{
+				"kty": "EC", "kid": "ec", "use": "sig", "alg": "ES256", "crv": "P-256",
+				"x": point(k.ec.X), "y": point(k.ec.Y),
+			},
+		},
+	}
+	if k.unpinned {
+		for _, key := range doc["keys"].([]map[string]string) {
+			delete(key, "alg")
+		}
+	}
+	data, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	keys, err := NewFileKeySet(path, logger)
+	if err != nil {
+		t.Fatalf("NewFileKeySet: %v", err)
+	}
+	v := NewValidator(keys, testIssuer, testAudience, scopes)
+	v.now = func() time.Time { return testNow }
+	return v
+}
+
+// sign returns a token with the given header alg and kid, signed with the
+// key for kid.
+func (k *testKeys) sign(t *testing.T, alg, kid string, claims map[string]interface{}) string {
+	t.Helper()
+	header, _ := json.Marshal(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	signed := b64(header) + "." + b64(payload)
+	digest := sha256.Sum256([]byte(signed))
+
+	var signature []byte
+	var err error
+	switch kid {
+	case "rsa":
+		signature, err = rsa.SignPKCS1v15(rand.Reader, k.rsa, crypto.SHA256, digest[:])
+	case "ec":
+		var r, s *big.Int
+		r, s, err = ecdsa.Sign(rand.Reader, k.ec, digest[:])
+		if err == nil {
+			signature = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+		}
+	default:
+		t.Fatalf("no key %q", kid)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed + "." + b64(signature)
+}
+
+// claims returns valid claims with the given overrides; a nil value
+// removes the claim.
+func claims(overrides map[string]interface{}) map[string]interface{} {
+	c := map[string]interface{}{
+		"sub":   "user-1",
+		"iss":   testIssuer,
+		"aud":   testAudience,
+		"iat":   testNow.Add(-time.Minute).Unix(),
+		"exp":   testNow.Add(time.Hour).Unix(),
+		"scope": "store:read store:write",
+	}
+	for k, v := range overrides {
+		if v == nil {
+			delete(c, k)
+		} else {
+			c[k] = v
+		}
+	}
+	return c
+}
+
+func TestValidateAcceptsRSAAndEC(t *testing.T) {
+	keys := newTestKeys(t)
+	v := keys.validator(t, "store:read")
+
+	for _, tc := range []struct{ alg, kid string }{{"RS256", "rsa"}, {"ES256", "ec"}} {
+		got, err := v.Validate(context.Background(), keys.sign(t, tc.alg, tc.kid, claims(nil)))
+		if err != nil {
+			t.Errorf("%s: Validate: %v", tc.alg, err)
+			continue
+		}
+		if got.Subject != "user-1" || !got.HasScope("store:write") {
+			t.Errorf("%s: claims = %+v", tc.alg, got)
+		}
+	}
+}
+
+func TestValidateRejectsClaims(t *testing.T) {
+	keys := newTestKeys(t)
+	v := keys.validator(t)
+
+	tests := []struct {
+		name      string
+		overrides map[string]interface{}
+		want      string
+	}{
+		{"expired", map[string]interface{}{"exp": testNow.Add(-2 * time.Minute).Unix()}, "expired"},
+		{"no exp", map[string]interface{}{"exp": nil}, "no exp"},
+		{"not yet valid", map[string]interface{}{"nbf": testNow.Add(5 * time.Minute).Unix()}, "not valid before"},
+		{"wrong issuer", map[string]interface{}{"iss": "https://evil.example.com"}, "issuer"},
+		{"wrong audience", map[string]interface{}{"aud": "https://other.example.com"}, "audience"},
+		{"audience list without ours", map[string]interface{}{"aud": []string{"a", "b"}}, "audience"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := v.Validate(context.Background(), keys.sign(t, "ES256", "ec", claims(tc.overrides)))
+			if !errors.Is(err, ErrInvalidToken) || !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("Validate = %v, want ErrInvalidToken mentioning %q", err, tc.want)
+			}
+		})
+	}
+}
+
+func TestValidateAllowsClockSkew(t *testing.T) {
+	keys := newTestKeys(t)
+	v := keys.validator(t)
+
+	token := keys.sign(t, "RS256", "rsa", claims(map[string]interface{}{
+		"exp": testNow.Add(-30 * time.Second).Unix(),
+		"nbf": testNow.Add(30 * time.Second).Unix(),
+		"aud": []string{"other", testAudience},
+	}))
+	if _, err := v.Validate(context.Background(), token); err != nil {
+		t.Errorf("Validate within the leeway: %v", err)
+	}
+}
+
+func TestValidateScopes(t *testing.T) {
+	keys := newTestKeys(t)
+	v := keys.validator(t, "store:read", "store:admin")
+
+	got, err := v.Validate(context.Background(), keys.sign(t, "RS256", "rsa", claims(nil)))
+	if !errors.Is(err, ErrInsufficientScope) || !strings.Contains(err.Error(), "store:admin") {
+		t.Fatalf("Validate = %v, want ErrInsufficientScope for store:admin", err)
+	}
+	if got == nil || got.Subject != "user-1" {
+		t.Errorf("claims with insufficient scope = %+v, want them returned", got)
+	}
+
+	token := keys.sign(t, "RS256", "rsa", claims(map[string]interface{}{
+		"scope": nil,
+		"scp":   []string{"store:read", "store:admin"},
+	}))
+	if _, err := v.Validate(context.Background(), token); err != nil {
+		t.Errorf("Validate with an scp list: %v", err)
+	}
+}
+
+func TestValidateRejectsAlgorithmMismatch(t *testing.T) {
+	keys := newTestKeys(t)
+	pinned := keys.validator(t)
+	keys.unpinned = true
+	unpinned := keys.validator(t)
+
+	tests := []struct {
+		name string
+		v    *Validator
+		alg  string
+		kid  string
+	}{
+		{"header alg differs from the key's", pinned, "RS384", "rsa"},
+		{"EC key claimed as RSA", pinned, "RS256", "ec"},
+		{"unpinned EC key claimed as RSA", unpinned, "RS256", "ec"},
+		{"unpinned RSA key claimed as EC", unpinned, "ES256", "rsa"},
+		{"none", unpinned, "none", "rsa"},
+		{"HMAC", unpinned, "HS256", "rsa"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := tc.v.Validate(context.Background(), keys.sign(t, tc.alg, tc.kid, claims(nil)))
+			if !errors.Is(err, ErrInvalidToken) {
+				t.Errorf("Validate = %v, want ErrInvalidToken", err)
+			}
+		})
+	}
+}
+
+func TestValidateRejectsBadSignatures(t *testing.T) {
+	keys := newTestKeys(t)
+	v := keys.validator(t)
+
+	token := keys.sign(t, "ES256", "ec", claims(nil))
+	parts := strings.Split(token, ".")
+	forged, _ := json.Marshal(claims(map[string]interface{}{"sub": "admin"}))
+
+	for name, bad := range map[string]string{
+		"tampered payload": parts[0] + "." + b64(forged) + "." + parts[2],
+		"unknown key":      strings.Replace(token, parts[0], b64([]byte(`{"alg":"ES256","kid":"gone"}`)), 1),
+		"malformed":        parts[0] + "." + parts[1],
+		"other key":        newTestKeys(t).sign(t, "ES256", "ec", claims(nil)),
+	} {
+		if _, err := v.Validate(context.Background(), bad); !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("%s: Validate = %v, want ErrInvalidToken", name, err)
+		}
+	}
+}
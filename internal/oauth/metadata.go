@@ -0,0 +1,71 @@
+package oauth
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// ---- Protected resource metadata (RFC 9728) ----
+
+// WellKnownPath is where protected resource metadata is served.
+const WellKnownPath = "/.well-known/oauth-protected-resource"
+
+// Metadata describes this server to OAuth clients: which authorization
+// servers issue tokens for it and which scopes it understands.
+type Metadata struct {
+	Resource               string   `json:"resource"`
+	AuthorizationServers   []string `json:"authorization_servers"`
+	ScopesSupported        []string `json:"scopes_supported,omitempty"`
+	BearerMethodsSupported []string `json:"bearer_methods_supported"`
+	ResourceName           string   `json:"resource_name,omitempty"`
+}
+
+// MetadataURL returns the metadata URL for a resource identifier, e.g.
+// https://host/.well-known/oauth-protected-resource/mcp for https://host/mcp.
+func MetadataURL(resource string) (string, error) {
+	u, err := url.Parse(resource)
+	if err != nil {
+		return "", fmt.Errorf("parse resource URL: %w", err)
+	}
+	u.Path = WellKnownPath + strings.TrimSuffix(u.Path, "/")
+	u.RawPath, u.RawQuery, u.Fragment = "", "", ""
+	return u.String(), nil
+}
+
+// MetadataHandler serves m as JSON.
+func MetadataHandler(m Metadata) http.Handler {
+	if m.BearerMethodsSupported == nil {
+		m.BearerMethodsSupported = []string{"header"}
+	}
+	body, _ := json.Marshal(m)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "max-age=3600")
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.Write(body)
+	})
+}
+
+// Challenge builds a WWW-Authenticate value for a Bearer challenge.
+// errCode is "", "invalid_token", or "insufficient_scope".
+func Challenge(metadataURL, errCode, description string, scopes []string) string {
+	params := []string{fmt.Sprintf("resource_metadata=%q", metadataURL)}
+	if errCode != "" {
+		params = append(params, fmt.Sprintf("error=%q", errCode))
+	}
+	if description != "" {
+		params = append(params, fmt.Sprintf("error_description=%q", strings.ReplaceAll(description, `"`, "'")))
+	}
+	if len(scopes) > 0 {
+		params = append(params, fmt.Sprintf("scope=%q", strings.Join(scopes, " ")))
+	}
+	return "Bearer " + strings.Join(params, ", ")
+}
@@ -0,0 +1,282 @@
+// Package memory implements the store interfaces in process, for tests and
+// for running the tools without an ecommerce API. It holds a single
+// customer's cart and orders.
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/trenchesdeveloper/mcp-server-store/internal/store"
+)
+
+// Store is an in-memory catalog, cart, and order book.
+type Store struct {
+	mu       sync.Mutex
+	now      func() time.Time
+	products map[uint]*store.Product
+	cart     store.CartContents
+	orders   map[uint]*store.Order
+	items    map[uint][]store.CartItem // order id -> items, to restock on cancel
+	nextID   struct{ cartItem, order uint }
+}
+
+var (
+	_ store.Catalog = (*Store)(nil)
+	_ store.Cart    = (*Store)(nil)
+	_ store.Orders  = (*Store)(nil)
+)
+
+// New creates a store whose catalog holds products.
+func New(products []store.Product) *Store {
+	now := time.Now().UTC()
+	s := &Store{
+		now:      time.Now,
+		products: make(map[uint]*store.Product, len(products)),
+		orders:   make(map[uint]*store.Order),
+		items:    make(map[uint][]store.CartItem),
+	}
+	s.cart.ID = 1
+	s.cart.CreatedAt, s.cart.UpdatedAt = now, now
+	for _, p := range products {
+		p := p
+		s.products[p.ID] = &p
+	}
+	return s
+}
+
+// ---- Catalog ----
+
+func (s *Store) ListProducts(_ context.Context, opts store.ListOptions) (*store.ProductPage, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.productPage(opts, func(*store.Product) bool { return true }), nil
+}
+
+func (s *Store) SearchProducts(_ context.Context, query store.SearchQuery) (*store.ProductPage, error) {
+	q := strings.ToLower(strings.TrimSpace(query.Query))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.productPage(query.ListOptions, func(p *store.Product) bool {
+		if q != "" &&
+			!strings.Contains(strings.ToLower(p.Name), q) &&
+			!strings.Contains(strings.ToLower(p.SKU), q) &&
+			!strings.Contains(strings.ToLower(p.Description), q) {
+			return false
+		}
+		if query.CategoryID != 0 && p.CategoryID != query.CategoryID {
+			return false
+		}
+		if query.MinPrice > 0 && p.Price < query.MinPrice {
+			return false
+		}
+		if query.MaxPrice > 0 && p.Price > query.MaxPrice {
+			return false
+		}
+		return true
+	}), nil
+}
+
+func (s *Store) GetProduct(_ context.Context, id uint) (*store.Product, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := s.products[id]
+	if !ok {
+		return nil, fmt.Errorf("product %d: %w", id, store.ErrNotFound)
+	}
+	product := *p
+	return &product, nil
+}
+
+// productPage returns a page of active products matching keep, ordered by
+// id. The caller must hold s.mu.
+func (s *Store) productPage(opts store.ListOptions, keep func(*store.Product) bool) *store.ProductPage {
+	var products []store.Product
+	for _, p := range s.products {
+		if p.IsActive && keep(p) {
+			products = append(products, *p)
+		}
+	}
+	sort.Slice(products, func(i, j int) bool { return products[i].ID < products[j].ID })
+
+	page, info := paginate(products, opts)
+	return &store.ProductPage{Products: page, PageInfo: info}
+}
+
+// ---- Cart ----
+
+func (s *Store) AddToCart(_ context.Context, productID uint, quantity int) (*store.CartSummary, error) {
+	if quantity <= 0 {
+		return nil, fmt.Errorf("quantity must be at least 1, got %d", quantity)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	product, ok := s.products[productID]
+	if !ok || !product.IsActive {
+		return nil, fmt.Errorf("product %d: %w", productID, store.ErrNotFound)
+	}
+
+	idx := -1
+	for i, item := range s.cart.Items {
+		if item.Product.ID == productID {
+			idx = i
+			break
+		}
+	}
+	inCart := 0
+	if idx >= 0 {
+		inCart = s.cart.Items[idx].Quantity
+	}
+	if inCart+quantity > product.Stock {
+		return nil, fmt.Errorf("product %d: %d available, %d already in cart: %w",
+			productID, product.Stock, inCart, store.ErrInsufficientStock)
+	}
+
+	if idx < 0 {
+		s.nextID.cartItem++
+		s.cart.Items = append(s.cart.Items, store.CartItem{ID: s.nextID.cartItem, Product: *product})
+		idx = len(s.cart.Items) - 1
+	}
+	s.cart.Items[idx].Quantity += quantity
+	s.recalculateCart()
+
+	summary := s.cart.CartSummary
+	return &summary, nil
+}
+
+func (s *Store) ViewCart(_ context.Context) (*store.CartContents, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	contents := s.cart
+	contents.Items = append([]store.CartItem(nil), s.cart.Items...)
+	return &contents, nil
+}
+
+// recalculateCart updates the cart total. The caller must hold s.mu.
+func (s *Store) recalculateCart() {
+	total := 0.0
+	for _, item := range s.cart.Items {
+		total += item.Product.Price * float64(item.Quantity)
+	}
+	s.cart.Total = roundCents(total)
+	s.cart.UpdatedAt = s.now().UTC()
+}
+
+// ---- Orders ----
+
+// PlaceOrder turns the cart into a pending order, taking the items out of
+// stock and emptying the cart.
+func (s *Store) PlaceOrder(_ context.Context) (*store.Order, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.cart.Items) == 0 {
+		return nil, store.ErrCartEmpty
+	}
+	for _, item := range s.cart.Items {
+		product, ok := s.products[item.Product.ID]
+		if !ok || !product.IsActive {
+			return nil, fmt.Errorf("product %d: %w", item.Product.ID, store.ErrNotFound)
+		}
+		if product.Stock < item.Quantity {
+			return nil, fmt.Errorf("product %d: %d available: %w", product.ID, product.Stock, store.ErrInsufficientStock)
+		}
+	}
+
+	for _, item := range s.cart.Items {
+		s.products[item.Product.ID].Stock -= item.Quantity
+	}
+	s.nextID.order++
+	order := &store.Order{ID: s.nextID.order, Status: store.OrderPending, Total: s.cart.Total}
+	s.orders[order.ID] = order
+	s.items[order.ID] = s.cart.Items
+
+	s.cart.Items = nil
+	s.recalculateCart()
+
+	placed := *order
+	return &placed, nil
+}
+
+func (s *Store) ListOrders(_ context.Context, opts store.ListOptions) (*store.OrderPage, error) {
+	s.mu.Lock()
+	orders := make([]store.Order, 0, len(s.orders))
+	for _, o := range s.orders {
+		orders = append(orders, *o)
+	}
+	s.mu.Unlock()
+
+	// Newest first.
+	sort.Slice(orders, func(i, j int) bool { return orders[i].ID > orders[j].ID })
+	page, info := paginate(orders, opts)
+	return &store.OrderPage{Orders: page, PageInfo: info}, nil
+}
+
+func (s *Store) GetOrder(_ context.Context, id uint) (*store.Order, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	order, ok := s.orders[id]
+	if !ok {
+		return nil, fmt.Errorf("order %d: %w", id, store.ErrNotFound)
+	}
+	found := *order
+	return &found, nil
+}
+
+// CancelOrder cancels a pending order and returns its items to stock.
+func (s *Store) CancelOrder(_ context.Context, id uint) (*store.Order, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	order, ok := s.orders[id]
+	if !ok {
+		return nil, fmt.Errorf("order %d: %w", id, store.ErrNotFound)
+	}
+	if order.Status != store.OrderPending {
+		return nil, fmt.Errorf("order %d is %s: %w", id, order.Status, store.ErrOrderNotPending)
+	}
+	for _, item := range s.items[id] {
+		if product, ok := s.products[item.Product.ID]; ok {
+			product.Stock += item.Quantity
+		}
+	}
+	order.Status = store.OrderCancelled
+
+	cancelled := *order
+	return &cancelled, nil
+}
+
+// ---- Helpers ----
+
+func paginate[T any](items []T, opts store.ListOptions) ([]T, store.PageInfo) {
+	page, limit := opts.Page, opts.Limit
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 10
+	}
+	info := store.PageInfo{
+		Total:      len(items),
+		Page:       page,
+		Limit:      limit,
+		TotalPages: (len(items) + limit - 1) / limit,
+	}
+	start := (page - 1) * limit
+	if start >= len(items) {
+		return []T{}, info
+	}
+	return items[start:min(start+limit, len(items))], info
+}
+
+func roundCents(v float64) float64 {
+	return float64(int64(v*100+0.5)) / 100
+}
@@ -0,0 +1,265 @@
+// Package rest implements the store interfaces against the ecommerce REST
+// API using client.RestClient. Requests the API refuses fail with the
+// matching store error wrapped around the client.APIError.
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/trenchesdeveloper/mcp-server-store/internal/client"
+	"github.com/trenchesdeveloper/mcp-server-store/internal/store"
+)
+
+// Backend talks to the ecommerce API. It implements store.Catalog,
+// store.Cart, and store.Orders; cart and order calls are authenticated.
+type Backend struct {
+	httpClient *client.RestClient
+}
+
+var (
+	_ store.Catalog = (*Backend)(nil)
+	_ store.Cart    = (*Backend)(nil)
+	_ store.Orders  = (*Backend)(nil)
+)
+
+// New creates a Backend using httpClient.
+func New(httpClient *client.RestClient) *Backend {
+	return &Backend{httpClient: httpClient}
+}
+
+// ---- Wire format ----
+
+// envelope is the response body shape shared by every endpoint.
+type envelope[T any] struct {
+	Success bool           `json:"success"`
+	Message string         `json:"message"`
+	Data    T              `json:"data"`
+	Meta    store.PageInfo `json:"meta"`
+	Error   string         `json:"error"`
+}
+
+type cartSummary struct {
+	ID        uint      `json:"id"`
+	UserID    uint      `json:"user_id"`
+	Total     float64   `json:"total"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type cartContents struct {
+	cartSummary
+	CartItems []struct {
+		ID       uint          `json:"id"`
+		Product  store.Product `json:"product"`
+		Quantity int           `json:"quantity"`
+	} `json:"cart_items"`
+}
+
+type addToCartRequest struct {
+	ProductID uint `json:"product_id"`
+	Quantity  int  `json:"quantity"`
+}
+
+func decode[T any](body []byte, what string) (*envelope[T], error) {
+	var resp envelope[T]
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("parse %s response: %w", what, err)
+	}
+	return &resp, nil
+}
+
+// refusals maps the statuses an endpoint refuses a request with to the
+// store errors they stand for. Any endpoint's 404 is store.ErrNotFound. A
+// 422 is left alone: it means an Idempotency-Key was reused for another
+// request, not that the store refused this one.
+type refusals map[int]error
+
+var (
+	stockRefusals = refusals{http.StatusConflict: store.ErrInsufficientStock}
+	// POST /orders has no body, so a 400 can only be about the cart.
+	orderRefusals = refusals{
+		http.StatusBadRequest: store.ErrCartEmpty,
+		http.StatusConflict:   store.ErrInsufficientStock,
+	}
+	cancelRefusals = refusals{http.StatusConflict: store.ErrOrderNotPending}
+)
+
+// storeError adds the store error an API refusal stands for to err, so
+// callers can match it with errors.Is while the client.APIError stays in
+// the chain for its details. Other errors are returned unchanged.
+func storeError(err error, byStatus refusals) error {
+	var apiErr *client.APIError
+	if !errors.As(err, &apiErr) {
+		return err
+	}
+	if sentinel, ok := byStatus[apiErr.StatusCode]; ok {
+		return fmt.Errorf("%w: %w", sentinel, err)
+	}
+	if apiErr.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("%w: %w", store.ErrNotFound, err)
+	}
+	return err
+}
+
+func pageParams(opts store.ListOptions) map[string]string {
+	params := map[string]string{}
+	if opts.Page > 0 {
+		params["page"] = strconv.Itoa(opts.Page)
+	}
+	if opts.Limit > 0 {
+		params["limit"] = strconv.Itoa(opts.Limit)
+	}
+	return params
+}
+
+// ---- Catalog ----
+
+func (b *Backend) ListProducts(ctx context.Context, opts store.ListOptions) (*store.ProductPage, error) {
+	body, err := b.httpClient.WithContext(ctx).Get("/products", pageParams(opts))
+	if err != nil {
+		return nil, err
+	}
+	resp, err := decode[[]store.Product](body, "products")
+	if err != nil {
+		return nil, err
+	}
+	return &store.ProductPage{Products: resp.Data, PageInfo: resp.Meta}, nil
+}
+
+func (b *Backend) SearchProducts(ctx context.Context, query store.SearchQuery) (*store.ProductPage, error) {
+	params := pageParams(query.ListOptions)
+	params["q"] = query.Query
+	if query.CategoryID != 0 {
+		params["category_id"] = strconv.FormatUint(uint64(query.CategoryID), 10)
+	}
+	if query.MinPrice > 0 {
+		params["min_price"] = strconv.FormatFloat(query.MinPrice, 'f', -1, 64)
+	}
+	if query.MaxPrice > 0 {
+		params["max_price"] = strconv.FormatFloat(query.MaxPrice, 'f', -1, 64)
+	}
+
+	body, err := b.httpClient.WithContext(ctx).Get("/products/search", params)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := decode[[]store.Product](body, "search")
+	if err != nil {
+		return nil, err
+	}
+	return &store.ProductPage{Products: resp.Data, PageInfo: resp.Meta}, nil
+}
+
+func (b *Backend) GetProduct(ctx context.Context, id uint) (*store.Product, error) {
+	body, err := b.httpClient.WithContext(ctx).Get(fmt.Sprintf("/products/%d", id), nil)
+	if err != nil {
+		return nil, storeError(err, nil)
+	}
+	resp, err := decode[store.Product](body, "product")
+	if err != nil {
+		return nil, err
+	}
+	return &resp.Data, nil
+}
+
+// mutation returns the client for an authenticated request that changes
+// state, carrying the operation's idempotency key if ctx has one.
+func (b *Backend) mutation(ctx context.Context) *client.RestClient {
+	return b.httpClient.WithContext(ctx).WithToken().
+		WithIdempotencyKey(client.IdempotencyKeyFromContext(ctx))
+}
+
+// ---- Cart ----
+
+func (b *Backend) AddToCart(ctx context.Context, productID uint, quantity int) (*store.CartSummary, error) {
+	body, err := b.mutation(ctx).Post("/cart/items", addToCartRequest{
+		ProductID: productID,
+		Quantity:  quantity,
+	})
+	if err != nil {
+		return nil, storeError(err, stockRefusals)
+	}
+	resp, err := decode[cartSummary](body, "cart")
+	if err != nil {
+		return nil, err
+	}
+	summary := store.CartSummary(resp.Data)
+	return &summary, nil
+}
+
+func (b *Backend) ViewCart(ctx context.Context) (*store.CartContents, error) {
+	body, err := b.httpClient.WithContext(ctx).WithToken().Get("/cart", nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := decode[cartContents](body, "cart")
+	if err != nil {
+		return nil, err
+	}
+
+	contents := &store.CartContents{CartSummary: store.CartSummary(resp.Data.cartSummary)}
+	for _, item := range resp.Data.CartItems {
+		contents.Items = append(contents.Items, store.CartItem{
+			ID:       item.ID,
+			Product:  item.Product,
+			Quantity: item.Quantity,
+		})
+	}
+	return contents, nil
+}
+
+// ---- Orders ----
+
+func (b *Backend) PlaceOrder(ctx context.Context) (*store.Order, error) {
+	body, err := b.mutation(ctx).Post("/orders", nil)
+	if err != nil {
+		return nil, storeError(err, orderRefusals)
+	}
+	resp, err := decode[store.Order](body, "order")
+	if err != nil {
+		return nil, err
+	}
+	return &resp.Data, nil
+}
+
+func (b *Backend) ListOrders(ctx context.Context, opts store.ListOptions) (*store.OrderPage, error) {
+	body, err := b.httpClient.WithContext(ctx).WithToken().Get("/orders", pageParams(opts))
+	if err != nil {
+		return nil, err
+	}
+	resp, err := decode[[]store.Order](body, "orders")
+	if err != nil {
+		return nil, err
+	}
+	return &store.OrderPage{Orders: resp.Data, PageInfo: resp.Meta}, nil
+}
+
+func (b *Backend) GetOrder(ctx context.Context, id uint) (*store.Order, error) {
+	body, err := b.httpClient.WithContext(ctx).WithToken().Get(fmt.Sprintf("/orders/%d", id), nil)
+	if err != nil {
+		return nil, storeError(err, nil)
+	}
+	resp, err := decode[store.Order](body, "order")
+	if err != nil {
+		return nil, err
+	}
+	return &resp.Data, nil
+}
+
+func (b *Backend) CancelOrder(ctx context.Context, id uint) (*store.Order, error) {
+	body, err := b.mutation(ctx).Post(fmt.Sprintf("/orders/%d/cancel", id), nil)
+	if err != nil {
+		return nil, storeError(err, cancelRefusals)
+	}
+	resp, err := decode[store.Order](body, "cancel")
+	if err != nil {
+		return nil, err
+	}
+	return &resp.Data, nil
+}
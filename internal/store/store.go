@@ -0,0 +1,146 @@
+// Package store defines the backend the store tools run against: a product
+// catalog, a shopping cart, and orders, expressed as interfaces over domain
+// types. The rest subpackage implements them against the ecommerce REST API
+// and the memory subpackage keeps everything in process.
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Errors backends return for requests the store refuses.
+var (
+	ErrNotFound          = errors.New("not found")
+	ErrInsufficientStock = errors.New("insufficient stock")
+	ErrCartEmpty         = errors.New("cart is empty")
+	ErrOrderNotPending   = errors.New("only pending orders can be cancelled")
+)
+
+// Catalog lists and looks up products.
+type Catalog interface {
+	ListProducts(ctx context.Context, opts ListOptions) (*ProductPage, error)
+	SearchProducts(ctx context.Context, query SearchQuery) (*ProductPage, error)
+	GetProduct(ctx context.Context, id uint) (*Product, error)
+}
+
+// Cart manages the current customer's shopping cart.
+type Cart interface {
+	AddToCart(ctx context.Context, productID uint, quantity int) (*CartSummary, error)
+	ViewCart(ctx context.Context) (*CartContents, error)
+}
+
+// Orders places and manages the current customer's orders.
+type Orders interface {
+	PlaceOrder(ctx context.Context) (*Order, error)
+	ListOrders(ctx context.Context, opts ListOptions) (*OrderPage, error)
+	GetOrder(ctx context.Context, id uint) (*Order, error)
+	CancelOrder(ctx context.Context, id uint) (*Order, error)
+}
+
+// ---- Queries ----
+
+// ListOptions selects a page of results. Zero values use the backend's
+// defaults (page 1, 10 per page).
+type ListOptions struct {
+	Page  int
+	Limit int
+}
+
+// SearchQuery is a full-text product search with optional filters.
+// Zero-valued filters are not applied.
+type SearchQuery struct {
+	Query      string
+	CategoryID uint
+	MinPrice   float64
+	MaxPrice   float64
+	ListOptions
+}
+
+// PageInfo describes where a page sits in the full result set.
+type PageInfo struct {
+	Total      int `json:"total"`
+	Page       int `json:"page"`
+	Limit      int `json:"limit"`
+	TotalPages int `json:"total_pages"`
+}
+
+// ---- Catalog ----
+
+type Product struct {
+	ID          uint           `json:"id"`
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	Price       float64        `json:"price"`
+	Stock       int            `json:"stock"`
+	CategoryID  uint           `json:"category_id"`
+	SKU         string         `json:"sku"`
+	IsActive    bool           `json:"is_active"`
+	Category    Category       `json:"category"`
+	Images      []ProductImage `json:"images"`
+}
+
+type ProductImage struct {
+	ID        uint      `json:"id"`
+	URL       string    `json:"url"`
+	AltText   string    `json:"alt_text"`
+	IsPrimary bool      `json:"is_primary"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type Category struct {
+	ID          uint   `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	IsActive    bool   `json:"is_active"`
+}
+
+// ProductPage is one page of a product listing or search.
+type ProductPage struct {
+	Products []Product
+	PageInfo PageInfo
+}
+
+// ---- Cart ----
+
+// CartSummary is returned after changing the cart.
+type CartSummary struct {
+	ID        uint
+	UserID    uint
+	Total     float64
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// CartContents is the full cart with its items.
+type CartContents struct {
+	CartSummary
+	Items []CartItem
+}
+
+type CartItem struct {
+	ID       uint
+	Product  Product
+	Quantity int
+}
+
+// ---- Orders ----
+
+// Order statuses.
+const (
+	OrderPending   = "pending"
+	OrderCancelled = "cancelled"
+)
+
+type Order struct {
+	ID     uint    `json:"id"`
+	Status string  `json:"status"`
+	Total  float64 `json:"total"`
+}
+
+// OrderPage is one page of the customer's orders.
+type OrderPage struct {
+	Orders   []Order
+	PageInfo PageInfo
+}
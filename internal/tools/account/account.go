@@ -0,0 +1,142 @@
+package account
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/trenchesdeveloper/mcp-server-store/internal/auth"
+	"github.com/trenchesdeveloper/mcp-server-store/internal/mcp"
+	"github.com/trenchesdeveloper/mcp-server-store/internal/tools"
+)
+
+// AccountToolSet groups the tools that log the session in and out of the
+// ecommerce API.
+type AccountToolSet struct {
+	auth   *auth.Manager
+	logger *logrus.Logger
+}
+
+// NewAccountToolSet creates a new AccountToolSet with the given auth manager and logger.
+func NewAccountToolSet(manager *auth.Manager, logger *logrus.Logger) *AccountToolSet {
+	return &AccountToolSet{auth: manager, logger: logger}
+}
+
+// sessionAnnotations describes login and logout: they change the session's
+// credentials, so they are not read-only, but they leave store data alone
+// and repeating them changes nothing more.
+func sessionAnnotations() *mcp.ToolAnnotations {
+	destructive := false
+	return &mcp.ToolAnnotations{DestructiveHint: &destructive, IdempotentHint: true}
+}
+
+// ---- Login ----
+
+// LoginTool returns the tool definition for logging in.
+func (a *AccountToolSet) LoginTool() mcp.Tool {
+	return mcp.Tool{
+		Name: "login",
+		Description: "Logs in to the store so cart and order tools act on your account. " +
+			"Pass email and password, or a refresh_token from an earlier login. " +
+			"The session stays logged in, refreshing its token automatically, until logout.",
+		Annotations: sessionAnnotations(),
+		InputSchema: mcp.InputSchema{
+			Type: "object",
+			Properties: map[string]mcp.Property{
+				"email": {
+					Type:        "string",
+					Description: "The account's email address",
+				},
+				"password": {
+					Type:        "string",
+					Description: "The account's password",
+					Sensitive:   true,
+				},
+				"refresh_token": {
+					Type:        "string",
+					Description: "A refresh token to log in with instead of email and password",
+					Sensitive:   true,
+				},
+			},
+		},
+	}
+}
+
+// LoginHandler returns a handler that logs the session in.
+func (a *AccountToolSet) LoginHandler() mcp.ToolHandler {
+	return func(ctx context.Context, arguments map[string]interface{}) (*mcp.ToolCallResult, error) {
+		email := strings.TrimSpace(tools.StringArg(arguments, "email"))
+		password := tools.StringArg(arguments, "password")
+		refreshToken := strings.TrimSpace(tools.StringArg(arguments, "refresh_token"))
+
+		// Never log the arguments: they are credentials.
+		var status auth.Status
+		var err error
+		switch {
+		case refreshToken != "":
+			a.logger.Info("Logging in with refresh token")
+			status, err = a.auth.LoginWithRefreshToken(ctx, refreshToken)
+		case email != "" && password != "":
+			a.logger.WithField("email", email).Info("Logging in")
+			status, err = a.auth.Login(ctx, email, password)
+		default:
+			return nil, errors.New("email and password, or refresh_token, are required")
+		}
+		if err != nil {
+			a.logger.WithError(err).Warn("Login failed")
+			return nil, err
+		}
+
+		var sb strings.Builder
+		if status.Email != "" {
+			fmt.Fprintf(&sb, "Logged in as %s.", status.Email)
+		} else {
+			sb.WriteString("Logged in.")
+		}
+		if !status.ExpiresAt.IsZero() {
+			fmt.Fprintf(&sb, "\nAccess token expires at %s", status.ExpiresAt.Format(time.RFC3339))
+			if status.CanRefresh {
+				sb.WriteString(" and will be refreshed automatically")
+			}
+			sb.WriteString(".")
+		}
+
+		return &mcp.ToolCallResult{
+			Content: []mcp.Content{
+				mcp.NewTextContent(sb.String()),
+			},
+		}, nil
+	}
+}
+
+// ---- Logout ----
+
+// LogoutTool returns the tool definition for logging out.
+func (a *AccountToolSet) LogoutTool() mcp.Tool {
+	return mcp.Tool{
+		Name:        "logout",
+		Description: "Logs out of the store, discarding this session's tokens.",
+		Annotations: sessionAnnotations(),
+		InputSchema: mcp.InputSchema{
+			Type: "object",
+		},
+	}
+}
+
+// LogoutHandler returns a handler that logs the session out.
+func (a *AccountToolSet) LogoutHandler() mcp.ToolHandler {
+	return func(ctx context.Context, arguments map[string]interface{}) (*mcp.ToolCallResult, error) {
+		result := "You were not logged in."
+		if a.auth.Logout(ctx) {
+			result = "Logged out."
+		}
+		return &mcp.ToolCallResult{
+			Content: []mcp.Content{
+				mcp.NewTextContent(result),
+			},
+		}, nil
+	}
+}
@@ -0,0 +1,90 @@
+package tools
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/trenchesdeveloper/mcp-server-store/internal/store"
+)
+
+// Tool arguments are declared as strings, but some clients send JSON
+// numbers anyway, so the helpers below accept both. A missing or empty
+// argument yields the zero value.
+
+// StringArg returns a string argument.
+func StringArg(arguments map[string]interface{}, key string) string {
+	switch v := arguments[key].(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return ""
+}
+
+// IntArg parses an integer argument.
+func IntArg(arguments map[string]interface{}, key string) (int, error) {
+	s := StringArg(arguments, key)
+	if s == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %q is not an integer", key, s)
+	}
+	return n, nil
+}
+
+// UintArg parses a non-negative integer argument, such as an ID.
+func UintArg(arguments map[string]interface{}, key string) (uint, error) {
+	s := StringArg(arguments, key)
+	if s == "" {
+		return 0, nil
+	}
+	n, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %q is not a valid ID", key, s)
+	}
+	return uint(n), nil
+}
+
+// URIID parses the ID at the end of a resource URI read through a
+// template, such as "store://orders/{id}"; prefix is the URI up to it.
+func URIID(uri, prefix string) (uint, error) {
+	s, ok := strings.CutPrefix(uri, prefix)
+	if !ok {
+		return 0, fmt.Errorf("invalid resource URI %q", uri)
+	}
+	n, err := strconv.ParseUint(s, 10, 64)
+	if err != nil || n == 0 {
+		return 0, fmt.Errorf("invalid resource URI %q: %q is not a valid ID", uri, s)
+	}
+	return uint(n), nil
+}
+
+// FloatArg parses a decimal argument.
+func FloatArg(arguments map[string]interface{}, key string) (float64, error) {
+	s := StringArg(arguments, key)
+	if s == "" {
+		return 0, nil
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %q is not a number", key, s)
+	}
+	return f, nil
+}
+
+// PageArgs reads the optional page and limit arguments.
+func PageArgs(arguments map[string]interface{}) (store.ListOptions, error) {
+	page, err := IntArg(arguments, "page")
+	if err != nil {
+		return store.ListOptions{}, err
+	}
+	limit, err := IntArg(arguments, "limit")
+	if err != nil {
+		return store.ListOptions{}, err
+	}
+	return store.ListOptions{Page: page, Limit: limit}, nil
+}
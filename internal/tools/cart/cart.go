@@ -1,25 +1,30 @@
 package cart
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
-	"strconv"
 	"strings"
 
 	"github.com/sirupsen/logrus"
-	"github.com/trenchesdeveloper/mcp-server-store/internal/client"
 	"github.com/trenchesdeveloper/mcp-server-store/internal/mcp"
+	"github.com/trenchesdeveloper/mcp-server-store/internal/store"
+	"github.com/trenchesdeveloper/mcp-server-store/internal/tools"
 )
 
-// CartToolSet groups all cart-related tools and shares the HTTP client.
+// CartToolSet groups all cart-related tools and shares the cart backend.
 type CartToolSet struct {
-	httpClient *client.RestClient
-	logger     *logrus.Logger
+	cart        store.Cart
+	idempotency *tools.Idempotency
+	logger      *logrus.Logger
 }
 
-// NewCartToolSet creates a new CartToolSet with the given HTTP client and logger.
-func NewCartToolSet(httpClient *client.RestClient, logger *logrus.Logger) *CartToolSet {
-	return &CartToolSet{httpClient: httpClient, logger: logger}
+// NewCartToolSet creates a new CartToolSet with the given cart backend and logger.
+func NewCartToolSet(cart store.Cart, logger *logrus.Logger) *CartToolSet {
+	return &CartToolSet{
+		cart:        cart,
+		idempotency: tools.NewIdempotency(tools.IdempotencyTTL, logger),
+		logger:      logger,
+	}
 }
 
 // ---- Add to Cart ----
@@ -40,49 +45,36 @@ func (c *CartToolSet) AddToCartTool() mcp.Tool {
 					Type:        "string",
 					Description: "The quantity to add (default: 1)",
 				},
+				tools.IdempotencyKeyArg: tools.IdempotencyKeyProperty(),
 			},
 			Required: []string{"product_id"},
 		},
 	}
 }
 
-// AddToCartHandler returns a handler that adds a product to the cart.
+// AddToCartHandler returns a handler that adds a product to the cart. A
+// repeated call returns the original result; see tools.Idempotency.
 func (c *CartToolSet) AddToCartHandler() mcp.ToolHandler {
-	return func(arguments map[string]interface{}) (*mcp.ToolCallResult, error) {
+	return c.idempotency.Wrap("add_to_cart", func(ctx context.Context, arguments map[string]interface{}) (*mcp.ToolCallResult, error) {
 		c.logger.WithField("arguments", arguments).Info("Adding product to cart")
 
-		productIDStr, ok := arguments["product_id"].(string)
-		if !ok || productIDStr == "" {
-			return nil, fmt.Errorf("product_id is required")
-		}
-
-		productID, err := strconv.ParseUint(productIDStr, 10, 64)
+		productID, err := tools.UintArg(arguments, "product_id")
 		if err != nil {
-			return nil, fmt.Errorf("invalid product_id: %w", err)
+			return nil, err
 		}
-
-		quantity := 1
-		if qtyStr, ok := arguments["quantity"].(string); ok && qtyStr != "" {
-			if q, err := strconv.Atoi(qtyStr); err == nil && q > 0 {
-				quantity = q
-			}
+		if productID == 0 {
+			return nil, fmt.Errorf("product_id is required")
 		}
 
-		reqBody := AddToCartRequest{
-			ProductID: uint(productID),
-			Quantity:  quantity,
+		quantity := 1
+		if q, err := tools.IntArg(arguments, "quantity"); err == nil && q > 0 {
+			quantity = q
 		}
 
-		body, err := c.httpClient.WithToken().Post("/cart/items", reqBody)
+		summary, err := c.cart.AddToCart(ctx, productID, quantity)
 		if err != nil {
 			c.logger.WithError(err).Error("Failed to add product to cart")
-			return nil, fmt.Errorf("failed to add to cart: %w", err)
-		}
-
-		var resp AddToCartResponse
-		if err := json.Unmarshal(body, &resp); err != nil {
-			c.logger.WithError(err).Error("Failed to parse cart response")
-			return nil, fmt.Errorf("failed to parse cart response: %w", err)
+			return nil, tools.Failed("add to cart", err)
 		}
 
 		c.logger.WithFields(logrus.Fields{
@@ -91,14 +83,14 @@ func (c *CartToolSet) AddToCartHandler() mcp.ToolHandler {
 		}).Info("Product added to cart")
 
 		result := fmt.Sprintf("Added %d x product #%d to cart.\nCart ID: %d, Total: $%.2f",
-			quantity, productID, resp.Data.ID, resp.Data.Total)
+			quantity, productID, summary.ID, summary.Total)
 
 		return &mcp.ToolCallResult{
 			Content: []mcp.Content{
 				mcp.NewTextContent(result),
 			},
 		}, nil
-	}
+	})
 }
 
 // ---- View Cart ----
@@ -108,6 +100,7 @@ func (c *CartToolSet) ViewCartTool() mcp.Tool {
 	return mcp.Tool{
 		Name:        "view_cart",
 		Description: "Views the current shopping cart contents, including all items and the total. Requires authentication.",
+		Annotations: &mcp.ToolAnnotations{ReadOnlyHint: true},
 		InputSchema: mcp.InputSchema{
 			Type: "object",
 		},
@@ -116,34 +109,28 @@ func (c *CartToolSet) ViewCartTool() mcp.Tool {
 
 // ViewCartHandler returns a handler that fetches the current cart.
 func (c *CartToolSet) ViewCartHandler() mcp.ToolHandler {
-	return func(arguments map[string]interface{}) (*mcp.ToolCallResult, error) {
+	return func(ctx context.Context, arguments map[string]interface{}) (*mcp.ToolCallResult, error) {
 		c.logger.Info("Viewing cart")
 
-		body, err := c.httpClient.WithToken().Get("/cart", nil)
+		contents, err := c.cart.ViewCart(ctx)
 		if err != nil {
 			c.logger.WithError(err).Error("Failed to view cart")
-			return nil, fmt.Errorf("failed to view cart: %w", err)
-		}
-
-		var resp ViewCartResponse
-		if err := json.Unmarshal(body, &resp); err != nil {
-			c.logger.WithError(err).Error("Failed to parse cart response")
-			return nil, fmt.Errorf("failed to parse cart response: %w", err)
+			return nil, tools.Failed("view cart", err)
 		}
 
-		c.logger.WithField("items", len(resp.Data.CartItems)).Info("Cart retrieved")
+		c.logger.WithField("items", len(contents.Items)).Info("Cart retrieved")
 
 		var sb strings.Builder
 
-		if len(resp.Data.CartItems) == 0 {
+		if len(contents.Items) == 0 {
 			sb.WriteString("Your cart is empty.")
 		} else {
-			fmt.Fprintf(&sb, "Shopping Cart (ID: %d)\n\n", resp.Data.ID)
-			for i, item := range resp.Data.CartItems {
+			fmt.Fprintf(&sb, "Shopping Cart (ID: %d)\n\n", contents.ID)
+			for i, item := range contents.Items {
 				fmt.Fprintf(&sb, "%d. %s (ID: %d) - $%.2f\n",
 					i+1, item.Product.Name, item.Product.ID, item.Product.Price)
 			}
-			fmt.Fprintf(&sb, "\nTotal: $%.2f\n", resp.Data.Total)
+			fmt.Fprintf(&sb, "\nTotal: $%.2f\n", contents.Total)
 		}
 
 		return &mcp.ToolCallResult{
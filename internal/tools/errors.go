@@ -0,0 +1,65 @@
+package tools
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/trenchesdeveloper/mcp-server-store/internal/client"
+)
+
+// Failed wraps an error from the store as the tool's error, so the result
+// tells the agent what went wrong and what to do about it. action names
+// what the tool was doing, e.g. "add to cart".
+func Failed(action string, err error) error {
+	var apiErr *client.APIError
+	if !errors.As(err, &apiErr) {
+		return fmt.Errorf("failed to %s: %w", action, err)
+	}
+
+	reason := apiErr.Message
+	switch {
+	case reason == "":
+		reason = apiErr.Detail
+	case apiErr.Detail != "" && apiErr.Detail != reason:
+		reason += ": " + apiErr.Detail
+	}
+	if reason == "" {
+		reason = "no details given"
+	}
+
+	var hint string
+	switch code := apiErr.StatusCode; {
+	case code == http.StatusUnauthorized:
+		hint = "The store did not accept your credentials. Log in with the login tool, then try again."
+	case code == http.StatusForbidden:
+		hint = "Your account is not allowed to do this."
+	case code == http.StatusNotFound:
+		hint = "Check the ID; the list and search tools show valid ones."
+	case code == http.StatusConflict:
+		hint = "The request conflicts with the current state; fetch it again before retrying."
+	case code == http.StatusTooManyRequests:
+		hint = "The store is rate limiting requests. Wait a moment, then try again."
+	case code >= 500:
+		hint = "The store API failed. Try again later."
+	case code >= 400:
+		hint = "The store rejected the request; change it before trying again."
+	default:
+		hint = "The store reported a failure."
+	}
+
+	msg := fmt.Sprintf("failed to %s: %s (%s). %s", action, reason, apiErr.Status(), hint)
+	if apiErr.RequestID != "" {
+		msg += " Request ID: " + apiErr.RequestID + "."
+	}
+	return &toolError{msg: msg, err: err}
+}
+
+// toolError keeps the underlying error for errors.Is and errors.As.
+type toolError struct {
+	msg string
+	err error
+}
+
+func (e *toolError) Error() string { return e.msg }
+func (e *toolError) Unwrap() error { return e.err }
@@ -0,0 +1,170 @@
+package tools
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/trenchesdeveloper/mcp-server-store/internal/client"
+	"github.com/trenchesdeveloper/mcp-server-store/internal/jsonrpc"
+	"github.com/trenchesdeveloper/mcp-server-store/internal/mcp"
+)
+
+// ---- Idempotency ----
+
+// IdempotencyKeyArg is the optional argument of mutating tools naming the
+// operation, so that repeating the call does not repeat its effect.
+const IdempotencyKeyArg = "idempotency_key"
+
+// IdempotencyTTL is how long a mutating call's result answers repeats.
+const IdempotencyTTL = 10 * time.Minute
+
+// IdempotencyKeyProperty declares IdempotencyKeyArg in a tool's schema.
+func IdempotencyKeyProperty() mcp.Property {
+	return mcp.Property{
+		Type: "string",
+		Description: "Optional key identifying this operation. Repeating a call with the same key " +
+			"returns the original result instead of doing it again, so set one before retrying after a timeout.",
+	}
+}
+
+// Idempotency makes mutating tools safe to retry. Each call is keyed by
+// its idempotency_key argument within the caller's session. A repeat of a
+// call that succeeded in the last TTL gets the original result; a repeat
+// of one still running waits for it. The key also reaches the store API as
+// the Idempotency-Key header, scoped by tool but not by session, since the
+// API scopes keys per user itself. That lets the client retry the POST,
+// keeps a call repeated after it failed partway from being carried out
+// twice, and catches a repeat from a new session, such as a client
+// reconnecting after a timeout.
+//
+// A call without a key is keyed by its JSON-RPC request ID instead, which
+// only catches the same request delivered twice: a client retrying sends a
+// new ID. Such keys stay local, since they are only unique within a run of
+// the server, and the POST is not retried.
+type Idempotency struct {
+	ttl    time.Duration
+	run    string // random, scopes keys derived from request IDs
+	logger *logrus.Logger
+	now    func() time.Time
+
+	mu    sync.Mutex
+	calls map[string]*idempotentCall
+}
+
+// idempotentCall is a call in progress or a result kept for repeats.
+type idempotentCall struct {
+	done      chan struct{}
+	arguments string // digest of the arguments, to catch reused keys
+	result    *mcp.ToolCallResult
+	err       error
+	expires   time.Time // zero while running
+}
+
+// NewIdempotency creates an Idempotency keeping results for ttl.
+func NewIdempotency(ttl time.Duration, logger *logrus.Logger) *Idempotency {
+	return &Idempotency{
+		ttl:    ttl,
+		run:    rand.Text(),
+		logger: logger,
+		now:    time.Now,
+		calls:  make(map[string]*idempotentCall),
+	}
+}
+
+// Wrap returns handler made idempotent. tool names the tool it serves.
+// Notifications without an idempotency_key argument are passed through.
+func (i *Idempotency) Wrap(tool string, handler mcp.ToolHandler) mcp.ToolHandler {
+	return func(ctx context.Context, arguments map[string]interface{}) (*mcp.ToolCallResult, error) {
+		session := mcp.SessionIDFromContext(ctx)
+		var key, scoped string
+		upstream := ctx
+		if k := StringArg(arguments, IdempotencyKeyArg); k != "" {
+			key = fmt.Sprintf("idempotency key %q", k)
+			scoped = scopedKey(session, tool, k)
+			upstream = client.ContextWithIdempotencyKey(ctx, scopedKey(tool, k))
+		} else if id, ok := jsonrpc.RequestIDFromContext(ctx); ok {
+			key = fmt.Sprintf("request ID %v", id)
+			scoped = scopedKey(session, i.run, tool, key)
+		} else {
+			return handler(ctx, arguments)
+		}
+		digest := argumentsDigest(arguments)
+
+		i.mu.Lock()
+		i.sweep()
+		call, repeated := i.calls[scoped]
+		if !repeated {
+			call = &idempotentCall{done: make(chan struct{}), arguments: digest}
+			i.calls[scoped] = call
+		}
+		i.mu.Unlock()
+
+		if repeated {
+			if call.arguments != digest {
+				return nil, fmt.Errorf("%s was already used with other arguments for %s; use a new idempotency key", key, tool)
+			}
+			select {
+			case <-call.done:
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+			i.logger.WithFields(logrus.Fields{
+				"tool":    tool,
+				"session": session,
+				"key":     key,
+			}).Info("Repeated tool call answered with the original result")
+			return call.result, call.err
+		}
+
+		result, err := handler(upstream, arguments)
+
+		i.mu.Lock()
+		call.result, call.err = result, err
+		if err != nil {
+			// Let a retry run again; with the caller's key, the API
+			// deduplicates it.
+			delete(i.calls, scoped)
+		} else {
+			call.expires = i.now().Add(i.ttl)
+		}
+		close(call.done)
+		i.mu.Unlock()
+		return result, err
+	}
+}
+
+// sweep drops expired results. i.mu must be held.
+func (i *Idempotency) sweep() {
+	now := i.now()
+	for key, call := range i.calls {
+		if !call.expires.IsZero() && now.After(call.expires) {
+			delete(i.calls, key)
+		}
+	}
+}
+
+// scopedKey turns a key into one unique within its scope, such as a
+// session and tool for the results kept here, or a tool for the key the
+// store API sees.
+func scopedKey(scope ...string) string {
+	sum := sha256.Sum256([]byte(strings.Join(scope, "\x00")))
+	return hex.EncodeToString(sum[:16])
+}
+
+// argumentsDigest identifies the arguments of a call, apart from its key.
+func argumentsDigest(arguments map[string]interface{}) string {
+	arguments = maps.Clone(arguments)
+	delete(arguments, IdempotencyKeyArg)
+	data, _ := json.Marshal(arguments) // map keys are sorted
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -1,24 +1,75 @@
 package orders
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"strings"
 
 	"github.com/sirupsen/logrus"
-	"github.com/trenchesdeveloper/mcp-server-store/internal/client"
 	"github.com/trenchesdeveloper/mcp-server-store/internal/mcp"
+	"github.com/trenchesdeveloper/mcp-server-store/internal/store"
+	"github.com/trenchesdeveloper/mcp-server-store/internal/tools"
 )
 
-// OrderToolSet groups all order-related tools and shares the HTTP client.
+// OrderToolSet groups all order-related tools and shares the orders backend.
 type OrderToolSet struct {
-	httpClient *client.RestClient
-	logger     *logrus.Logger
+	orders      store.Orders
+	idempotency *tools.Idempotency
+	logger      *logrus.Logger
 }
 
-// NewOrderToolSet creates a new OrderToolSet with the given HTTP client and logger.
-func NewOrderToolSet(httpClient *client.RestClient, logger *logrus.Logger) *OrderToolSet {
-	return &OrderToolSet{httpClient: httpClient, logger: logger}
+// NewOrderToolSet creates a new OrderToolSet with the given orders backend and logger.
+func NewOrderToolSet(orders store.Orders, logger *logrus.Logger) *OrderToolSet {
+	return &OrderToolSet{
+		orders:      orders,
+		idempotency: tools.NewIdempotency(tools.IdempotencyTTL, logger),
+		logger:      logger,
+	}
+}
+
+// orderURIPrefix is what OrderURI puts before the ID.
+const orderURIPrefix = "store://orders/"
+
+// OrderURI returns the resource URI identifying an order.
+func OrderURI(id uint) string {
+	return fmt.Sprintf("%s%d", orderURIPrefix, id)
+}
+
+// ---- Order Resources ----
+
+// OrderTemplate returns the resource template of the order URIs that
+// list_orders links to.
+func (o *OrderToolSet) OrderTemplate() mcp.ResourceTemplate {
+	return mcp.ResourceTemplate{
+		URITemplate: orderURIPrefix + "{id}",
+		Name:        "order",
+		Description: "One of the current user's orders, as JSON. Requires authentication.",
+		MimeType:    "application/json",
+	}
+}
+
+// OrderResourceHandler returns a handler that reads an order by URI.
+func (o *OrderToolSet) OrderResourceHandler() mcp.ResourceHandler {
+	return func(ctx context.Context, uri string) (*mcp.ReadResourceResult, error) {
+		id, err := tools.URIID(uri, orderURIPrefix)
+		if err != nil {
+			return nil, err
+		}
+		order, err := o.orders.GetOrder(ctx, id)
+		if err != nil {
+			return nil, tools.Failed("get order", err)
+		}
+		data, err := json.MarshalIndent(order, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode order: %w", err)
+		}
+		return &mcp.ReadResourceResult{
+			Contents: []mcp.ResourceContents{
+				mcp.NewTextResourceContents(uri, "application/json", string(data)),
+			},
+		}, nil
+	}
 }
 
 // ---- Create Order ----
@@ -30,41 +81,43 @@ func (o *OrderToolSet) CreateOrderTool() mcp.Tool {
 		Description: "Creates an order from the current shopping cart. Requires authentication.",
 		InputSchema: mcp.InputSchema{
 			Type: "object",
+			Properties: map[string]mcp.Property{
+				tools.IdempotencyKeyArg: tools.IdempotencyKeyProperty(),
+			},
 		},
 	}
 }
 
-// CreateOrderHandler returns a handler that creates an order.
+// CreateOrderHandler returns a handler that creates an order. A repeated
+// call returns the original order instead of placing another; see
+// tools.Idempotency.
 func (o *OrderToolSet) CreateOrderHandler() mcp.ToolHandler {
-	return func(arguments map[string]interface{}) (*mcp.ToolCallResult, error) {
+	return o.idempotency.Wrap("place_order", func(ctx context.Context, arguments map[string]interface{}) (*mcp.ToolCallResult, error) {
 		o.logger.Info("Creating order from cart")
 
-		body, err := o.httpClient.WithToken().Post("/orders", nil)
+		order, err := o.orders.PlaceOrder(ctx)
 		if err != nil {
 			o.logger.WithError(err).Error("Failed to create order")
-			return nil, fmt.Errorf("failed to create order: %w", err)
-		}
-
-		var resp OrderResponse
-		if err := json.Unmarshal(body, &resp); err != nil {
-			o.logger.WithError(err).Error("Failed to parse order response")
-			return nil, fmt.Errorf("failed to parse order response: %w", err)
+			return nil, tools.Failed("create order", err)
 		}
 
 		o.logger.WithFields(logrus.Fields{
-			"order_id": resp.Data.ID,
-			"total":    resp.Data.Total,
+			"order_id": order.ID,
+			"total":    order.Total,
 		}).Info("Order created")
 
 		result := fmt.Sprintf("Order #%d created successfully!\n- Status: %s\n- Total: $%.2f",
-			resp.Data.ID, resp.Data.Status, resp.Data.Total)
+			order.ID, order.Status, order.Total)
 
+		// Confirmations must reach the end user, not just the model.
 		return &mcp.ToolCallResult{
 			Content: []mcp.Content{
-				mcp.NewTextContent(result),
+				mcp.NewTextContent(result).
+					WithAudience(mcp.RoleUser, mcp.RoleAssistant).
+					WithPriority(1),
 			},
 		}, nil
-	}
+	})
 }
 
 // ---- List Orders ----
@@ -74,6 +127,7 @@ func (o *OrderToolSet) ListOrdersTool() mcp.Tool {
 	return mcp.Tool{
 		Name:        "list_orders",
 		Description: "Lists all orders for the current user with pagination. Requires authentication.",
+		Annotations: &mcp.ToolAnnotations{ReadOnlyHint: true},
 		InputSchema: mcp.InputSchema{
 			Type: "object",
 			Properties: map[string]mcp.Property{
@@ -92,42 +146,39 @@ func (o *OrderToolSet) ListOrdersTool() mcp.Tool {
 
 // ListOrdersHandler returns a handler that lists the user's orders.
 func (o *OrderToolSet) ListOrdersHandler() mcp.ToolHandler {
-	return func(arguments map[string]interface{}) (*mcp.ToolCallResult, error) {
+	return func(ctx context.Context, arguments map[string]interface{}) (*mcp.ToolCallResult, error) {
 		o.logger.Info("Listing orders")
 
-		params := map[string]string{}
-		for _, key := range []string{"page", "limit"} {
-			if val, ok := arguments[key].(string); ok && val != "" {
-				params[key] = val
-			}
+		opts, err := tools.PageArgs(arguments)
+		if err != nil {
+			return nil, err
 		}
 
-		body, err := o.httpClient.WithToken().Get("/orders", params)
+		page, err := o.orders.ListOrders(ctx, opts)
 		if err != nil {
 			o.logger.WithError(err).Error("Failed to list orders")
-			return nil, fmt.Errorf("failed to list orders: %w", err)
+			return nil, tools.Failed("list orders", err)
 		}
 
-		var resp ListOrdersResponse
-		if err := json.Unmarshal(body, &resp); err != nil {
-			o.logger.WithError(err).Error("Failed to parse orders response")
-			return nil, fmt.Errorf("failed to parse orders response: %w", err)
-		}
-
-		o.logger.WithField("count", len(resp.Data)).Info("Orders listed")
+		o.logger.WithField("count", len(page.Orders)).Info("Orders listed")
 
 		var sb strings.Builder
-		fmt.Fprintf(&sb, "Found %d orders\n\n", len(resp.Data))
+		fmt.Fprintf(&sb, "Found %d orders\n\n", len(page.Orders))
 
-		for i, order := range resp.Data {
+		links := make([]mcp.Content, 0, len(page.Orders))
+		for i, order := range page.Orders {
 			fmt.Fprintf(&sb, "%d. Order #%d - %s - $%.2f\n",
 				i+1, order.ID, order.Status, order.Total)
+			links = append(links, mcp.NewResourceLinkContent(
+				OrderURI(order.ID),
+				fmt.Sprintf("Order #%d", order.ID),
+				fmt.Sprintf("%s order totalling $%.2f", order.Status, order.Total),
+				"application/json",
+			))
 		}
 
 		return &mcp.ToolCallResult{
-			Content: []mcp.Content{
-				mcp.NewTextContent(sb.String()),
-			},
+			Content: append([]mcp.Content{mcp.NewTextContent(sb.String())}, links...),
 		}, nil
 	}
 }
@@ -146,43 +197,44 @@ func (o *OrderToolSet) CancelOrderTool() mcp.Tool {
 					Type:        "string",
 					Description: "The order ID to cancel",
 				},
+				tools.IdempotencyKeyArg: tools.IdempotencyKeyProperty(),
 			},
 			Required: []string{"id"},
 		},
 	}
 }
 
-// CancelOrderHandler returns a handler that cancels an order.
+// CancelOrderHandler returns a handler that cancels an order. A repeated
+// call returns the original result; see tools.Idempotency.
 func (o *OrderToolSet) CancelOrderHandler() mcp.ToolHandler {
-	return func(arguments map[string]interface{}) (*mcp.ToolCallResult, error) {
-		id, ok := arguments["id"].(string)
-		if !ok || id == "" {
+	return o.idempotency.Wrap("cancel_order", func(ctx context.Context, arguments map[string]interface{}) (*mcp.ToolCallResult, error) {
+		id, err := tools.UintArg(arguments, "id")
+		if err != nil {
+			return nil, err
+		}
+		if id == 0 {
 			return nil, fmt.Errorf("order id is required")
 		}
 
 		o.logger.WithField("id", id).Info("Cancelling order")
 
-		body, err := o.httpClient.WithToken().Post("/orders/"+id+"/cancel", nil)
+		order, err := o.orders.CancelOrder(ctx, id)
 		if err != nil {
 			o.logger.WithError(err).Error("Failed to cancel order")
-			return nil, fmt.Errorf("failed to cancel order: %w", err)
-		}
-
-		var resp OrderResponse
-		if err := json.Unmarshal(body, &resp); err != nil {
-			o.logger.WithError(err).Error("Failed to parse cancel response")
-			return nil, fmt.Errorf("failed to parse cancel response: %w", err)
+			return nil, tools.Failed("cancel order", err)
 		}
 
-		o.logger.WithField("order_id", resp.Data.ID).Info("Order cancelled")
+		o.logger.WithField("order_id", order.ID).Info("Order cancelled")
 
 		result := fmt.Sprintf("Order #%d cancelled.\n- Status: %s\n- Total: $%.2f",
-			resp.Data.ID, resp.Data.Status, resp.Data.Total)
+			order.ID, order.Status, order.Total)
 
 		return &mcp.ToolCallResult{
 			Content: []mcp.Content{
-				mcp.NewTextContent(result),
+				mcp.NewTextContent(result).
+					WithAudience(mcp.RoleUser, mcp.RoleAssistant).
+					WithPriority(1),
 			},
 		}, nil
-	}
+	})
 }
@@ -1,6 +1,7 @@
 package tools
 
 import (
+	"context"
 	"github.com/trenchesdeveloper/mcp-server-store/internal/mcp"
 )
 
@@ -9,6 +10,7 @@ func PingTool() mcp.Tool {
 	return mcp.Tool{
 		Name:        "ping",
 		Description: "A simple ping tool that returns pong.",
+		Annotations: &mcp.ToolAnnotations{ReadOnlyHint: true},
 		InputSchema: mcp.InputSchema{
 			Type: "object",
 		},
@@ -17,7 +19,7 @@ func PingTool() mcp.Tool {
 
 // PingHandler returns a tool handler that simply returns "pong".
 func PingHandler() mcp.ToolHandler {
-	return func(arguments map[string]interface{}) (*mcp.ToolCallResult, error) {
+	return func(_ context.Context, arguments map[string]interface{}) (*mcp.ToolCallResult, error) {
 		return &mcp.ToolCallResult{
 			Content: []mcp.Content{
 				mcp.NewTextContent("pong"),
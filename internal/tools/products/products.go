@@ -1,24 +1,26 @@
 package products
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"strings"
 
 	"github.com/sirupsen/logrus"
-	"github.com/trenchesdeveloper/mcp-server-store/internal/client"
 	"github.com/trenchesdeveloper/mcp-server-store/internal/mcp"
+	"github.com/trenchesdeveloper/mcp-server-store/internal/store"
+	"github.com/trenchesdeveloper/mcp-server-store/internal/tools"
 )
 
-// ProductToolSet groups all product-related tools and shares the HTTP client.
+// ProductToolSet groups all product-related tools and shares the catalog backend.
 type ProductToolSet struct {
-	httpClient *client.RestClient
-	logger     *logrus.Logger
+	catalog store.Catalog
+	logger  *logrus.Logger
 }
 
-// NewProductToolSet creates a new ProductToolSet with the given HTTP client and logger.
-func NewProductToolSet(httpClient *client.RestClient, logger *logrus.Logger) *ProductToolSet {
-	return &ProductToolSet{httpClient: httpClient, logger: logger}
+// NewProductToolSet creates a new ProductToolSet with the given catalog and logger.
+func NewProductToolSet(catalog store.Catalog, logger *logrus.Logger) *ProductToolSet {
+	return &ProductToolSet{catalog: catalog, logger: logger}
 }
 
 // ---- List Products ----
@@ -28,6 +30,7 @@ func (p *ProductToolSet) ListTool() mcp.Tool {
 	return mcp.Tool{
 		Name:        "list_products",
 		Description: "Lists products from the ecommerce store. Supports optional pagination with page and limit parameters.",
+		Annotations: &mcp.ToolAnnotations{ReadOnlyHint: true},
 		InputSchema: mcp.InputSchema{
 			Type: "object",
 			Properties: map[string]mcp.Property{
@@ -46,34 +49,24 @@ func (p *ProductToolSet) ListTool() mcp.Tool {
 
 // ListHandler returns a handler that fetches products from the ecommerce API.
 func (p *ProductToolSet) ListHandler() mcp.ToolHandler {
-	return func(arguments map[string]interface{}) (*mcp.ToolCallResult, error) {
+	return func(ctx context.Context, arguments map[string]interface{}) (*mcp.ToolCallResult, error) {
 		p.logger.WithField("arguments", arguments).Info("Listing products")
 
-		params := map[string]string{}
-
-		if page, ok := arguments["page"].(string); ok && page != "" {
-			params["page"] = page
-		}
-		if limit, ok := arguments["limit"].(string); ok && limit != "" {
-			params["limit"] = limit
+		opts, err := tools.PageArgs(arguments)
+		if err != nil {
+			return nil, err
 		}
 
-		body, err := p.httpClient.Get("/products", params)
+		page, err := p.catalog.ListProducts(ctx, opts)
 		if err != nil {
 			p.logger.WithError(err).Error("Failed to list products")
-			return nil, fmt.Errorf("failed to list products: %w", err)
-		}
-
-		var resp ProductResponse
-		if err := json.Unmarshal(body, &resp); err != nil {
-			p.logger.WithError(err).Error("Failed to parse products response")
-			return nil, fmt.Errorf("failed to parse products response: %w", err)
+			return nil, tools.Failed("list products", err)
 		}
 
 		var sb strings.Builder
-		fmt.Fprintf(&sb, "Found %d products\n\n", len(resp.Data))
+		fmt.Fprintf(&sb, "Found %d products\n\n", len(page.Products))
 
-		for i, product := range resp.Data {
+		for i, product := range page.Products {
 			fmt.Fprintf(&sb, "%d. %s\n", i+1, formatProduct(product))
 		}
 
@@ -88,7 +81,51 @@ func (p *ProductToolSet) ListHandler() mcp.ToolHandler {
 	}
 }
 
-func formatProduct(p Product) string {
+// productURIPrefix is what ProductURI puts before the ID.
+const productURIPrefix = "store://products/"
+
+// ProductURI returns the resource URI identifying a product.
+func ProductURI(id uint) string {
+	return fmt.Sprintf("%s%d", productURIPrefix, id)
+}
+
+// ---- Product Resources ----
+
+// ProductTemplate returns the resource template of the product URIs that
+// get_product embeds.
+func (p *ProductToolSet) ProductTemplate() mcp.ResourceTemplate {
+	return mcp.ResourceTemplate{
+		URITemplate: productURIPrefix + "{id}",
+		Name:        "product",
+		Description: "A product in the catalog, as JSON.",
+		MimeType:    "application/json",
+	}
+}
+
+// ProductResourceHandler returns a handler that reads a product by URI.
+func (p *ProductToolSet) ProductResourceHandler() mcp.ResourceHandler {
+	return func(ctx context.Context, uri string) (*mcp.ReadResourceResult, error) {
+		id, err := tools.URIID(uri, productURIPrefix)
+		if err != nil {
+			return nil, err
+		}
+		product, err := p.catalog.GetProduct(ctx, id)
+		if err != nil {
+			return nil, tools.Failed("get product", err)
+		}
+		data, err := json.MarshalIndent(product, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode product: %w", err)
+		}
+		return &mcp.ReadResourceResult{
+			Contents: []mcp.ResourceContents{
+				mcp.NewTextResourceContents(uri, "application/json", string(data)),
+			},
+		}, nil
+	}
+}
+
+func formatProduct(p store.Product) string {
 	name := p.Name
 	price := p.Price
 	id := p.ID
@@ -103,6 +140,7 @@ func (p *ProductToolSet) SearchTool() mcp.Tool {
 	return mcp.Tool{
 		Name:        "search_products",
 		Description: "Full-text search products by name, SKU, and description with optional filters for category, price range, and pagination.",
+		Annotations: &mcp.ToolAnnotations{ReadOnlyHint: true},
 		InputSchema: mcp.InputSchema{
 			Type: "object",
 			Properties: map[string]mcp.Property{
@@ -138,35 +176,26 @@ func (p *ProductToolSet) SearchTool() mcp.Tool {
 
 // SearchHandler returns a handler that searches products via the ecommerce API.
 func (p *ProductToolSet) SearchHandler() mcp.ToolHandler {
-	return func(arguments map[string]interface{}) (*mcp.ToolCallResult, error) {
+	return func(ctx context.Context, arguments map[string]interface{}) (*mcp.ToolCallResult, error) {
 		p.logger.WithField("arguments", arguments).Info("Searching products")
 
-		params := map[string]string{}
-
-		for _, key := range []string{"q", "page", "limit", "category_id", "min_price", "max_price"} {
-			if val, ok := arguments[key].(string); ok && val != "" {
-				params[key] = val
-			}
+		query, err := searchArgs(arguments)
+		if err != nil {
+			return nil, err
 		}
 
-		body, err := p.httpClient.Get("/products/search", params)
+		page, err := p.catalog.SearchProducts(ctx, query)
 		if err != nil {
 			p.logger.WithError(err).Error("Failed to search products")
-			return nil, fmt.Errorf("failed to search products: %w", err)
-		}
-
-		var resp ProductResponse
-		if err := json.Unmarshal(body, &resp); err != nil {
-			p.logger.WithError(err).Error("Failed to parse search response")
-			return nil, fmt.Errorf("failed to parse search response: %w", err)
+			return nil, tools.Failed("search products", err)
 		}
 
-		p.logger.WithField("count", len(resp.Data)).Info("Product search completed")
+		p.logger.WithField("count", len(page.Products)).Info("Product search completed")
 
 		var sb strings.Builder
-		fmt.Fprintf(&sb, "Found %d products matching '%s'\n\n", len(resp.Data), params["q"])
+		fmt.Fprintf(&sb, "Found %d products matching '%s'\n\n", len(page.Products), query.Query)
 
-		for i, product := range resp.Data {
+		for i, product := range page.Products {
 			fmt.Fprintf(&sb, "%d. %s\n", i+1, formatProduct(product))
 		}
 
@@ -181,6 +210,26 @@ func (p *ProductToolSet) SearchHandler() mcp.ToolHandler {
 	}
 }
 
+func searchArgs(arguments map[string]interface{}) (store.SearchQuery, error) {
+	var query store.SearchQuery
+	var err error
+
+	query.Query = tools.StringArg(arguments, "q")
+	if query.ListOptions, err = tools.PageArgs(arguments); err != nil {
+		return query, err
+	}
+	if query.CategoryID, err = tools.UintArg(arguments, "category_id"); err != nil {
+		return query, err
+	}
+	if query.MinPrice, err = tools.FloatArg(arguments, "min_price"); err != nil {
+		return query, err
+	}
+	if query.MaxPrice, err = tools.FloatArg(arguments, "max_price"); err != nil {
+		return query, err
+	}
+	return query, nil
+}
+
 // ---- Product Details ----
 
 // GetDetailTool returns the tool definition for getting a single product by ID.
@@ -188,6 +237,7 @@ func (p *ProductToolSet) GetDetailTool() mcp.Tool {
 	return mcp.Tool{
 		Name:        "get_product",
 		Description: "Gets detailed information about a specific product by its ID, including name, description, price, stock, category, and images.",
+		Annotations: &mcp.ToolAnnotations{ReadOnlyHint: true},
 		InputSchema: mcp.InputSchema{
 			Type: "object",
 			Properties: map[string]mcp.Property{
@@ -203,52 +253,58 @@ func (p *ProductToolSet) GetDetailTool() mcp.Tool {
 
 // GetDetailHandler returns a handler that fetches a product by ID.
 func (p *ProductToolSet) GetDetailHandler() mcp.ToolHandler {
-	return func(arguments map[string]interface{}) (*mcp.ToolCallResult, error) {
-		id, ok := arguments["id"].(string)
-		if !ok || id == "" {
+	return func(ctx context.Context, arguments map[string]interface{}) (*mcp.ToolCallResult, error) {
+		id, err := tools.UintArg(arguments, "id")
+		if err != nil {
+			return nil, err
+		}
+		if id == 0 {
 			return nil, fmt.Errorf("product id is required")
 		}
 
 		p.logger.WithField("id", id).Info("Getting product details")
 
-		body, err := p.httpClient.Get("/products/"+id, nil)
+		product, err := p.catalog.GetProduct(ctx, id)
 		if err != nil {
 			p.logger.WithError(err).Error("Failed to get product details")
-			return nil, fmt.Errorf("failed to get product: %w", err)
-		}
-
-		var resp ProductDetailResponse
-		if err := json.Unmarshal(body, &resp); err != nil {
-			p.logger.WithError(err).Error("Failed to parse product response")
-			return nil, fmt.Errorf("failed to parse product response: %w", err)
+			return nil, tools.Failed("get product", err)
 		}
 
-		p.logger.WithField("product", resp.Data.Name).Info("Product details retrieved")
+		p.logger.WithField("product", product.Name).Info("Product details retrieved")
 
 		var sb strings.Builder
-		fmt.Fprintf(&sb, "**%s** (ID: %d)\n", resp.Data.Name, resp.Data.ID)
-		fmt.Fprintf(&sb, "- SKU: %s\n", resp.Data.SKU)
-		fmt.Fprintf(&sb, "- Price: $%.2f\n", resp.Data.Price)
-		fmt.Fprintf(&sb, "- Stock: %d\n", resp.Data.Stock)
-		fmt.Fprintf(&sb, "- Category: %s\n", resp.Data.Category.Name)
-		fmt.Fprintf(&sb, "- Active: %v\n", resp.Data.IsActive)
-		fmt.Fprintf(&sb, "- Description: %s\n", resp.Data.Description)
-
-		if len(resp.Data.Images) > 0 {
+		fmt.Fprintf(&sb, "**%s** (ID: %d)\n", product.Name, product.ID)
+		fmt.Fprintf(&sb, "- SKU: %s\n", product.SKU)
+		fmt.Fprintf(&sb, "- Price: $%.2f\n", product.Price)
+		fmt.Fprintf(&sb, "- Stock: %d\n", product.Stock)
+		fmt.Fprintf(&sb, "- Category: %s\n", product.Category.Name)
+		fmt.Fprintf(&sb, "- Active: %v\n", product.IsActive)
+
+		if len(product.Images) > 0 {
 			fmt.Fprintf(&sb, "\nImages:\n")
-			for _, img := range resp.Data.Images {
+			for _, img := range product.Images {
 				fmt.Fprintf(&sb, "  - %s (%s)\n", img.URL, img.AltText)
 			}
 		}
 
+		// Embed the raw product so clients can keep it as a resource.
+		productJSON, err := json.MarshalIndent(product, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode product: %w", err)
+		}
+
+		// The summary is for everyone; the full JSON, the only place the
+		// long description appears, is background material for the model.
 		return &mcp.ToolCallResult{
 			Content: []mcp.Content{
-				{
-					Type: "text",
-					Text: sb.String(),
-				},
+				mcp.NewTextContent(sb.String()).
+					WithAudience(mcp.RoleUser, mcp.RoleAssistant),
+				mcp.NewEmbeddedResourceContent(mcp.NewTextResourceContents(
+					ProductURI(product.ID), "application/json", string(productJSON),
+				).WithAudience(mcp.RoleAssistant)).
+					WithAudience(mcp.RoleAssistant).
+					WithPriority(0.2),
 			},
 		}, nil
 	}
 }
-
@@ -0,0 +1,316 @@
+package tools_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/trenchesdeveloper/mcp-server-store/internal/auth"
+	"github.com/trenchesdeveloper/mcp-server-store/internal/client"
+	"github.com/trenchesdeveloper/mcp-server-store/internal/mcp"
+	"github.com/trenchesdeveloper/mcp-server-store/internal/mockstore"
+	"github.com/trenchesdeveloper/mcp-server-store/internal/store"
+	"github.com/trenchesdeveloper/mcp-server-store/internal/store/memory"
+	"github.com/trenchesdeveloper/mcp-server-store/internal/store/rest"
+	"github.com/trenchesdeveloper/mcp-server-store/internal/tools"
+	"github.com/trenchesdeveloper/mcp-server-store/internal/tools/account"
+	"github.com/trenchesdeveloper/mcp-server-store/internal/tools/cart"
+	"github.com/trenchesdeveloper/mcp-server-store/internal/tools/orders"
+	"github.com/trenchesdeveloper/mcp-server-store/internal/tools/products"
+)
+
+// toolSets are the tool sets wired to one store, as the server wires them.
+// account is nil for stores without logins.
+type toolSets struct {
+	account  *account.AccountToolSet
+	products *products.ProductToolSet
+	cart     *cart.CartToolSet
+	orders   *orders.OrderToolSet
+}
+
+func quietLogger() *logrus.Logger {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	return logger
+}
+
+// newMockstoreToolSets serves a fresh mockstore with httptest and returns
+// tool sets calling it through the REST backend.
+func newMockstoreToolSets(t *testing.T) *toolSets {
+	t.Helper()
+	ts := httptest.NewServer(mockstore.NewServer(nil))
+	t.Cleanup(ts.Close)
+
+	logger := quietLogger()
+	httpClient := client.NewRestClient(ts.URL+mockstore.DefaultBasePath, "", logger)
+	backend := rest.New(httpClient)
+	sessions := auth.NewManager(httpClient, logger)
+	return &toolSets{
+		account:  account.NewAccountToolSet(sessions, logger),
+		products: products.NewProductToolSet(backend, logger),
+		cart:     cart.NewCartToolSet(auth.Cart(backend, sessions), logger),
+		orders:   orders.NewOrderToolSet(auth.Orders(backend, sessions), logger),
+	}
+}
+
+// newMemoryToolSets returns tool sets on an in-memory store holding the
+// mockstore's sample catalog.
+func newMemoryToolSets(t *testing.T) *toolSets {
+	t.Helper()
+	data, err := json.Marshal(mockstore.DefaultFixtures().Products)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var catalog []store.Product
+	if err := json.Unmarshal(data, &catalog); err != nil {
+		t.Fatal(err)
+	}
+
+	logger := quietLogger()
+	backend := memory.New(catalog)
+	return &toolSets{
+		products: products.NewProductToolSet(backend, logger),
+		cart:     cart.NewCartToolSet(backend, logger),
+		orders:   orders.NewOrderToolSet(backend, logger),
+	}
+}
+
+// forEachBackend runs test against tool sets on each store backend.
+func forEachBackend(t *testing.T, test func(t *testing.T, s *toolSets)) {
+	for _, backend := range []struct {
+		name string
+		new  func(*testing.T) *toolSets
+	}{
+		{"rest", newMockstoreToolSets},
+		{"memory", newMemoryToolSets},
+	} {
+		t.Run(backend.name, func(t *testing.T) { test(t, backend.new(t)) })
+	}
+}
+
+func (s *toolSets) login(t *testing.T) {
+	t.Helper()
+	if s.account == nil {
+		return
+	}
+	call(t, s.account.LoginHandler(), map[string]interface{}{
+		"email":    "alice@example.com",
+		"password": "password123",
+	})
+}
+
+func call(t *testing.T, handler mcp.ToolHandler, arguments map[string]interface{}) *mcp.ToolCallResult {
+	t.Helper()
+	result, err := handler(context.Background(), arguments)
+	if err != nil {
+		t.Fatalf("tool call failed: %v", err)
+	}
+	return result
+}
+
+// text joins the text content of result that is meant for role.
+func text(result *mcp.ToolCallResult, role mcp.Role) string {
+	var parts []string
+	for _, c := range result.Content {
+		if c.Type != mcp.ContentTypeText {
+			continue
+		}
+		if c.Annotations != nil && len(c.Annotations.Audience) > 0 && !slices.Contains(c.Annotations.Audience, role) {
+			continue
+		}
+		parts = append(parts, c.Text)
+	}
+	return strings.Join(parts, "\n")
+}
+
+func TestProductTools(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, s *toolSets) {
+		list := text(call(t, s.products.ListHandler(), map[string]interface{}{"limit": "3"}), mcp.RoleAssistant)
+		if !strings.Contains(list, "Ceramic Coffee Mug") {
+			t.Errorf("list_products = %q, want the mug on the first page", list)
+		}
+
+		search := text(call(t, s.products.SearchHandler(), map[string]interface{}{"q": "skillet"}), mcp.RoleAssistant)
+		if !strings.Contains(search, "Cast Iron Skillet") || strings.Contains(search, "Ceramic Coffee Mug") {
+			t.Errorf("search_products(skillet) = %q, want only the skillet", search)
+		}
+
+		bySKU := text(call(t, s.products.SearchHandler(), map[string]interface{}{"q": "KIT-SKL"}), mcp.RoleAssistant)
+		if !strings.Contains(bySKU, "Cast Iron Skillet") {
+			t.Errorf("search_products(KIT-SKL) = %q, want the skillet by its SKU", bySKU)
+		}
+
+		_, err := s.products.GetDetailHandler()(context.Background(), map[string]interface{}{"id": "999"})
+		if !errors.Is(err, store.ErrNotFound) {
+			t.Errorf("get_product of a missing product = %v, want ErrNotFound", err)
+		}
+	})
+}
+
+func TestGetProductKeepsDescriptionFromUser(t *testing.T) {
+	s := newMockstoreToolSets(t)
+	const description = "350ml stoneware mug"
+
+	result := call(t, s.products.GetDetailHandler(), map[string]interface{}{"id": "1"})
+	if summary := text(result, mcp.RoleUser); !strings.Contains(summary, "KIT-MUG-001") || strings.Contains(summary, description) {
+		t.Errorf("user-facing summary = %q, want the SKU and not the description", summary)
+	}
+
+	var embedded *mcp.ResourceContents
+	for _, c := range result.Content {
+		if c.Type == mcp.ContentTypeResource {
+			embedded = c.Resource
+		}
+	}
+	if embedded == nil || embedded.URI != products.ProductURI(1) || !strings.Contains(embedded.Text, description) {
+		t.Errorf("embedded resource = %+v, want product 1 with its description", embedded)
+	}
+}
+
+func TestProductResourceTemplate(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, s *toolSets) {
+		read := s.products.ProductResourceHandler()
+
+		result, err := read(context.Background(), products.ProductURI(2))
+		if err != nil {
+			t.Fatalf("read %s: %v", products.ProductURI(2), err)
+		}
+		if len(result.Contents) != 1 || !strings.Contains(result.Contents[0].Text, "KIT-SKL-026") {
+			t.Errorf("read product 2 = %+v, want the skillet", result.Contents)
+		}
+
+		for _, uri := range []string{"store://products/x", "store://products/0", "store://orders/1"} {
+			if _, err := read(context.Background(), uri); err == nil {
+				t.Errorf("read %s succeeded", uri)
+			}
+		}
+	})
+}
+
+func TestCartRequiresLogin(t *testing.T) {
+	s := newMockstoreToolSets(t)
+
+	_, err := s.cart.ViewCartHandler()(context.Background(), nil)
+	if err == nil || !strings.Contains(err.Error(), "not logged in") {
+		t.Fatalf("view_cart before login = %v, want not logged in", err)
+	}
+
+	s.login(t)
+	if cart := text(call(t, s.cart.ViewCartHandler(), nil), mcp.RoleAssistant); !strings.Contains(cart, "empty") {
+		t.Errorf("view_cart after login = %q, want an empty cart", cart)
+	}
+}
+
+func TestOrderFlow(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, s *toolSets) {
+		s.login(t)
+
+		_, err := s.orders.CreateOrderHandler()(context.Background(), nil)
+		if !errors.Is(err, store.ErrCartEmpty) {
+			t.Errorf("place_order with an empty cart = %v, want ErrCartEmpty", err)
+		}
+
+		call(t, s.cart.AddToCartHandler(), map[string]interface{}{"product_id": "1", "quantity": "2"})
+		if cart := text(call(t, s.cart.ViewCartHandler(), nil), mcp.RoleAssistant); !strings.Contains(cart, "Total: $25.00") {
+			t.Errorf("view_cart = %q, want a total of $25.00", cart)
+		}
+
+		placed := text(call(t, s.orders.CreateOrderHandler(), nil), mcp.RoleUser)
+		if !strings.Contains(placed, "Order #1 created") {
+			t.Fatalf("place_order = %q, want order #1", placed)
+		}
+
+		listed := call(t, s.orders.ListOrdersHandler(), nil)
+		var links []string
+		for _, c := range listed.Content {
+			if c.Type == mcp.ContentTypeResourceLink {
+				links = append(links, c.URI)
+			}
+		}
+		if len(links) != 1 || links[0] != orders.OrderURI(1) {
+			t.Fatalf("list_orders links = %v, want [%s]", links, orders.OrderURI(1))
+		}
+
+		read, err := s.orders.OrderResourceHandler()(context.Background(), links[0])
+		if err != nil {
+			t.Fatalf("read %s: %v", links[0], err)
+		}
+		if len(read.Contents) != 1 || !strings.Contains(read.Contents[0].Text, `"status": "pending"`) {
+			t.Errorf("read %s = %+v, want a pending order", links[0], read.Contents)
+		}
+		if _, err := s.orders.OrderResourceHandler()(context.Background(), orders.OrderURI(99)); !errors.Is(err, store.ErrNotFound) {
+			t.Errorf("read %s = %v, want ErrNotFound", orders.OrderURI(99), err)
+		}
+
+		cancelled := text(call(t, s.orders.CancelOrderHandler(), map[string]interface{}{"id": "1"}), mcp.RoleUser)
+		if !strings.Contains(cancelled, "cancelled") {
+			t.Errorf("cancel_order = %q, want it cancelled", cancelled)
+		}
+		_, err = s.orders.CancelOrderHandler()(context.Background(), map[string]interface{}{"id": "1"})
+		if !errors.Is(err, store.ErrOrderNotPending) {
+			t.Errorf("cancelling a cancelled order = %v, want ErrOrderNotPending", err)
+		}
+	})
+}
+
+func TestAddToCartBeyondStock(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, s *toolSets) {
+		s.login(t)
+
+		_, err := s.cart.AddToCartHandler()(context.Background(), map[string]interface{}{"product_id": "2", "quantity": "13"})
+		if !errors.Is(err, store.ErrInsufficientStock) {
+			t.Errorf("add_to_cart of 13 of 12 skillets = %v, want ErrInsufficientStock", err)
+		}
+	})
+}
+
+func TestIdempotencyKeyRepeatsResult(t *testing.T) {
+	s := newMockstoreToolSets(t)
+	s.login(t)
+	call(t, s.cart.AddToCartHandler(), map[string]interface{}{"product_id": "2"})
+
+	key := map[string]interface{}{tools.IdempotencyKeyArg: "order-1"}
+	first := text(call(t, s.orders.CreateOrderHandler(), key), mcp.RoleUser)
+	again := text(call(t, s.orders.CreateOrderHandler(), key), mcp.RoleUser)
+	if first != again {
+		t.Errorf("repeated place_order = %q, want the original %q", again, first)
+	}
+
+	listed := text(call(t, s.orders.ListOrdersHandler(), nil), mcp.RoleAssistant)
+	if !strings.Contains(listed, "Found 1 orders") {
+		t.Errorf("list_orders = %q, want one order", listed)
+	}
+
+	call(t, s.cart.AddToCartHandler(), map[string]interface{}{"product_id": "1", tools.IdempotencyKeyArg: "add-1"})
+	_, err := s.cart.AddToCartHandler()(context.Background(), map[string]interface{}{"product_id": "5", tools.IdempotencyKeyArg: "add-1"})
+	if err == nil || !strings.Contains(err.Error(), "other arguments") {
+		t.Errorf("reusing a key with other arguments = %v, want it refused", err)
+	}
+}
+
+func TestIdempotencyKeyRepeatsAcrossSessions(t *testing.T) {
+	s := newMockstoreToolSets(t)
+	s.login(t)
+	call(t, s.cart.AddToCartHandler(), map[string]interface{}{"product_id": "2"})
+
+	// A client that timed out reconnects and retries in a new session; the
+	// result kept here is per session, so the API has to spot the repeat.
+	key := map[string]interface{}{tools.IdempotencyKeyArg: "order-1"}
+	first, err := s.orders.CreateOrderHandler()(mcp.ContextWithSessionID(context.Background(), "first"), key)
+	if err != nil {
+		t.Fatalf("place_order: %v", err)
+	}
+	again, err := s.orders.CreateOrderHandler()(mcp.ContextWithSessionID(context.Background(), "second"), key)
+	if err != nil {
+		t.Fatalf("place_order repeated from another session: %v", err)
+	}
+	if text(first, mcp.RoleUser) != text(again, mcp.RoleUser) {
+		t.Errorf("repeated place_order = %q, want the original %q", text(again, mcp.RoleUser), text(first, mcp.RoleUser))
+	}
+}
@@ -0,0 +1,273 @@
+package transcript
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/trenchesdeveloper/mcp-server-store/internal/mcp"
+)
+
+// ---- HTTP replay ----
+
+// ReplayTransport answers HTTP requests from the exchanges recorded in a
+// transcript. Requests are matched on the session of their context, method,
+// path and query, ignoring the host, so two sessions reading the same path,
+// such as their carts, each get their own recorded responses. Recorded
+// responses for the same request are served in order.
+type ReplayTransport struct {
+	mu        sync.Mutex
+	exchanges map[string][]*HTTPExchange
+}
+
+// NewReplayTransport builds a transport from the HTTP entries of a transcript.
+func NewReplayTransport(entries []Entry) *ReplayTransport {
+	t := &ReplayTransport{exchanges: make(map[string][]*HTTPExchange)}
+	for _, e := range entries {
+		if e.Kind != KindHTTP || e.HTTP == nil {
+			continue
+		}
+		u, err := url.Parse(e.HTTP.URL)
+		if err != nil {
+			continue
+		}
+		session := e.Session
+		if session == "" {
+			session = mcp.SessionIDFromContext(context.Background())
+		}
+		key := exchangeKey(session, e.HTTP.Method, u)
+		t.exchanges[key] = append(t.exchanges[key], e.HTTP)
+	}
+	return t
+}
+
+func exchangeKey(session, method string, u *url.URL) string {
+	return session + " " + method + " " + u.RequestURI()
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *ReplayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+
+	key := exchangeKey(mcp.SessionIDFromContext(req.Context()), req.Method, req.URL)
+	t.mu.Lock()
+	queue := t.exchanges[key]
+	if len(queue) == 0 {
+		t.mu.Unlock()
+		return nil, fmt.Errorf("transcript: no recorded response for %s", key)
+	}
+	exchange := queue[0]
+	t.exchanges[key] = queue[1:]
+	t.mu.Unlock()
+
+	if exchange.Error != "" {
+		return nil, errors.New(exchange.Error)
+	}
+
+	header := exchange.Header.Clone()
+	if header == nil {
+		header = make(http.Header)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", exchange.Status, http.StatusText(exchange.Status)),
+		StatusCode:    exchange.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(strings.NewReader(exchange.ResponseBody)),
+		ContentLength: int64(len(exchange.ResponseBody)),
+		Request:       req,
+	}, nil
+}
+
+// Remaining returns how many recorded exchanges were never requested.
+func (t *ReplayTransport) Remaining() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	n := 0
+	for _, queue := range t.exchanges {
+		n += len(queue)
+	}
+	return n
+}
+
+// ---- Session replay ----
+
+// Handler handles one encoded JSON-RPC message and returns the encoded
+// response, or nil for notifications. mcp.Server implements it.
+type Handler interface {
+	HandleMessage(ctx context.Context, msg []byte) []byte
+}
+
+// Mismatch is a request whose replayed response differs from the recording.
+type Mismatch struct {
+	Session  string          `json:"session,omitempty"`
+	ID       json.RawMessage `json:"id"`
+	Method   string          `json:"method"`
+	Expected json.RawMessage `json:"expected"`
+	Actual   json.RawMessage `json:"actual"`
+}
+
+// Report summarizes a replay.
+type Report struct {
+	Requests   int        `json:"requests"`
+	Mismatches []Mismatch `json:"mismatches"`
+}
+
+// Replay feeds every inbound message of the transcript into h, in order and
+// in the session it was recorded in, and compares each response with the
+// one recorded for the same request id in the same session.
+// Server-initiated notifications in the recording are ignored.
+func Replay(ctx context.Context, entries []Entry, h Handler) (*Report, error) {
+	recorded := make(map[responseKey]json.RawMessage)
+	for _, e := range entries {
+		if e.Kind != KindOutbound {
+			continue
+		}
+		var head struct {
+			ID     json.RawMessage `json:"id"`
+			Method string          `json:"method"`
+		}
+		if err := json.Unmarshal(e.Message, &head); err != nil || head.Method != "" {
+			continue
+		}
+		recorded[responseKey{e.Session, idKey(head.ID)}] = e.Message
+	}
+
+	report := &Report{Mismatches: []Mismatch{}}
+	for _, e := range entries {
+		if e.Kind != KindInbound {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+
+		msg := []byte(e.Message)
+		// Malformed input was recorded as a JSON string; replay the original bytes.
+		var text string
+		if json.Unmarshal(msg, &text) == nil {
+			msg = []byte(text)
+		}
+
+		var head struct {
+			ID     json.RawMessage `json:"id"`
+			Method string          `json:"method"`
+		}
+		json.Unmarshal(msg, &head)
+
+		sessionCtx := ctx
+		if e.Session != "" {
+			sessionCtx = mcp.ContextWithSessionID(ctx, e.Session)
+		}
+		actual := h.HandleMessage(sessionCtx, msg)
+		if actual == nil {
+			continue
+		}
+		report.Requests++
+
+		expected := recorded[responseKey{e.Session, idKey(head.ID)}]
+		if !jsonEqual(expected, actual) {
+			report.Mismatches = append(report.Mismatches, Mismatch{
+				Session:  e.Session,
+				ID:       head.ID,
+				Method:   head.Method,
+				Expected: expected,
+				Actual:   actual,
+			})
+		}
+	}
+	return report, nil
+}
+
+// responseKey identifies a request within a transcript: request ids are
+// only unique within a session.
+type responseKey struct {
+	session string
+	id      string
+}
+
+// idKey normalizes a request id so 1 and "1" stay distinct but formatting
+// differences do not matter.
+func idKey(id json.RawMessage) string {
+	var buf bytes.Buffer
+	if len(id) == 0 || json.Compact(&buf, id) != nil {
+		return "null"
+	}
+	return buf.String()
+}
+
+func jsonEqual(a, b json.RawMessage) bool {
+	if len(a) == 0 || len(b) == 0 {
+		return len(a) == len(b)
+	}
+	var va, vb interface{}
+	if json.Unmarshal(a, &va) != nil || json.Unmarshal(b, &vb) != nil {
+		return bytes.Equal(a, b)
+	}
+	return reflect.DeepEqual(va, vb)
+}
+
+// Diff renders a line diff between the indented expected and actual
+// responses, prefixing removed lines with "-" and added lines with "+".
+func (m Mismatch) Diff() string {
+	expected := indentLines(m.Expected)
+	actual := indentLines(m.Actual)
+
+	// Longest common subsequence over lines; responses are small.
+	lcs := make([][]int, len(expected)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(actual)+1)
+	}
+	for i := len(expected) - 1; i >= 0; i-- {
+		for j := len(actual) - 1; j >= 0; j-- {
+			if expected[i] == actual[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var b strings.Builder
+	i, j := 0, 0
+	for i < len(expected) || j < len(actual) {
+		switch {
+		case i < len(expected) && j < len(actual) && expected[i] == actual[j]:
+			fmt.Fprintf(&b, "  %s\n", expected[i])
+			i++
+			j++
+		case i < len(expected) && (j == len(actual) || lcs[i+1][j] >= lcs[i][j+1]):
+			fmt.Fprintf(&b, "- %s\n", expected[i])
+			i++
+		default:
+			fmt.Fprintf(&b, "+ %s\n", actual[j])
+			j++
+		}
+	}
+	return b.String()
+}
+
+func indentLines(raw json.RawMessage) []string {
+	if len(raw) == 0 {
+		return nil
+	}
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, raw, "", "  "); err != nil {
+		return strings.Split(string(raw), "\n")
+	}
+	return strings.Split(buf.String(), "\n")
+}
@@ -0,0 +1,84 @@
+package transcript
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/trenchesdeveloper/mcp-server-store/internal/mcp"
+)
+
+// sessionEcho answers every request with the session it was handled in.
+type sessionEcho struct{}
+
+func (sessionEcho) HandleMessage(ctx context.Context, msg []byte) []byte {
+	var req struct {
+		ID json.RawMessage `json:"id"`
+	}
+	json.Unmarshal(msg, &req)
+	return []byte(fmt.Sprintf(`{"jsonrpc":"2.0","id":%s,"result":{"session":%q}}`, req.ID, mcp.SessionIDFromContext(ctx)))
+}
+
+func TestReplayMatchesResponsesBySession(t *testing.T) {
+	entry := func(kind Kind, session, msg string) Entry {
+		return Entry{Kind: kind, Session: session, Message: json.RawMessage(msg)}
+	}
+	// Both sessions use request id 1; the responses are interleaved.
+	entries := []Entry{
+		entry(KindInbound, "a", `{"jsonrpc":"2.0","id":1,"method":"ping"}`),
+		entry(KindInbound, "b", `{"jsonrpc":"2.0","id":1,"method":"ping"}`),
+		entry(KindOutbound, "b", `{"jsonrpc":"2.0","id":1,"result":{"session":"b"}}`),
+		entry(KindOutbound, "a", `{"jsonrpc":"2.0","id":1,"result":{"session":"a"}}`),
+	}
+
+	report, err := Replay(context.Background(), entries, sessionEcho{})
+	if err != nil {
+		t.Fatalf("Replay: %v", err)
+	}
+	if report.Requests != 2 || len(report.Mismatches) != 0 {
+		t.Errorf("report = %+v, want 2 requests matching their own session's responses", report)
+	}
+}
+
+func TestReplayTransportMatchesExchangesBySession(t *testing.T) {
+	exchange := func(session, body string) Entry {
+		return Entry{Kind: KindHTTP, Session: session, HTTP: &HTTPExchange{
+			Method:       http.MethodGet,
+			URL:          "http://api.example.com/api/v1/cart",
+			Status:       http.StatusOK,
+			ResponseBody: body,
+		}}
+	}
+	// Recorded in the opposite order to the replay below.
+	transport := NewReplayTransport([]Entry{
+		exchange("b", `{"owner":"b"}`),
+		exchange("a", `{"owner":"a"}`),
+		exchange("", `{"owner":"stdio"}`),
+	})
+
+	for _, session := range []string{"a", "b", "stdio"} {
+		ctx := mcp.ContextWithSessionID(context.Background(), session)
+		req := httptest.NewRequestWithContext(ctx, http.MethodGet, "http://localhost:8080/api/v1/cart", nil)
+		resp, err := transport.RoundTrip(req)
+		if err != nil {
+			t.Fatalf("session %s: %v", session, err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		if want := `{"owner":"` + session + `"}`; string(body) != want {
+			t.Errorf("session %s got %s, want %s", session, body, want)
+		}
+	}
+	if n := transport.Remaining(); n != 0 {
+		t.Errorf("remaining exchanges = %d, want 0", n)
+	}
+
+	ctx := mcp.ContextWithSessionID(context.Background(), "c")
+	req := httptest.NewRequestWithContext(ctx, http.MethodGet, "http://localhost:8080/api/v1/cart", nil)
+	if _, err := transport.RoundTrip(req); err == nil {
+		t.Error("a session without recorded exchanges got a response")
+	}
+}
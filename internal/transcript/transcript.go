@@ -0,0 +1,219 @@
+// Package transcript records MCP sessions to JSONL files and replays them.
+//
+// A transcript holds every JSON-RPC message exchanged with the client plus
+// every HTTP exchange with the store API, each with a timestamp. Replaying
+// a transcript feeds the recorded requests back into a server whose API
+// calls are answered from the recorded HTTP exchanges, and reports any
+// response that differs from the recording.
+package transcript
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/trenchesdeveloper/mcp-server-store/internal/jsonrpc"
+	"github.com/trenchesdeveloper/mcp-server-store/internal/mcp"
+)
+
+// Kind identifies what an entry holds.
+type Kind string
+
+const (
+	KindInbound  Kind = "in"   // JSON-RPC message from the client
+	KindOutbound Kind = "out"  // JSON-RPC message to the client
+	KindHTTP     Kind = "http" // request to and response from the store API
+)
+
+// Entry is one line of a transcript. Session is the MCP session the entry
+// belongs to ("stdio" for the stdio transport); the HTTP transport serves
+// several sessions into one transcript, and their request ids overlap.
+type Entry struct {
+	Time    time.Time       `json:"time"`
+	Kind    Kind            `json:"kind"`
+	Session string          `json:"session,omitempty"`
+	Message json.RawMessage `json:"message,omitempty"`
+	HTTP    *HTTPExchange   `json:"http,omitempty"`
+}
+
+// HTTPExchange is a recorded store API call. Neither request headers nor
+// the bodies of calls to the /auth/ endpoints are kept, and the server
+// masks tool arguments marked sensitive before recording them, so that
+// passwords and tokens stay out of transcripts. A replayed login gets an
+// empty response and is reported as a mismatch.
+type HTTPExchange struct {
+	Method       string      `json:"method"`
+	URL          string      `json:"url"`
+	RequestBody  string      `json:"requestBody,omitempty"`
+	Status       int         `json:"status,omitempty"`
+	Header       http.Header `json:"header,omitempty"`
+	ResponseBody string      `json:"responseBody,omitempty"`
+	Error        string      `json:"error,omitempty"`
+}
+
+// ---- Recording ----
+
+// Recorder appends entries to a transcript. It implements jsonrpc.Recorder
+// and can wrap an http.RoundTripper; it is safe for concurrent use.
+type Recorder struct {
+	mu     sync.Mutex
+	enc    *json.Encoder
+	closer io.Closer
+	now    func() time.Time
+}
+
+// NewRecorder writes transcript entries to w.
+func NewRecorder(w io.Writer) *Recorder {
+	r := &Recorder{enc: json.NewEncoder(w), now: time.Now}
+	if c, ok := w.(io.Closer); ok {
+		r.closer = c
+	}
+	return r
+}
+
+// Create opens path for appending and returns a recorder writing to it.
+// The file is created readable only by the current user since it holds
+// customer data.
+func Create(path string) (*Recorder, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("open transcript: %w", err)
+	}
+	return NewRecorder(f), nil
+}
+
+// RecordMessage records a JSON-RPC message of the session ctx belongs to.
+func (r *Recorder) RecordMessage(ctx context.Context, direction jsonrpc.Direction, msg []byte) {
+	kind := KindInbound
+	if direction == jsonrpc.Outbound {
+		kind = KindOutbound
+	}
+	// Keep malformed input as a JSON string so the line stays valid.
+	raw := json.RawMessage(msg)
+	if !json.Valid(msg) {
+		raw, _ = json.Marshal(string(msg))
+	}
+	r.write(Entry{
+		Kind:    kind,
+		Session: mcp.SessionIDFromContext(ctx),
+		Message: append(json.RawMessage(nil), raw...),
+	})
+}
+
+// Close closes the underlying writer if it is closable.
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closer == nil {
+		return nil
+	}
+	return r.closer.Close()
+}
+
+func (r *Recorder) write(e Entry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	e.Time = r.now().UTC()
+	// A failed write must never break the session being recorded.
+	_ = r.enc.Encode(e)
+}
+
+// RoundTripper returns a transport that records every exchange made
+// through next.
+func (r *Recorder) RoundTripper(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &recordingTransport{recorder: r, next: next}
+}
+
+type recordingTransport struct {
+	recorder *Recorder
+	next     http.RoundTripper
+}
+
+// isAuthPath reports whether path is an authentication endpoint, whose
+// bodies carry passwords and tokens.
+func isAuthPath(path string) bool {
+	return strings.Contains(path, "/auth/")
+}
+
+func (t *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	exchange := &HTTPExchange{Method: req.Method, URL: req.URL.String()}
+	session := mcp.SessionIDFromContext(req.Context())
+	secret := isAuthPath(req.URL.Path)
+
+	if req.Body != nil && !secret {
+		body, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		exchange.RequestBody = string(body)
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		exchange.Error = err.Error()
+		t.recorder.write(Entry{Kind: KindHTTP, Session: session, HTTP: exchange})
+		return nil, err
+	}
+
+	exchange.Status = resp.StatusCode
+	exchange.Header = resp.Header.Clone()
+	if !secret {
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+		exchange.ResponseBody = string(body)
+	}
+	t.recorder.write(Entry{Kind: KindHTTP, Session: session, HTTP: exchange})
+	return resp, nil
+}
+
+// ---- Loading ----
+
+// Load reads a transcript file.
+func Load(path string) ([]Entry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open transcript: %w", err)
+	}
+	defer f.Close()
+
+	entries, err := Read(f)
+	if err != nil {
+		return nil, fmt.Errorf("transcript %s: %w", path, err)
+	}
+	return entries, nil
+}
+
+// Read decodes transcript entries, one JSON object per line.
+func Read(r io.Reader) ([]Entry, error) {
+	var entries []Entry
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		entries = append(entries, e)
+	}
+	return entries, scanner.Err()
+}